@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"profilego/internal/client"
+	"profilego/internal/config"
+	"profilego/internal/domain"
+	"profilego/internal/geo"
+	"profilego/internal/repository"
+	"profilego/internal/service"
+	"profilego/pkg/db"
+)
+
+// runGeo implementa los subcomandos de geografía:
+//
+//	profilego geo load [localidades.csv] [flags]  reemplaza el catálogo de localidades por el
+//	                                              incluido en el binario o por el del archivo.
+//	profilego geo regeocode [all] [flags]         geocodifica las direcciones pendientes, o todas
+//	                                              las activas con "all".
+func runGeo(args []string) {
+	if len(args) == 0 {
+		log.Fatal("uso: profilego geo load [localidades.csv] | regeocode [all] [flags]")
+	}
+
+	switch args[0] {
+	case "load":
+		runGeoLoad(args[1:])
+	case "regeocode":
+		runGeoRegeocode(args[1:])
+	default:
+		log.Fatalf("subcomando desconocido %q", args[0])
+	}
+}
+
+func runGeoLoad(args []string) {
+	file := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		file, args = args[0], args[1:]
+	}
+
+	localities, err := readLocalities(file)
+	if err != nil {
+		log.Fatalf("❌ Error leyendo el catálogo de localidades: %v", err)
+	}
+
+	cfg, err := config.Load(args)
+	if err != nil {
+		log.Fatalf("❌ Error cargando la configuración: %v", err)
+	}
+
+	database, err := db.InitDB(cfg.DB.DSN(), cfg.DB.ConnectTimeout)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer database.Close()
+
+	if err := repository.NewLocalityRepository(database).ReplaceLocalities(context.Background(), localities); err != nil {
+		log.Fatalf("❌ Error cargando el catálogo de localidades: %v", err)
+	}
+	fmt.Printf("✅ %d localidades cargadas\n", len(localities))
+}
+
+func runGeoRegeocode(args []string) {
+	all := len(args) > 0 && args[0] == "all"
+	if all {
+		args = args[1:]
+	}
+
+	cfg, err := config.Load(args)
+	if err != nil {
+		log.Fatalf("❌ Error cargando la configuración: %v", err)
+	}
+
+	geocoder, err := newGeocoder(cfg.Geo)
+	if err != nil {
+		log.Fatalf("❌ Error creando el geocodificador: %v", err)
+	}
+	if geocoder == nil {
+		log.Fatal("❌ La geocodificación está deshabilitada (geo.geocoder=none)")
+	}
+
+	database, err := db.InitDB(cfg.DB.DSN(), cfg.DB.ConnectTimeout)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer database.Close()
+
+	geocoding := service.NewGeocodingService(repository.NewAddressRepository(database), geocoder, cfg.Geo.GeocoderQueueSize)
+	n, err := geocoding.RegeocodePending(context.Background(), all)
+	if err != nil {
+		log.Fatalf("❌ Error geocodificando direcciones (%d procesadas): %v", n, err)
+	}
+	fmt.Printf("✅ %d direcciones geocodificadas\n", n)
+}
+
+// newGeocoder crea el geocodificador configurado, o nil si la geocodificación está deshabilitada.
+func newGeocoder(cfg config.GeoConfig) (geo.Geocoder, error) {
+	var geocoder geo.Geocoder
+	switch cfg.Geocoder {
+	case "none":
+		return nil, nil
+	case "local":
+		local, err := readCoordinates(cfg.GeocoderFile)
+		if err != nil {
+			return nil, err
+		}
+		geocoder = local
+	case "http":
+		geocoder = client.NewGeocodingClient(cfg.GeocoderURL, client.GeocodingClientOptions{
+			Timeout:       cfg.GeocoderTimeout,
+			RatePerSecond: cfg.GeocoderRate,
+			UserAgent:     cfg.GeocoderUserAgent,
+		})
+	default:
+		return nil, fmt.Errorf("geocodificador desconocido %q", cfg.Geocoder)
+	}
+	if cfg.GeocoderCacheSize > 0 {
+		geocoder = geo.NewCachedGeocoder(geocoder, cfg.GeocoderCacheSize)
+	}
+	return geocoder, nil
+}
+
+func readCoordinates(path string) (*geo.LocalGeocoder, error) {
+	if path == "" {
+		return geo.BundledGeocoder()
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return geo.NewLocalGeocoder(f)
+}
+
+func readLocalities(path string) ([]domain.Locality, error) {
+	if path == "" {
+		return geo.BundledLocalities()
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return geo.ReadLocalities(f)
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"profilego/internal/domain"
+	"profilego/internal/metrics"
+	"profilego/pkg/logger"
+)
+
+// UserResolver obtiene el usuario autenticado a partir de un access token.
+// Lo implementan client.AuthClient (llamada remota) y Authenticator.
+type UserResolver interface {
+	GetCurrentUser(ctx context.Context, token string) (*domain.AuthUser, error)
+}
+
+// Authenticator verifica los tokens localmente y, si está habilitado, recurre al servicio
+// de autenticación cuando el token no puede verificarse localmente: no es un JWT (token opaco)
+// o su clave de firma es desconocida o no está disponible. Un token vencido o con firma
+// inválida se rechaza sin consultar al servicio remoto.
+type Authenticator struct {
+	Local    *Verifier
+	Remote   UserResolver
+	Fallback bool
+}
+
+// NewAuthenticator crea un Authenticator. Si local es nil todos los tokens se resuelven remotamente.
+func NewAuthenticator(local *Verifier, remote UserResolver, fallback bool) *Authenticator {
+	return &Authenticator{Local: local, Remote: remote, Fallback: fallback}
+}
+
+// GetCurrentUser implementa UserResolver.
+func (a *Authenticator) GetCurrentUser(ctx context.Context, token string) (*domain.AuthUser, error) {
+	if a.Local == nil {
+		return a.remote(ctx, token)
+	}
+
+	user, err := a.Local.Verify(ctx, token)
+	if err == nil {
+		metrics.AuthVerificationsTotal.WithLabelValues("local", "success").Inc()
+		return user, nil
+	}
+	metrics.AuthVerificationsTotal.WithLabelValues("local", "failure").Inc()
+
+	if errors.Is(err, ErrInvalidToken) || !a.Fallback || a.Remote == nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Debug("token no verificable localmente, se consulta al servicio de autenticación", "error", err)
+	return a.remote(ctx, token)
+}
+
+func (a *Authenticator) remote(ctx context.Context, token string) (*domain.AuthUser, error) {
+	user, err := a.Remote.GetCurrentUser(ctx, token)
+	if err != nil {
+		metrics.AuthVerificationsTotal.WithLabelValues("remote", "failure").Inc()
+		return nil, err
+	}
+	metrics.AuthVerificationsTotal.WithLabelValues("remote", "success").Inc()
+	return user, nil
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"profilego/internal/domain"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// fakeResolver es un UserResolver remoto que cuenta las consultas.
+type fakeResolver struct {
+	calls int
+}
+
+func (f *fakeResolver) GetCurrentUser(ctx context.Context, token string) (*domain.AuthUser, error) {
+	f.calls++
+	return &domain.AuthUser{ID: "remoto", Enabled: true}, nil
+}
+
+func TestAuthenticatorFallback(t *testing.T) {
+	key := testKeys()[0]
+	verifier := NewVerifier(staticKeys{"k1": &key.PublicKey}, "https://auth.test", "profilego", 0)
+	expired := testClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name       string
+		fallback   bool
+		token      string
+		wantUser   string
+		wantErr    error
+		wantRemote int
+	}{
+		{"verificado localmente", true, sign(t, key, "k1", testClaims()), "u1", nil, 0},
+		{"vencido no consulta al remoto", true, sign(t, key, "k1", expired), "", ErrInvalidToken, 0},
+		{"kid desconocido consulta al remoto", true, sign(t, key, "k2", testClaims()), "remoto", nil, 1},
+		{"token opaco consulta al remoto", true, "opaco", "remoto", nil, 1},
+		{"sin fallback", false, sign(t, key, "k2", testClaims()), "", ErrKeyNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := &fakeResolver{}
+			user, err := NewAuthenticator(verifier, remote, tt.fallback).GetCurrentUser(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("esperaba %v, obtuve (%+v, %v)", tt.wantErr, user, err)
+				}
+			} else if err != nil || user.ID != tt.wantUser {
+				t.Fatalf("esperaba el usuario %q, obtuve (%+v, %v)", tt.wantUser, user, err)
+			}
+			if remote.calls != tt.wantRemote {
+				t.Fatalf("esperaba %d consultas remotas, hubo %d", tt.wantRemote, remote.calls)
+			}
+		})
+	}
+
+	t.Run("sin verificador local", func(t *testing.T) {
+		remote := &fakeResolver{}
+		token, _ := jwt.New(jwt.SigningMethodNone).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		if user, err := NewAuthenticator(nil, remote, false).GetCurrentUser(context.Background(), token); err != nil || user.ID != "remoto" {
+			t.Fatalf("sin verificador local todo se resuelve remotamente: (%+v, %v)", user, err)
+		}
+	})
+}
@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"profilego/pkg/logger"
+)
+
+var (
+	// ErrKeyNotFound indica que ninguna clave conocida corresponde al kid del token.
+	ErrKeyNotFound = errors.New("clave de firma desconocida")
+	// ErrKeysUnavailable indica que no se pudieron obtener las claves públicas.
+	ErrKeysUnavailable = errors.New("claves de firma no disponibles")
+)
+
+// minRefetchInterval limita las recargas forzadas por un kid desconocido.
+const minRefetchInterval = 30 * time.Second
+
+// KeySource resuelve la clave pública con la que verificar un token a partir de su kid.
+type KeySource interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// keyCache guarda un conjunto de claves y decide cuándo recargarlo.
+// La recarga ocurre al vencer `refresh` o, como máximo cada minRefetchInterval,
+// cuando llega un kid desconocido (rotación de claves).
+type keyCache struct {
+	refresh time.Duration
+	load    func(ctx context.Context) (map[string]crypto.PublicKey, error)
+
+	loadMu      sync.Mutex // serializa las recargas y protege lastAttempt/lastErr
+	lastAttempt time.Time
+	lastErr     error
+
+	mu       sync.RWMutex
+	keys     map[string]crypto.PublicKey
+	loadedAt time.Time
+}
+
+func (kc *keyCache) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	kc.mu.RLock()
+	key, found := lookup(kc.keys, kid)
+	fresh := !kc.loadedAt.IsZero() && time.Since(kc.loadedAt) < kc.refresh
+	kc.mu.RUnlock()
+	if found && fresh {
+		return key, nil
+	}
+
+	if err := kc.reload(ctx); err != nil {
+		if found {
+			// Mejor verificar con claves algo viejas que rechazar todos los requests
+			logger.FromContext(ctx).Warn("no se pudieron recargar las claves de firma, se usan las anteriores", "error", err)
+			return key, nil
+		}
+		return nil, fmt.Errorf("%w: %v", ErrKeysUnavailable, err)
+	}
+
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	if key, ok := lookup(kc.keys, kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+}
+
+// reload vuelve a cargar las claves. Para no saturar el origen (p. ej. ante tokens con kid
+// inventados o un JWKS caído) se intenta como máximo una vez cada minRefetchInterval;
+// mientras tanto se devuelve el resultado del último intento.
+func (kc *keyCache) reload(ctx context.Context) error {
+	kc.loadMu.Lock()
+	defer kc.loadMu.Unlock()
+
+	if time.Since(kc.lastAttempt) < minRefetchInterval {
+		return kc.lastErr
+	}
+	kc.lastAttempt = time.Now()
+
+	keys, err := kc.load(ctx)
+	kc.lastErr = err
+	if err != nil {
+		return err
+	}
+
+	kc.mu.Lock()
+	kc.keys = keys
+	kc.loadedAt = time.Now()
+	kc.mu.Unlock()
+	return nil
+}
+
+// lookup busca la clave por kid. Si el token no trae kid y hay una sola clave, se usa esa.
+func lookup(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if key, ok := keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// NewJWKS crea un KeySource que descarga las claves desde un endpoint JWKS
+// y las vuelve a descargar cada `refresh` o ante un kid desconocido.
+func NewJWKS(url string, refresh, timeout time.Duration) KeySource {
+	client := &http.Client{Timeout: timeout}
+	return &keyCache{
+		refresh: refresh,
+		load: func(ctx context.Context) (map[string]crypto.PublicKey, error) {
+			return fetchJWKS(ctx, client, url)
+		},
+	}
+}
+
+// NewPEMFile crea un KeySource a partir de un archivo con una o más claves públicas PEM
+// ("PUBLIC KEY", "RSA PUBLIC KEY" o "CERTIFICATE"). El kid de cada clave se toma del header
+// PEM `kid` si existe. El archivo se relee cada `refresh` para permitir la rotación.
+func NewPEMFile(path string, refresh time.Duration) KeySource {
+	return &keyCache{
+		refresh: refresh,
+		load: func(context.Context) (map[string]crypto.PublicKey, error) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			return parsePEM(data)
+		},
+	}
+}
+
+func parsePEM(data []byte) (map[string]crypto.PublicKey, error) {
+	keys := make(map[string]crypto.PublicKey)
+	for i := 0; ; i++ {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		var key crypto.PublicKey
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			cert, err = x509.ParseCertificate(block.Bytes)
+			if err == nil {
+				key = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("clave PEM %d inválida: %w", i, err)
+		}
+
+		kid := block.Headers["kid"]
+		if kid == "" && len(keys) > 0 {
+			kid = fmt.Sprintf("#%d", i)
+		}
+		keys[kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("el archivo no contiene claves públicas PEM")
+	}
+	return keys, nil
+}
+
+// jwk es una clave del documento JWKS (RFC 7517). Sólo se usan claves de firma.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func fetchJWKS(ctx context.Context, client *http.Client, url string) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS: código de estado %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("JWKS inválido: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// Una clave no soportada no invalida al resto del documento
+			logger.FromContext(ctx).Warn("clave JWKS ignorada", "kid", k.Kid, "error", err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("el JWKS no contiene claves de firma utilizables")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("exponente RSA inválido")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("curva no soportada %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("punto EC fuera de la curva")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("curva no soportada %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("clave Ed25519 inválida")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("tipo de clave no soportado %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("entero base64url inválido")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
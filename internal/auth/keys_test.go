@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeJWKS sirve un documento JWKS con las claves cargadas y cuenta las descargas.
+type fakeJWKS struct {
+	mu    sync.Mutex
+	keys  map[string]*rsa.PublicKey
+	calls atomic.Int32
+}
+
+func (f *fakeJWKS) setKeys(keys map[string]*rsa.PublicKey) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = keys
+}
+
+func (f *fakeJWKS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.calls.Add(1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	for kid, key := range f.keys {
+		doc.Keys = append(doc.Keys, jwk{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	json.NewEncoder(w).Encode(doc)
+}
+
+func TestJWKSRefreshOnUnknownKid(t *testing.T) {
+	ctx := context.Background()
+	key, rotated := testKeys()[0], testKeys()[1]
+	fake := &fakeJWKS{keys: map[string]*rsa.PublicKey{"k1": &key.PublicKey}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	keys := NewJWKS(server.URL, time.Hour, time.Second)
+	verifier := NewVerifier(keys, "https://auth.test", "profilego", 0)
+	if _, err := verifier.Verify(ctx, sign(t, key, "k1", testClaims())); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if _, err := verifier.Verify(ctx, sign(t, key, "k1", testClaims())); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Fatalf("las claves vigentes no deben volver a descargarse: %d descargas", calls)
+	}
+
+	// Rotación: un kid desconocido fuerza la recarga, pero no más de una vez cada minRefetchInterval
+	fake.setKeys(map[string]*rsa.PublicKey{"k1": &key.PublicKey, "k2": &rotated.PublicKey})
+	if _, err := verifier.Verify(ctx, sign(t, rotated, "k2", testClaims())); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("dentro de minRefetchInterval esperaba ErrKeyNotFound, obtuve %v", err)
+	}
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Fatalf("un kid desconocido no puede recargar antes de minRefetchInterval: %d descargas", calls)
+	}
+
+	cache := keys.(*keyCache)
+	cache.loadMu.Lock()
+	cache.lastAttempt = time.Now().Add(-minRefetchInterval)
+	cache.loadMu.Unlock()
+	if _, err := verifier.Verify(ctx, sign(t, rotated, "k2", testClaims())); err != nil {
+		t.Fatalf("el kid nuevo debe encontrarse al recargar: %v", err)
+	}
+	if calls := fake.calls.Load(); calls != 2 {
+		t.Fatalf("esperaba una recarga por el kid desconocido: %d descargas", calls)
+	}
+}
+
+func TestJWKSUnavailable(t *testing.T) {
+	ctx := context.Background()
+	key := testKeys()[0]
+	fake := &fakeJWKS{keys: map[string]*rsa.PublicKey{"k1": &key.PublicKey}}
+	server := httptest.NewServer(fake)
+
+	keys := NewJWKS(server.URL, time.Millisecond, time.Second)
+	if _, err := keys.Key(ctx, "k1"); err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	server.Close()
+
+	// Vencido el refresh y sin poder recargar se siguen usando las claves anteriores
+	time.Sleep(2 * time.Millisecond)
+	keys.(*keyCache).lastAttempt = time.Now().Add(-minRefetchInterval)
+	if _, err := keys.Key(ctx, "k1"); err != nil {
+		t.Fatalf("esperaba la clave anterior, obtuve %v", err)
+	}
+	if _, err := NewJWKS(server.URL, time.Hour, time.Second).Key(ctx, "k1"); !errors.Is(err, ErrKeysUnavailable) {
+		t.Fatalf("sin claves cargadas esperaba ErrKeysUnavailable, obtuve %v", err)
+	}
+}
+
+func TestPEMFile(t *testing.T) {
+	ctx := context.Background()
+	key, other := testKeys()[0], testKeys()[1]
+	dir := t.TempDir()
+
+	// Una sola clave sin kid: se usa para los tokens sin kid
+	single := filepath.Join(dir, "single.pem")
+	if err := os.WriteFile(single, publicKeyPEM(t, key), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewVerifier(NewPEMFile(single, time.Hour), "", "", 0)
+	if user, err := verifier.Verify(ctx, sign(t, key, "", testClaims())); err != nil || user.ID != "u1" {
+		t.Fatalf("Verify sin kid: (%+v, %v)", user, err)
+	}
+	if _, err := verifier.Verify(ctx, sign(t, key, "k9", testClaims())); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("un kid que no está en el archivo debe ser ErrKeyNotFound, obtuve %v", err)
+	}
+
+	// Varias claves: el kid sale del header PEM
+	withKid := func(kid string, data []byte) []byte {
+		return []byte("-----BEGIN PUBLIC KEY-----\nkid: " + kid + "\n\n" + string(data[len("-----BEGIN PUBLIC KEY-----\n"):]))
+	}
+	multi := filepath.Join(dir, "multi.pem")
+	data := append(withKid("a", publicKeyPEM(t, key)), withKid("b", publicKeyPEM(t, other))...)
+	if err := os.WriteFile(multi, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	verifier = NewVerifier(NewPEMFile(multi, time.Hour), "", "", 0)
+	if _, err := verifier.Verify(ctx, sign(t, other, "b", testClaims())); err != nil {
+		t.Fatalf("Verify con kid b: %v", err)
+	}
+	if _, err := verifier.Verify(ctx, sign(t, other, "a", testClaims())); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("firma con otra clave que la del kid: esperaba ErrInvalidToken, obtuve %v", err)
+	}
+
+	if _, err := NewPEMFile(filepath.Join(dir, "no-existe.pem"), time.Hour).Key(ctx, ""); !errors.Is(err, ErrKeysUnavailable) {
+		t.Fatalf("sin archivo esperaba ErrKeysUnavailable, obtuve %v", err)
+	}
+}
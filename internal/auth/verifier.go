@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"profilego/internal/domain"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signingMethods son los algoritmos aceptados. Sólo asimétricos: con HS* cualquiera que
+// conozca la clave pública podría firmar tokens.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// ErrInvalidToken envuelve todos los rechazos de un token que sí pudo verificarse localmente
+// (firma incorrecta, vencido, emisor o audiencia distintos).
+var ErrInvalidToken = errors.New("token inválido")
+
+// userClaims son los claims esperados en el access token emitido por el servicio de autenticación.
+type userClaims struct {
+	jwt.RegisteredClaims
+	Name        string   `json:"name"`
+	Login       string   `json:"login"`
+	Permissions []string `json:"permissions"`
+	// Enabled es opcional; si el emisor no lo incluye se asume habilitado.
+	Enabled *bool `json:"enabled"`
+}
+
+// Verifier valida access tokens JWT localmente: firma, exp, nbf, iss y aud.
+type Verifier struct {
+	Keys     KeySource
+	Issuer   string        // vacío = no se valida
+	Audience string        // vacío = no se valida
+	Leeway   time.Duration // tolerancia de reloj para exp/nbf
+}
+
+// NewVerifier crea un Verifier con las claves y restricciones indicadas.
+func NewVerifier(keys KeySource, issuer, audience string, leeway time.Duration) *Verifier {
+	return &Verifier{Keys: keys, Issuer: issuer, Audience: audience, Leeway: leeway}
+}
+
+// Verify valida el token y devuelve el usuario de sus claims.
+// Los errores de formato o de claves no disponibles se devuelven tal cual (ver Authenticator);
+// el resto se envuelve en ErrInvalidToken.
+func (v *Verifier) Verify(ctx context.Context, token string) (*domain.AuthUser, error) {
+	var claims userClaims
+	parser := jwt.NewParser(jwt.WithValidMethods(signingMethods), jwt.WithoutClaimsValidation())
+	_, err := parser.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.Keys.Key(ctx, kid)
+	})
+	if err != nil {
+		var verr *jwt.ValidationError
+		if errors.As(err, &verr) {
+			switch {
+			case errors.Is(verr.Inner, ErrKeyNotFound), errors.Is(verr.Inner, ErrKeysUnavailable):
+				return nil, verr.Inner
+			case verr.Errors&jwt.ValidationErrorMalformed != 0:
+				return nil, err
+			}
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if err := v.validateClaims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	user := &domain.AuthUser{
+		ID:          claims.Subject,
+		Name:        claims.Name,
+		Login:       claims.Login,
+		Permissions: claims.Permissions,
+		Enabled:     claims.Enabled == nil || *claims.Enabled,
+	}
+	return user, nil
+}
+
+func (v *Verifier) validateClaims(claims *userClaims) error {
+	now := time.Now()
+	if claims.ExpiresAt == nil {
+		return errors.New("falta el claim exp")
+	}
+	if !claims.VerifyExpiresAt(now.Add(-v.Leeway), true) {
+		return errors.New("token vencido")
+	}
+	if !claims.VerifyNotBefore(now.Add(v.Leeway), false) {
+		return errors.New("token todavía no válido (nbf)")
+	}
+	if v.Issuer != "" && !claims.VerifyIssuer(v.Issuer, true) {
+		return fmt.Errorf("emisor inesperado %q", claims.Issuer)
+	}
+	if v.Audience != "" && !claims.VerifyAudience(v.Audience, true) {
+		return fmt.Errorf("audiencia inesperada %v", claims.Audience)
+	}
+	if claims.Subject == "" {
+		return errors.New("falta el claim sub")
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// testKeys genera una sola vez las claves RSA de los tests (generarlas es lento).
+var testKeys = sync.OnceValue(func() []*rsa.PrivateKey {
+	keys := make([]*rsa.PrivateKey, 2)
+	for i := range keys {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		keys[i] = key
+	}
+	return keys
+})
+
+// staticKeys es un KeySource fijo.
+type staticKeys map[string]crypto.PublicKey
+
+func (s staticKeys) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := lookup(s, kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+}
+
+// testClaims son claims válidos para NewVerifier(..., "https://auth.test", "profilego", ...).
+func testClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"sub":         "u1",
+		"iss":         "https://auth.test",
+		"aud":         "profilego",
+		"exp":         now.Add(time.Hour).Unix(),
+		"iat":         now.Unix(),
+		"name":        "Ana",
+		"login":       "ana",
+		"permissions": []string{"profile:read"},
+	}
+}
+
+// sign firma claims con RS256 y la clave indicada, agregando kid si no es vacío.
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("firmando el token: %v", err)
+	}
+	return signed
+}
+
+func publicKeyPEM(t *testing.T, key *rsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestVerifierVerify(t *testing.T) {
+	key, other := testKeys()[0], testKeys()[1]
+	verifier := NewVerifier(staticKeys{"k1": &key.PublicKey}, "https://auth.test", "profilego", 30*time.Second)
+
+	with := func(change func(jwt.MapClaims)) jwt.MapClaims {
+		claims := testClaims()
+		change(claims)
+		return claims
+	}
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Downgrade: HS256 usando como secreto la clave pública, que cualquiera conoce
+	hs256 := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	hs256.Header["kid"] = "k1"
+	downgrade, err := hs256.SignedString(publicKeyPEM(t, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error // nil = válido
+	}{
+		{"válido", sign(t, key, "k1", testClaims()), nil},
+		{"vencido dentro del leeway", sign(t, key, "k1", with(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-10 * time.Second).Unix() })), nil},
+		{"vencido", sign(t, key, "k1", with(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() })), ErrInvalidToken},
+		{"sin exp", sign(t, key, "k1", with(func(c jwt.MapClaims) { delete(c, "exp") })), ErrInvalidToken},
+		{"nbf futuro", sign(t, key, "k1", with(func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Hour).Unix() })), ErrInvalidToken},
+		{"otro emisor", sign(t, key, "k1", with(func(c jwt.MapClaims) { c["iss"] = "https://evil.test" })), ErrInvalidToken},
+		{"otra audiencia", sign(t, key, "k1", with(func(c jwt.MapClaims) { c["aud"] = "otro-servicio" })), ErrInvalidToken},
+		{"audiencia en lista", sign(t, key, "k1", with(func(c jwt.MapClaims) { c["aud"] = []string{"otro", "profilego"} })), nil},
+		{"sin sub", sign(t, key, "k1", with(func(c jwt.MapClaims) { delete(c, "sub") })), ErrInvalidToken},
+		{"firmado con otra clave", sign(t, other, "k1", testClaims()), ErrInvalidToken},
+		{"alg none", none, ErrInvalidToken},
+		{"downgrade a HS256", downgrade, ErrInvalidToken},
+		{"kid desconocido", sign(t, key, "k2", testClaims()), ErrKeyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("esperaba %v, obtuve (%+v, %v)", tt.wantErr, user, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if user.ID != "u1" || user.Login != "ana" || !user.Enabled || len(user.Permissions) != 1 {
+				t.Fatalf("usuario inesperado: %+v", user)
+			}
+		})
+	}
+
+	t.Run("token que no es un JWT", func(t *testing.T) {
+		_, err := verifier.Verify(context.Background(), "opaco")
+		if err == nil || errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("un token opaco no es inválido sino no verificable localmente: %v", err)
+		}
+	})
+
+	t.Run("enabled false", func(t *testing.T) {
+		token := sign(t, key, "k1", with(func(c jwt.MapClaims) { c["enabled"] = false }))
+		user, err := verifier.Verify(context.Background(), token)
+		if err != nil || user.Enabled {
+			t.Fatalf("esperaba un usuario deshabilitado: (%+v, %v)", user, err)
+		}
+	})
+}
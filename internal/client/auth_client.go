@@ -2,23 +2,212 @@ package client
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"net/http"
 	"profilego/internal/domain"
+	"profilego/internal/metrics"
+	"profilego/pkg/logger"
+	"profilego/pkg/tracing"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	// ErrUnauthorized indica que el servicio de autenticación rechazó el token (4xx).
+	ErrUnauthorized = errors.New("token rechazado por el servicio de autenticación")
+	// ErrAuthUnavailable indica que no se pudo consultar al servicio de autenticación
+	// (error de red, timeout, 5xx/429 o circuit breaker abierto). No dice nada sobre el token.
+	ErrAuthUnavailable = errors.New("servicio de autenticación no disponible")
 )
 
+// AuthClientOptions configura el transporte HTTP y el cache de tokens de AuthClient.
+type AuthClientOptions struct {
+	Timeout      time.Duration
+	MaxIdleConns int // conexiones ociosas reutilizables hacia el servicio de autenticación
+	// CacheSize es la cantidad máxima de tokens en cache; 0 deshabilita el cache.
+	CacheSize int
+	CacheTTL  time.Duration
+	// NegativeCacheTTL es cuánto se recuerda un 401 antes de volver a consultar el token.
+	NegativeCacheTTL time.Duration
+	// StaleIfError permite usar una identidad en cache vencida hace menos de este tiempo
+	// cuando el servicio no está disponible; 0 lo deshabilita.
+	StaleIfError time.Duration
+
+	// Retries es la cantidad de reintentos ante fallas del servicio, con backoff exponencial
+	// con jitter a partir de RetryBackoff.
+	Retries      int
+	RetryBackoff time.Duration
+	// BreakerThreshold es la cantidad de fallas consecutivas que abren el circuito (0 = sin breaker);
+	// BreakerOpenTimeout es cuánto permanece abierto antes de probar de nuevo.
+	BreakerThreshold   int
+	BreakerOpenTimeout time.Duration
+}
+
 type AuthClient struct {
 	BaseURL string
+	Timeout time.Duration
+
+	httpClient       *http.Client
+	cache            *tokenCache
+	cacheTTL         time.Duration
+	negativeCacheTTL time.Duration
+	staleIfError     time.Duration
+	retries          int
+	retryBackoff     time.Duration
+	breaker          *circuitBreaker
+	// lookups agrupa las consultas concurrentes del mismo token en una sola llamada
+	lookups singleflight.Group
 }
 
-func NewAuthClient(baseURL string) *AuthClient {
-	return &AuthClient{BaseURL: baseURL}
+func NewAuthClient(baseURL string, opts AuthClientOptions) *AuthClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+		// Todas las conexiones van al mismo host
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConns
+	}
+
+	ac := &AuthClient{
+		BaseURL:          baseURL,
+		Timeout:          opts.Timeout,
+		httpClient:       &http.Client{Timeout: opts.Timeout, Transport: transport},
+		cacheTTL:         opts.CacheTTL,
+		negativeCacheTTL: opts.NegativeCacheTTL,
+		staleIfError:     opts.StaleIfError,
+		retries:          opts.Retries,
+		retryBackoff:     opts.RetryBackoff,
+	}
+	if opts.CacheSize > 0 && opts.CacheTTL > 0 {
+		ac.cache = newTokenCache(opts.CacheSize)
+	}
+	if opts.BreakerThreshold > 0 {
+		ac.breaker = newCircuitBreaker(opts.BreakerThreshold, opts.BreakerOpenTimeout)
+	}
+	return ac
 }
 
+// GetCurrentUser resuelve el usuario dueño del token. Las respuestas exitosas se guardan en cache
+// durante CacheTTL y los 401 durante NegativeCacheTTL; las consultas concurrentes del mismo
+// token comparten una única llamada al servicio de autenticación.
+//
+// Devuelve ErrUnauthorized si el servicio rechazó el token y ErrAuthUnavailable si no pudo
+// consultarse; en ese caso, si StaleIfError lo permite, se usa la última identidad conocida.
 func (ac *AuthClient) GetCurrentUser(ctx context.Context, token string) (*domain.AuthUser, error) {
+	key := hashToken(token)
+	if ac.cache != nil {
+		if entry, ok := ac.cache.get(key); ok {
+			if entry.user == nil {
+				metrics.AuthCacheTotal.WithLabelValues("negative_hit").Inc()
+				return nil, ErrUnauthorized
+			}
+			metrics.AuthCacheTotal.WithLabelValues("hit").Inc()
+			return entry.user, nil
+		}
+		metrics.AuthCacheTotal.WithLabelValues("miss").Inc()
+	}
+
+	// La llamada compartida no debe cancelarse si se cancela el request que la inició, pero
+	// tampoco puede quedar sin plazo: a lo sumo lo que tardan todos los intentos
+	v, err, _ := ac.lookups.Do(hex.EncodeToString(key[:]), func() (interface{}, error) {
+		fetchCtx := context.WithoutCancel(ctx)
+		if budget := ac.retryBudget(); budget > 0 {
+			var cancel context.CancelFunc
+			fetchCtx, cancel = context.WithTimeout(fetchCtx, budget)
+			defer cancel()
+		}
+		user, err := ac.fetchWithRetry(fetchCtx, token)
+		if ac.cache != nil {
+			switch {
+			case err == nil:
+				ac.cache.set(key, user, ac.cacheTTL, ac.staleIfError)
+			case errors.Is(err, ErrUnauthorized) && ac.negativeCacheTTL > 0:
+				ac.cache.set(key, nil, ac.negativeCacheTTL, 0)
+			}
+		}
+		return user, err
+	})
+	if err != nil {
+		if errors.Is(err, ErrAuthUnavailable) && ac.cache != nil {
+			if entry, ok := ac.cache.getStale(key); ok && entry.user != nil {
+				metrics.AuthCacheTotal.WithLabelValues("stale_hit").Inc()
+				logger.FromContext(ctx).Warn("servicio de autenticación no disponible, se usa la identidad en cache", "error", err)
+				return entry.user, nil
+			}
+		}
+		return nil, err
+	}
+	return v.(*domain.AuthUser), nil
+}
+
+// fetchWithRetry consulta al servicio reintentando sólo las fallas de disponibilidad.
+// Los rechazos del token no se reintentan ni cuentan como fallas para el circuit breaker.
+func (ac *AuthClient) fetchWithRetry(ctx context.Context, token string) (*domain.AuthUser, error) {
+	for attempt := 0; ; attempt++ {
+		if err := ac.breaker.allow(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrAuthUnavailable, err)
+		}
+
+		user, err := ac.fetchCurrentUser(ctx, token)
+		unavailable := errors.Is(err, ErrAuthUnavailable)
+		ac.breaker.record(!unavailable)
+		if !unavailable || attempt >= ac.retries {
+			return user, err
+		}
+
+		metrics.AuthRetriesTotal.Inc()
+		delay := ac.backoff(attempt)
+		logger.FromContext(ctx).Warn("falló la consulta al servicio de autenticación, se reintenta",
+			"attempt", attempt+1, "delay_ms", delay.Milliseconds(), "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", ErrAuthUnavailable, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryBudget es el plazo máximo de fetchWithRetry: Timeout por intento más la espera máxima
+// entre intentos. 0 si Timeout no está configurado.
+func (ac *AuthClient) retryBudget() time.Duration {
+	if ac.Timeout <= 0 {
+		return 0
+	}
+	budget := ac.Timeout * time.Duration(ac.retries+1)
+	for attempt := 0; attempt < ac.retries; attempt++ {
+		budget += ac.retryBackoff<<attempt + ac.retryBackoff/2
+	}
+	return budget
+}
+
+// backoff devuelve la espera antes del reintento número attempt+1: exponencial y con jitter
+// para que las réplicas no reintenten todas al mismo tiempo.
+func (ac *AuthClient) backoff(attempt int) time.Duration {
+	ceiling := ac.retryBackoff << attempt
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(ceiling))) + ac.retryBackoff/2
+}
+
+func (ac *AuthClient) fetchCurrentUser(ctx context.Context, token string) (_ *domain.AuthUser, err error) {
 	url := fmt.Sprintf("%s/users/current", ac.BaseURL)
 
+	ctx, span := tracing.Tracer("client").Start(ctx, "AuthClient.GetCurrentUser",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPRequestMethodGet, semconv.URLFull(url)),
+	)
+	defer tracing.End(span, &err)
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
@@ -26,22 +215,49 @@ func (ac *AuthClient) GetCurrentUser(ctx context.Context, token string) (*domain
 
 	// Agregar el Bearer Token en el header de autorización
 	req.Header.Set("Authorization", "Bearer "+token)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	start := time.Now()
+	resp, err := ac.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		metrics.AuthRequestDuration.WithLabelValues("error").Observe(metrics.Since(start))
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
 	}
 	defer resp.Body.Close()
+	metrics.AuthRequestDuration.WithLabelValues(strconv.Itoa(resp.StatusCode)).Observe(metrics.Since(start))
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error: código de estado %d", resp.StatusCode)
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError, resp.StatusCode == http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%w: código de estado %d", ErrAuthUnavailable, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: código de estado %d", ErrUnauthorized, resp.StatusCode)
 	}
 
 	var user domain.AuthUser
 	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: respuesta inválida: %v", ErrAuthUnavailable, err)
 	}
 
 	return &user, nil
 }
+
+// Ping verifica que el servicio de autenticación responda. Cualquier respuesta HTTP
+// (incluso 401/404) cuenta como alcanzable; sólo fallan los errores de red o los 5xx.
+func (ac *AuthClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ac.BaseURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := ac.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("error: código de estado %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"profilego/internal/domain"
+)
+
+// fakeAuthService simula el servicio de autenticación: responde status (con el usuario si es
+// 200) y cuenta las llamadas. Si block no es nil, cada llamada espera a que se cierre.
+type fakeAuthService struct {
+	status  atomic.Int32
+	calls   atomic.Int32
+	block   chan struct{}
+	entered chan struct{}
+}
+
+func newFakeAuthService(t *testing.T, status int) (*fakeAuthService, *httptest.Server) {
+	fake := &fakeAuthService{}
+	fake.status.Store(int32(status))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.calls.Add(1)
+		if fake.block != nil {
+			fake.entered <- struct{}{}
+			<-fake.block
+		}
+		status := int(fake.status.Load())
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			json.NewEncoder(w).Encode(domain.AuthUser{ID: "u1", Enabled: true})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+// newTestAuthClient crea un cliente contra server con el reloj del cache y del breaker en clock.
+func newTestAuthClient(server *httptest.Server, clock *fakeClock, opts AuthClientOptions) *AuthClient {
+	opts.Timeout = time.Second
+	ac := NewAuthClient(server.URL, opts)
+	if ac.cache != nil {
+		ac.cache.now = clock.Now
+	}
+	if ac.breaker != nil {
+		ac.breaker.now = clock.Now
+	}
+	return ac
+}
+
+func TestAuthClientCache(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	fake, server := newFakeAuthService(t, http.StatusOK)
+	ac := newTestAuthClient(server, clock, AuthClientOptions{CacheSize: 10, CacheTTL: time.Minute, NegativeCacheTTL: 10 * time.Second})
+
+	for i := 0; i < 3; i++ {
+		if user, err := ac.GetCurrentUser(ctx, "token"); err != nil || user.ID != "u1" {
+			t.Fatalf("GetCurrentUser: (%v, %v)", user, err)
+		}
+	}
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Fatalf("las consultas dentro de CacheTTL deben salir del cache: %d llamadas", calls)
+	}
+	clock.Advance(time.Minute + time.Second)
+	ac.GetCurrentUser(ctx, "token")
+	if calls := fake.calls.Load(); calls != 2 {
+		t.Fatalf("vencido CacheTTL debe consultarse de nuevo: %d llamadas", calls)
+	}
+
+	// Los rechazos se recuerdan durante NegativeCacheTTL
+	fake.status.Store(http.StatusUnauthorized)
+	for i := 0; i < 3; i++ {
+		if _, err := ac.GetCurrentUser(ctx, "rejected"); !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("esperaba ErrUnauthorized, obtuve %v", err)
+		}
+	}
+	if calls := fake.calls.Load(); calls != 3 {
+		t.Fatalf("un 401 debe quedar en el cache negativo: %d llamadas", calls)
+	}
+	clock.Advance(11 * time.Second)
+	ac.GetCurrentUser(ctx, "rejected")
+	if calls := fake.calls.Load(); calls != 4 {
+		t.Fatalf("vencido NegativeCacheTTL debe consultarse de nuevo: %d llamadas", calls)
+	}
+}
+
+func TestAuthClientSingleflight(t *testing.T) {
+	fake, server := newFakeAuthService(t, http.StatusOK)
+	fake.block, fake.entered = make(chan struct{}), make(chan struct{}, 10)
+	// Sin cache: sólo el singleflight puede evitar llamadas repetidas
+	ac := newTestAuthClient(server, newFakeClock(), AuthClientOptions{})
+
+	const concurrent = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrent)
+	for i := 0; i < concurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ac.GetCurrentUser(context.Background(), "token")
+			errs <- err
+		}()
+	}
+	<-fake.entered
+	time.Sleep(50 * time.Millisecond) // que el resto se sume a la llamada en curso
+	close(fake.block)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("GetCurrentUser: %v", err)
+		}
+	}
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Fatalf("las consultas concurrentes del mismo token deben compartir una llamada: %d llamadas", calls)
+	}
+}
+
+func TestAuthClientStaleIfError(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	fake, server := newFakeAuthService(t, http.StatusOK)
+	ac := newTestAuthClient(server, clock, AuthClientOptions{CacheSize: 10, CacheTTL: time.Minute, StaleIfError: 5 * time.Minute})
+
+	if _, err := ac.GetCurrentUser(ctx, "token"); err != nil {
+		t.Fatalf("GetCurrentUser: %v", err)
+	}
+	fake.status.Store(http.StatusServiceUnavailable)
+
+	clock.Advance(2 * time.Minute)
+	if user, err := ac.GetCurrentUser(ctx, "token"); err != nil || user.ID != "u1" {
+		t.Fatalf("con el servicio caído debe usarse la identidad vencida: (%v, %v)", user, err)
+	}
+	if _, err := ac.GetCurrentUser(ctx, "other"); !errors.Is(err, ErrAuthUnavailable) {
+		t.Fatalf("sin identidad en cache esperaba ErrAuthUnavailable, obtuve %v", err)
+	}
+
+	clock.Advance(5 * time.Minute)
+	if _, err := ac.GetCurrentUser(ctx, "token"); !errors.Is(err, ErrAuthUnavailable) {
+		t.Fatalf("pasado StaleIfError esperaba ErrAuthUnavailable, obtuve %v", err)
+	}
+
+	// Un rechazo del token nunca se reemplaza por la identidad vencida
+	fake.status.Store(http.StatusOK)
+	ac.GetCurrentUser(ctx, "token")
+	fake.status.Store(http.StatusUnauthorized)
+	clock.Advance(2 * time.Minute)
+	if _, err := ac.GetCurrentUser(ctx, "token"); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("esperaba ErrUnauthorized, obtuve %v", err)
+	}
+}
+
+func TestAuthClientBreaker(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	fake, server := newFakeAuthService(t, http.StatusServiceUnavailable)
+	ac := newTestAuthClient(server, clock, AuthClientOptions{BreakerThreshold: 2, BreakerOpenTimeout: 30 * time.Second})
+
+	for i := 0; i < 3; i++ {
+		if _, err := ac.GetCurrentUser(ctx, "token"); !errors.Is(err, ErrAuthUnavailable) {
+			t.Fatalf("esperaba ErrAuthUnavailable, obtuve %v", err)
+		}
+	}
+	if calls := fake.calls.Load(); calls != 2 {
+		t.Fatalf("con el circuito abierto no debe consultarse el servicio: %d llamadas", calls)
+	}
+
+	// Los rechazos del token no cuentan como fallas
+	fake.status.Store(http.StatusUnauthorized)
+	clock.Advance(30 * time.Second)
+	for i := 0; i < 3; i++ {
+		if _, err := ac.GetCurrentUser(ctx, "token"); !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("esperaba ErrUnauthorized, obtuve %v", err)
+		}
+	}
+	if calls := fake.calls.Load(); calls != 5 {
+		t.Fatalf("un 401 debe cerrar el circuito: %d llamadas", calls)
+	}
+}
+
+func TestAuthClientRetries(t *testing.T) {
+	fake, server := newFakeAuthService(t, http.StatusServiceUnavailable)
+	ac := newTestAuthClient(server, newFakeClock(), AuthClientOptions{Retries: 2, RetryBackoff: time.Millisecond})
+
+	if _, err := ac.GetCurrentUser(context.Background(), "token"); !errors.Is(err, ErrAuthUnavailable) {
+		t.Fatalf("esperaba ErrAuthUnavailable, obtuve %v", err)
+	}
+	if calls := fake.calls.Load(); calls != 3 {
+		t.Fatalf("esperaba el intento y 2 reintentos: %d llamadas", calls)
+	}
+	if budget := ac.retryBudget(); budget < 3*time.Second {
+		t.Fatalf("el plazo debe cubrir todos los intentos: %v", budget)
+	}
+}
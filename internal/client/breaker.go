@@ -0,0 +1,91 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"profilego/internal/metrics"
+)
+
+// ErrCircuitOpen indica que el circuit breaker no deja pasar llamadas al servicio de autenticación.
+var ErrCircuitOpen = errors.New("circuit breaker abierto")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker corta las llamadas después de `threshold` fallas consecutivas.
+// Pasado `openTimeout` deja pasar una única llamada de prueba: si funciona se cierra,
+// si falla vuelve a abrirse.
+type circuitBreaker struct {
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time // reloj; reemplazable en los tests
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(threshold int, openTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, openTimeout: openTimeout, now: time.Now}
+}
+
+// allow devuelve ErrCircuitOpen si la llamada no debe realizarse.
+func (cb *circuitBreaker) allow() error {
+	if cb == nil {
+		return nil
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case breakerOpen:
+		if cb.now().Sub(cb.openedAt) < cb.openTimeout {
+			return ErrCircuitOpen
+		}
+		cb.setState(breakerHalfOpen)
+		cb.probing = true
+		return nil
+	case breakerHalfOpen:
+		if cb.probing {
+			return ErrCircuitOpen
+		}
+		cb.probing = true
+	}
+	return nil
+}
+
+// record registra el resultado de una llamada permitida por allow.
+func (cb *circuitBreaker) record(success bool) {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probing = false
+	if success {
+		cb.failures = 0
+		cb.setState(breakerClosed)
+		return
+	}
+
+	cb.failures++
+	if cb.state == breakerHalfOpen || cb.failures >= cb.threshold {
+		cb.openedAt = cb.now()
+		cb.setState(breakerOpen)
+	}
+}
+
+func (cb *circuitBreaker) setState(state breakerState) {
+	cb.state = state
+	metrics.AuthCircuitState.Set(float64(state))
+}
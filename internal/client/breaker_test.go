@@ -0,0 +1,78 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerStates(t *testing.T) {
+	clock := newFakeClock()
+	cb := newCircuitBreaker(3, 30*time.Second)
+	cb.now = clock.Now
+
+	// Cerrado: las fallas por debajo del umbral no lo abren y un éxito reinicia la cuenta
+	for i := 0; i < 2; i++ {
+		if err := cb.allow(); err != nil {
+			t.Fatalf("cerrado debe dejar pasar: %v", err)
+		}
+		cb.record(false)
+	}
+	cb.allow()
+	cb.record(true)
+	for i := 0; i < 2; i++ {
+		cb.allow()
+		cb.record(false)
+	}
+	if cb.state != breakerClosed {
+		t.Fatalf("un éxito reinicia las fallas consecutivas: estado %d", cb.state)
+	}
+
+	// Abierto: la tercera falla consecutiva lo abre
+	cb.allow()
+	cb.record(false)
+	if cb.state != breakerOpen {
+		t.Fatalf("esperaba abierto, estado %d", cb.state)
+	}
+	clock.Advance(29 * time.Second)
+	if err := cb.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("abierto no debe dejar pasar antes de openTimeout: %v", err)
+	}
+
+	// Semiabierto: una única llamada de prueba; si falla vuelve a abrirse
+	clock.Advance(time.Second)
+	if err := cb.allow(); err != nil {
+		t.Fatalf("pasado openTimeout debe dejar pasar la prueba: %v", err)
+	}
+	if cb.state != breakerHalfOpen {
+		t.Fatalf("esperaba semiabierto, estado %d", cb.state)
+	}
+	if err := cb.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("semiabierto deja pasar una sola llamada: %v", err)
+	}
+	cb.record(false)
+	if cb.state != breakerOpen {
+		t.Fatalf("una prueba fallida debe reabrirlo, estado %d", cb.state)
+	}
+
+	// Una prueba exitosa lo cierra
+	clock.Advance(30 * time.Second)
+	if err := cb.allow(); err != nil {
+		t.Fatalf("pasado openTimeout debe dejar pasar la prueba: %v", err)
+	}
+	cb.record(true)
+	if cb.state != breakerClosed {
+		t.Fatalf("una prueba exitosa debe cerrarlo, estado %d", cb.state)
+	}
+	if err := cb.allow(); err != nil {
+		t.Fatalf("cerrado debe dejar pasar: %v", err)
+	}
+}
+
+func TestCircuitBreakerNil(t *testing.T) {
+	var cb *circuitBreaker
+	if err := cb.allow(); err != nil {
+		t.Fatalf("sin breaker todas las llamadas pasan: %v", err)
+	}
+	cb.record(false)
+}
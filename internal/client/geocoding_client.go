@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"profilego/internal/domain"
+	"profilego/internal/geo"
+	"profilego/pkg/tracing"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrGeocoderUnavailable indica que no se pudo consultar al proveedor de geocodificación.
+var ErrGeocoderUnavailable = errors.New("proveedor de geocodificación no disponible")
+
+// GeocodingClientOptions configura GeocodingClient.
+type GeocodingClientOptions struct {
+	Timeout time.Duration
+	// RatePerSecond es la cantidad máxima de consultas por segundo al proveedor (0 = sin límite).
+	RatePerSecond float64
+	// UserAgent identifica al servicio ante el proveedor (Nominatim lo exige).
+	UserAgent string
+}
+
+// GeocodingClient geocodifica con un proveedor HTTP compatible con la búsqueda estructurada de
+// Nominatim (GET {BaseURL}/search?street=...&city=...&format=jsonv2). Implementa geo.Geocoder.
+type GeocodingClient struct {
+	BaseURL string
+
+	httpClient *http.Client
+	userAgent  string
+	limiter    *rateLimiter
+}
+
+var _ geo.Geocoder = (*GeocodingClient)(nil)
+
+func NewGeocodingClient(baseURL string, opts GeocodingClientOptions) *GeocodingClient {
+	gc := &GeocodingClient{
+		BaseURL:    baseURL,
+		httpClient: &http.Client{Timeout: opts.Timeout},
+		userAgent:  opts.UserAgent,
+	}
+	if opts.RatePerSecond > 0 {
+		gc.limiter = newRateLimiter(time.Duration(float64(time.Second) / opts.RatePerSecond))
+	}
+	return gc
+}
+
+// nominatimResult son los campos usados de un resultado de /search.
+type nominatimResult struct {
+	Lat       string `json:"lat"`
+	Lon       string `json:"lon"`
+	PlaceRank int    `json:"place_rank"`
+}
+
+func (gc *GeocodingClient) Geocode(ctx context.Context, address domain.Address) (_ domain.Geocode, err error) {
+	query := url.Values{
+		"street":       {fmt.Sprintf("%d %s", address.Number, address.Street)},
+		"city":         {address.Locality},
+		"postalcode":   {address.CP},
+		"countrycodes": {strings.ToLower(address.Country)},
+		"format":       {"jsonv2"},
+		"limit":        {"1"},
+	}
+	if province, ok := geo.FindProvince(address.Province); ok {
+		query.Set("state", province.Name)
+	}
+	endpoint := gc.BaseURL + "/search"
+
+	ctx, span := tracing.Tracer("client").Start(ctx, "GeocodingClient.Geocode",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPRequestMethodGet, semconv.URLFull(endpoint)),
+	)
+	defer tracing.End(span, &err)
+
+	if gc.limiter != nil {
+		if err := gc.limiter.wait(ctx); err != nil {
+			return domain.Geocode{}, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return domain.Geocode{}, err
+	}
+	if gc.userAgent != "" {
+		req.Header.Set("User-Agent", gc.userAgent)
+	}
+
+	resp, err := gc.httpClient.Do(req)
+	if err != nil {
+		return domain.Geocode{}, fmt.Errorf("%w: %v", ErrGeocoderUnavailable, err)
+	}
+	defer resp.Body.Close()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode != http.StatusOK {
+		return domain.Geocode{}, fmt.Errorf("%w: código de estado %d", ErrGeocoderUnavailable, resp.StatusCode)
+	}
+
+	var results []nominatimResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return domain.Geocode{}, fmt.Errorf("%w: respuesta inválida: %v", ErrGeocoderUnavailable, err)
+	}
+	if len(results) == 0 {
+		return domain.Geocode{Quality: domain.GeocodeNotFound}, nil
+	}
+
+	lat, errLat := strconv.ParseFloat(results[0].Lat, 64)
+	lon, errLon := strconv.ParseFloat(results[0].Lon, 64)
+	if errLat != nil || errLon != nil {
+		return domain.Geocode{}, fmt.Errorf("%w: coordenadas inválidas", ErrGeocoderUnavailable)
+	}
+	return domain.Geocode{Latitude: lat, Longitude: lon, Quality: placeRankQuality(results[0].PlaceRank)}, nil
+}
+
+// placeRankQuality traduce el place_rank de Nominatim: 30 es un edificio o una altura,
+// 26-29 una calle y el resto zonas más amplias.
+func placeRankQuality(rank int) domain.GeocodeQuality {
+	switch {
+	case rank >= 30:
+		return domain.GeocodeExact
+	case rank >= 26:
+		return domain.GeocodeStreet
+	default:
+		return domain.GeocodeLocality
+	}
+}
+
+// rateLimiter espacia las consultas al menos interval entre sí.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{interval: interval}
+}
+
+// wait bloquea hasta que se pueda hacer la próxima consulta o se cancele ctx.
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	rl.mu.Lock()
+	now := time.Now()
+	at := rl.next
+	if at.Before(now) {
+		at = now
+	}
+	rl.next = at.Add(rl.interval)
+	rl.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
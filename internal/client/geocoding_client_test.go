@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"profilego/internal/domain"
+)
+
+func TestPlaceRankQuality(t *testing.T) {
+	tests := []struct {
+		rank int
+		want domain.GeocodeQuality
+	}{
+		{30, domain.GeocodeExact},
+		{31, domain.GeocodeExact},
+		{29, domain.GeocodeStreet},
+		{26, domain.GeocodeStreet},
+		{25, domain.GeocodeLocality},
+		{16, domain.GeocodeLocality},
+		{0, domain.GeocodeLocality},
+	}
+	for _, tt := range tests {
+		if got := placeRankQuality(tt.rank); got != tt.want {
+			t.Errorf("placeRankQuality(%d) = %s, esperaba %s", tt.rank, got, tt.want)
+		}
+	}
+}
+
+func TestGeocodingClient(t *testing.T) {
+	address := domain.Address{
+		CP: "C1425ABC", Street: "Av. Santa Fe", Number: 3253, Locality: "Palermo", Province: "AR-C", Country: "AR",
+	}
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    domain.Geocode
+		wantErr bool
+	}{
+		{
+			name: "altura exacta", status: http.StatusOK,
+			body: `[{"lat":"-34.5880","lon":"-58.4110","place_rank":30}]`,
+			want: domain.Geocode{Latitude: -34.5880, Longitude: -58.4110, Quality: domain.GeocodeExact},
+		},
+		{
+			name: "sólo la calle", status: http.StatusOK,
+			body: `[{"lat":"-34.5880","lon":"-58.4110","place_rank":27}]`,
+			want: domain.Geocode{Latitude: -34.5880, Longitude: -58.4110, Quality: domain.GeocodeStreet},
+		},
+		{name: "sin resultados", status: http.StatusOK, body: `[]`, want: domain.Geocode{Quality: domain.GeocodeNotFound}},
+		{name: "coordenadas inválidas", status: http.StatusOK, body: `[{"lat":"norte","lon":"-58.4110","place_rank":30}]`, wantErr: true},
+		{name: "respuesta que no es JSON", status: http.StatusOK, body: `<html>`, wantErr: true},
+		{name: "límite de consultas", status: http.StatusTooManyRequests, wantErr: true},
+		{name: "error del proveedor", status: http.StatusInternalServerError, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query url.Values
+			var userAgent string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/search" {
+					t.Errorf("path %s, esperaba /search", r.URL.Path)
+				}
+				query, userAgent = r.URL.Query(), r.UserAgent()
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			gc := NewGeocodingClient(server.URL, GeocodingClientOptions{Timeout: time.Second, UserAgent: "profilego-test"})
+			got, err := gc.Geocode(context.Background(), address)
+			if tt.wantErr {
+				if !errors.Is(err, ErrGeocoderUnavailable) {
+					t.Fatalf("esperaba ErrGeocoderUnavailable, obtuve %+v %v", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Geocode = %+v, %v; esperaba %+v", got, err, tt.want)
+			}
+
+			want := url.Values{
+				"street":       {"3253 Av. Santa Fe"},
+				"city":         {"Palermo"},
+				"state":        {"Ciudad Autónoma de Buenos Aires"},
+				"postalcode":   {"C1425ABC"},
+				"countrycodes": {"ar"},
+				"format":       {"jsonv2"},
+				"limit":        {"1"},
+			}
+			if query.Encode() != want.Encode() {
+				t.Fatalf("query %s, esperaba %s", query.Encode(), want.Encode())
+			}
+			if userAgent != "profilego-test" {
+				t.Fatalf("User-Agent %q", userAgent)
+			}
+		})
+	}
+}
+
+func TestGeocodingClientUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	gc := NewGeocodingClient(server.URL, GeocodingClientOptions{Timeout: time.Second})
+	if _, err := gc.Geocode(context.Background(), domain.Address{}); !errors.Is(err, ErrGeocoderUnavailable) {
+		t.Fatalf("esperaba ErrGeocoderUnavailable, obtuve %v", err)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	const interval = 40 * time.Millisecond
+
+	t.Run("espacia las consultas", func(t *testing.T) {
+		rl := newRateLimiter(interval)
+		start := time.Now()
+		for range 3 {
+			if err := rl.wait(context.Background()); err != nil {
+				t.Fatalf("wait: %v", err)
+			}
+		}
+		// La primera pasa enseguida, las otras dos esperan un intervalo cada una
+		if elapsed := time.Since(start); elapsed < 2*interval {
+			t.Fatalf("3 consultas en %v, esperaba al menos %v", elapsed, 2*interval)
+		}
+	})
+
+	t.Run("la cancelación corta la espera", func(t *testing.T) {
+		rl := newRateLimiter(time.Hour)
+		if err := rl.wait(context.Background()); err != nil {
+			t.Fatalf("la primera consulta no espera: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		defer cancel()
+		start := time.Now()
+		if err := rl.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("esperaba context.DeadlineExceeded, obtuve %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("la espera cancelada tardó %v", elapsed)
+		}
+	})
+
+	t.Run("el cliente respeta el límite", func(t *testing.T) {
+		var calls []time.Time
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, time.Now())
+			w.Write([]byte(`[]`))
+		}))
+		defer server.Close()
+
+		gc := NewGeocodingClient(server.URL, GeocodingClientOptions{Timeout: time.Second, RatePerSecond: float64(time.Second / interval)})
+		for range 2 {
+			if _, err := gc.Geocode(context.Background(), domain.Address{}); err != nil {
+				t.Fatalf("Geocode: %v", err)
+			}
+		}
+		if len(calls) != 2 || calls[1].Sub(calls[0]) < interval-5*time.Millisecond {
+			t.Fatalf("consultas demasiado seguidas: %v", calls)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"profilego/internal/domain"
+)
+
+// tokenKey es el hash del token: el cache nunca guarda tokens en claro.
+type tokenKey [sha256.Size]byte
+
+func hashToken(token string) tokenKey {
+	return sha256.Sum256([]byte(token))
+}
+
+// cacheEntry guarda el usuario resuelto o, si user es nil, un rechazo (cache negativo).
+// Entre expiresAt y staleUntil la entrada ya no se usa normalmente, pero puede servirse
+// si el servicio de autenticación no está disponible.
+type cacheEntry struct {
+	key        tokenKey
+	user       *domain.AuthUser
+	expiresAt  time.Time
+	staleUntil time.Time
+}
+
+// tokenCache es un LRU acotado con vencimiento por entrada. Es seguro para uso concurrente.
+type tokenCache struct {
+	size int
+	now  func() time.Time // reloj; reemplazable en los tests
+
+	mu      sync.Mutex
+	order   *list.List // frente = usado más recientemente
+	entries map[tokenKey]*list.Element
+}
+
+func newTokenCache(size int) *tokenCache {
+	return &tokenCache{size: size, now: time.Now, order: list.New(), entries: make(map[tokenKey]*list.Element, size)}
+}
+
+// get devuelve la entrada vigente para key.
+func (tc *tokenCache) get(key tokenKey) (*cacheEntry, bool) {
+	return tc.lookup(key, func(e *cacheEntry) time.Time { return e.expiresAt })
+}
+
+// getStale devuelve la entrada para key aunque esté vencida, mientras no supere staleUntil.
+func (tc *tokenCache) getStale(key tokenKey) (*cacheEntry, bool) {
+	return tc.lookup(key, func(e *cacheEntry) time.Time { return e.staleUntil })
+}
+
+func (tc *tokenCache) lookup(key tokenKey, deadline func(*cacheEntry) time.Time) (*cacheEntry, bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	elem, ok := tc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	now := tc.now()
+	if now.After(entry.staleUntil) {
+		tc.order.Remove(elem)
+		delete(tc.entries, key)
+		return nil, false
+	}
+	if now.After(deadline(entry)) {
+		return nil, false
+	}
+	tc.order.MoveToFront(elem)
+	return entry, true
+}
+
+// set guarda user (o un rechazo si es nil) durante ttl más un período de gracia stale,
+// desalojando la entrada menos usada si hace falta.
+func (tc *tokenCache) set(key tokenKey, user *domain.AuthUser, ttl, stale time.Duration) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	expiresAt := tc.now().Add(ttl)
+	entry := &cacheEntry{key: key, user: user, expiresAt: expiresAt, staleUntil: expiresAt.Add(stale)}
+	if elem, ok := tc.entries[key]; ok {
+		elem.Value = entry
+		tc.order.MoveToFront(elem)
+		return
+	}
+
+	tc.entries[key] = tc.order.PushFront(entry)
+	for tc.order.Len() > tc.size {
+		oldest := tc.order.Back()
+		tc.order.Remove(oldest)
+		delete(tc.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
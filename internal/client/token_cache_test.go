@@ -0,0 +1,119 @@
+package client
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"profilego/internal/domain"
+)
+
+// fakeClock es un reloj que sólo avanza con Advance.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestTokenCacheLRU(t *testing.T) {
+	cache := newTokenCache(2)
+	a, b, c := hashToken("a"), hashToken("b"), hashToken("c")
+	cache.set(a, &domain.AuthUser{ID: "a"}, time.Minute, 0)
+	cache.set(b, &domain.AuthUser{ID: "b"}, time.Minute, 0)
+
+	// Leer a la vuelve la más reciente: al agregar c se desaloja b
+	if _, ok := cache.get(a); !ok {
+		t.Fatal("esperaba encontrar a")
+	}
+	cache.set(c, &domain.AuthUser{ID: "c"}, time.Minute, 0)
+
+	if _, ok := cache.get(b); ok {
+		t.Fatal("b debía desalojarse por ser la menos usada")
+	}
+	for _, key := range []tokenKey{a, c} {
+		if _, ok := cache.get(key); !ok {
+			t.Fatalf("esperaba encontrar %x", key[:4])
+		}
+	}
+	if cache.order.Len() != 2 || len(cache.entries) != 2 {
+		t.Fatalf("el cache no puede superar su tamaño: %d/%d", cache.order.Len(), len(cache.entries))
+	}
+}
+
+func TestTokenCacheTTL(t *testing.T) {
+	clock := newFakeClock()
+	cache := newTokenCache(10)
+	cache.now = clock.Now
+	user, rejected := hashToken("user"), hashToken("rejected")
+	cache.set(user, &domain.AuthUser{ID: "u1"}, time.Minute, 0)
+	cache.set(rejected, nil, 10*time.Second, 0)
+
+	tests := []struct {
+		name         string
+		advance      time.Duration
+		wantUser     bool
+		wantRejected bool
+	}{
+		{"recién guardadas", 0, true, true},
+		{"vence el rechazo", 11 * time.Second, true, false},
+		{"vence el usuario", time.Minute, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock.Advance(tt.advance)
+			entry, ok := cache.get(user)
+			if ok != tt.wantUser {
+				t.Fatalf("usuario en cache = %v, esperaba %v", ok, tt.wantUser)
+			}
+			if ok && entry.user.ID != "u1" {
+				t.Fatalf("usuario inesperado: %+v", entry.user)
+			}
+			entry, ok = cache.get(rejected)
+			if ok != tt.wantRejected {
+				t.Fatalf("rechazo en cache = %v, esperaba %v", ok, tt.wantRejected)
+			}
+			if ok && entry.user != nil {
+				t.Fatalf("un rechazo no tiene usuario: %+v", entry.user)
+			}
+		})
+	}
+}
+
+func TestTokenCacheStale(t *testing.T) {
+	clock := newFakeClock()
+	cache := newTokenCache(10)
+	cache.now = clock.Now
+	key := hashToken("token")
+	cache.set(key, &domain.AuthUser{ID: "u1"}, time.Minute, 5*time.Minute)
+
+	clock.Advance(2 * time.Minute)
+	if _, ok := cache.get(key); ok {
+		t.Fatal("una entrada vencida no debe servirse normalmente")
+	}
+	if entry, ok := cache.getStale(key); !ok || entry.user.ID != "u1" {
+		t.Fatalf("esperaba la entrada vencida dentro del período stale: (%v, %v)", entry, ok)
+	}
+
+	clock.Advance(5 * time.Minute)
+	if _, ok := cache.getStale(key); ok {
+		t.Fatal("pasado staleUntil la entrada no debe servirse")
+	}
+	if len(cache.entries) != 0 {
+		t.Fatal("pasado staleUntil la entrada debe eliminarse")
+	}
+}
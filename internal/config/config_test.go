@@ -0,0 +1,37 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestDSN(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"contraseña simple", "root", `password='root'`},
+		{"con espacios", "una clave", `password='una clave'`},
+		{"con comilla simple", "o'higgins", `password='o\'higgins'`},
+		{"con barra invertida", `a\b`, `password='a\\b'`},
+		{"con signo igual", "a=b", `password='a=b'`},
+		{"vacía", "", `password=''`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := Default().DB
+			db.Password = tt.password
+			dsn := db.DSN()
+
+			want := `host='127.0.0.1' port='5432' user='root' ` + tt.want + ` dbname='profileDB' sslmode='disable'`
+			if dsn != want {
+				t.Fatalf("DSN %s, esperaba %s", dsn, want)
+			}
+			if _, err := pq.NewConnector(dsn); err != nil {
+				t.Fatalf("lib/pq no acepta el DSN %s: %v", dsn, err)
+			}
+		})
+	}
+}
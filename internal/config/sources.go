@@ -0,0 +1,155 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// binding relaciona un valor de configuración con su variable de entorno y su flag.
+type binding struct {
+	flag  string
+	env   string
+	usage string
+	set   func(string) error
+}
+
+func (c *Config) bindings() []binding {
+	return []binding{
+		{"port", "PORT", "puerto HTTP", setString(&c.HTTP.Port)},
+		{"http-read-timeout", "HTTP_READ_TIMEOUT", "timeout de lectura HTTP", setDuration(&c.HTTP.ReadTimeout)},
+		{"http-write-timeout", "HTTP_WRITE_TIMEOUT", "timeout de escritura HTTP", setDuration(&c.HTTP.WriteTimeout)},
+		{"shutdown-timeout", "SHUTDOWN_TIMEOUT", "tiempo máximo de apagado ordenado", setDuration(&c.HTTP.ShutdownTimeout)},
+		{"http-require-if-match", "HTTP_REQUIRE_IF_MATCH", "exigir If-Match en PUT/PATCH/DELETE de /api/v2", setBool(&c.HTTP.RequireIfMatch)},
+		{"db-host", "DB_HOST", "host de PostgreSQL", setString(&c.DB.Host)},
+		{"db-port", "DB_PORT", "puerto de PostgreSQL", setString(&c.DB.Port)},
+		{"db-user", "DB_USER", "usuario de PostgreSQL", setString(&c.DB.User)},
+		{"db-password", "DB_PASSWORD", "contraseña de PostgreSQL", setString(&c.DB.Password)},
+		{"db-name", "DB_NAME", "base de datos de PostgreSQL", setString(&c.DB.Name)},
+		{"db-sslmode", "DB_SSLMODE", "sslmode de PostgreSQL", setString(&c.DB.SSLMode)},
+		{"db-connect-timeout", "DB_CONNECT_TIMEOUT", "timeout de conexión a PostgreSQL", setDuration(&c.DB.ConnectTimeout)},
+		{"db-migrate", "DB_MIGRATE_ON_STARTUP", "aplicar migraciones al iniciar (true/false)", setBool(&c.DB.MigrateOnStartup)},
+		{"amqp-url", "AMQP_URL", "URL de RabbitMQ", setString(&c.RabbitMQ.URL)},
+		{"profile-queue", "PROFILE_QUEUE", "cola de eventos de perfil", setString(&c.RabbitMQ.ProfileQueue)},
+		{"auth-url", "AUTH_BASE_URL", "URL base del servicio de autenticación", setString(&c.Auth.BaseURL)},
+		{"auth-timeout", "AUTH_TIMEOUT", "timeout de las llamadas al servicio de autenticación", setDuration(&c.Auth.Timeout)},
+		{"auth-health-check", "AUTH_HEALTH_CHECK", "verificar el servicio de autenticación en /readyz (true/false)", setBool(&c.Auth.HealthCheck)},
+		{"auth-max-idle-conns", "AUTH_MAX_IDLE_CONNS", "conexiones keep-alive hacia el servicio de autenticación", setInt(&c.Auth.MaxIdleConns)},
+		{"auth-cache-size", "AUTH_CACHE_SIZE", "tokens resueltos en cache (0 = sin cache)", setInt(&c.Auth.CacheSize)},
+		{"auth-cache-ttl", "AUTH_CACHE_TTL", "vigencia de un token resuelto en cache", setDuration(&c.Auth.CacheTTL)},
+		{"auth-negative-cache-ttl", "AUTH_NEGATIVE_CACHE_TTL", "vigencia en cache de un token rechazado (401)", setDuration(&c.Auth.NegativeCacheTTL)},
+		{"auth-stale-if-error", "AUTH_STALE_IF_ERROR", "usar identidades en cache vencidas hace menos de este tiempo si el servicio no responde", setDuration(&c.Auth.StaleIfError)},
+		{"auth-retries", "AUTH_RETRIES", "reintentos ante fallas del servicio de autenticación", setInt(&c.Auth.Retries)},
+		{"auth-retry-backoff", "AUTH_RETRY_BACKOFF", "espera base entre reintentos (exponencial con jitter)", setDuration(&c.Auth.RetryBackoff)},
+		{"auth-breaker-threshold", "AUTH_BREAKER_THRESHOLD", "fallas consecutivas que abren el circuit breaker (0 = sin breaker)", setInt(&c.Auth.BreakerThreshold)},
+		{"auth-breaker-open-timeout", "AUTH_BREAKER_OPEN_TIMEOUT", "tiempo que el circuit breaker permanece abierto", setDuration(&c.Auth.BreakerOpenTimeout)},
+		{"auth-jwks-url", "AUTH_JWKS_URL", "endpoint JWKS para verificar tokens localmente", setString(&c.Auth.JWT.JWKSURL)},
+		{"auth-public-key-file", "AUTH_PUBLIC_KEY_FILE", "archivo PEM con las claves públicas para verificar tokens localmente", setString(&c.Auth.JWT.PublicKeyFile)},
+		{"auth-keys-refresh", "AUTH_KEYS_REFRESH", "intervalo de recarga de las claves de firma", setDuration(&c.Auth.JWT.KeysRefresh)},
+		{"auth-issuer", "AUTH_ISSUER", "claim iss esperado (vacío = no se valida)", setString(&c.Auth.JWT.Issuer)},
+		{"auth-audience", "AUTH_AUDIENCE", "claim aud esperado (vacío = no se valida)", setString(&c.Auth.JWT.Audience)},
+		{"auth-leeway", "AUTH_LEEWAY", "tolerancia de reloj para exp/nbf", setDuration(&c.Auth.JWT.Leeway)},
+		{"auth-remote-fallback", "AUTH_REMOTE_FALLBACK", "consultar al servicio de autenticación los tokens no verificables localmente (true/false)", setBool(&c.Auth.JWT.RemoteFallback)},
+		{"log-format", "LOG_FORMAT", "formato de log (json/text)", setString(&c.Log.Format)},
+		{"log-level", "LOG_LEVEL", "nivel de log (debug/info/warn/error)", setString(&c.Log.Level)},
+		{"tracing-exporter", "TRACING_EXPORTER", "exportador de trazas (none/stdout/otlp)", setString(&c.Tracing.Exporter)},
+		{"tracing-otlp-endpoint", "TRACING_OTLP_ENDPOINT", "host:puerto del colector OTLP/HTTP", setString(&c.Tracing.OTLPEndpoint)},
+		{"tracing-service-name", "TRACING_SERVICE_NAME", "nombre del servicio en las trazas", setString(&c.Tracing.ServiceName)},
+		{"tracing-sample-ratio", "TRACING_SAMPLE_RATIO", "proporción de trazas muestreadas (0..1)", setFloat(&c.Tracing.SampleRatio)},
+		{"geo-validate-localities", "GEO_VALIDATE_LOCALITIES", "exigir que el CP y la localidad estén en el catálogo (true/false)", setBool(&c.Geo.ValidateLocalities)},
+		{"geocoder", "GEOCODER", "proveedor de geocodificación (none/local/http)", setString(&c.Geo.Geocoder)},
+		{"geocoder-file", "GEOCODER_FILE", "CSV del proveedor local (vacío = tabla incluida)", setString(&c.Geo.GeocoderFile)},
+		{"geocoder-url", "GEOCODER_URL", "URL base del proveedor http (compatible con Nominatim)", setString(&c.Geo.GeocoderURL)},
+		{"geocoder-user-agent", "GEOCODER_USER_AGENT", "User-Agent de las consultas al proveedor http", setString(&c.Geo.GeocoderUserAgent)},
+		{"geocoder-timeout", "GEOCODER_TIMEOUT", "timeout de las consultas al proveedor http", setDuration(&c.Geo.GeocoderTimeout)},
+		{"geocoder-rate", "GEOCODER_RATE", "consultas por segundo al proveedor http (0 = sin límite)", setFloat(&c.Geo.GeocoderRate)},
+		{"geocoder-cache-size", "GEOCODER_CACHE_SIZE", "resultados de geocodificación en cache (0 = sin cache)", setInt(&c.Geo.GeocoderCacheSize)},
+		{"geocoder-queue-size", "GEOCODER_QUEUE_SIZE", "direcciones en espera de ser geocodificadas", setInt(&c.Geo.GeocoderQueueSize)},
+	}
+}
+
+// bindFlags registra los flags en fs y devuelve los setters indexados por nombre de flag.
+func (c *Config) bindFlags(fs *flag.FlagSet) map[string]func(string) error {
+	setters := make(map[string]func(string) error)
+	for _, b := range c.bindings() {
+		fs.String(b.flag, "", fmt.Sprintf("%s (env %s)", b.usage, b.env))
+		setters[b.flag] = b.set
+	}
+	return setters
+}
+
+func (c *Config) loadEnv() error {
+	for _, b := range c.bindings() {
+		value, ok := os.LookupEnv(b.env)
+		if !ok || value == "" {
+			continue
+		}
+		if err := b.set(value); err != nil {
+			return fmt.Errorf("variable de entorno %s: %w", b.env, err)
+		}
+	}
+	return nil
+}
+
+func setString(dst *string) func(string) error {
+	return func(v string) error {
+		*dst = v
+		return nil
+	}
+}
+
+func setBool(dst *bool) func(string) error {
+	return func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("booleano inválido %q", v)
+		}
+		*dst = b
+		return nil
+	}
+}
+
+func setInt(dst *int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("entero inválido %q", v)
+		}
+		*dst = n
+		return nil
+	}
+}
+
+func setFloat(dst *float64) func(string) error {
+	return func(v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("número inválido %q", v)
+		}
+		*dst = f
+		return nil
+	}
+}
+
+func setDuration(dst *time.Duration) func(string) error {
+	return func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("duración inválida %q", v)
+		}
+		*dst = d
+		return nil
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -8,18 +8,33 @@ import (
 
 // Address representa el modelo de datos para una dirección.
 type Address struct {
-	AddressID     uuid.UUID `json:"addressId"`
-	CP            string    `json:"CP"`
-	Street        string    `json:"street"`
-	Number        int       `json:"number"`
-	Floor         *string   `json:"floor,omitempty"`
-	MainAddress   bool      `json:"mainAddress"`
-	CreationDate  time.Time `json:"creationDate"`
-	UpdatedDate   time.Time `json:"updatedDate"`
-	ActiveAddress bool      `json:"activeAddress"`
-	IdProfile     uuid.UUID `json:"idProfile"` // Clave foránea a Profile
+	AddressID      uuid.UUID `json:"addressId"`
+	CP             string    `json:"CP"` // código de 4 dígitos o CPA (p. ej. C1425ABC)
+	Street         string    `json:"street"`
+	Number         int       `json:"number"`
+	Floor          *string   `json:"floor,omitempty"`
+	Apartment      *string   `json:"apartment,omitempty"` // departamento
+	Locality       string    `json:"locality"`
+	Province       string    `json:"province"` // código ISO 3166-2:AR, p. ej. AR-C
+	Country        string    `json:"country"`  // código ISO 3166-1 alfa-2; por defecto AR
+	BetweenStreets *string   `json:"betweenStreets,omitempty"`
+	References     *string   `json:"references,omitempty"`
+	MainAddress    bool      `json:"mainAddress"`
+	// Latitude, Longitude y GeocodeQuality se completan en segundo plano después de cada alta o
+	// cambio de la dirección (ver service.GeocodingService).
+	Latitude       *float64       `json:"latitude,omitempty"`
+	Longitude      *float64       `json:"longitude,omitempty"`
+	GeocodeQuality GeocodeQuality `json:"geocodeQuality,omitempty"`
+	CreationDate   time.Time      `json:"creationDate"`
+	UpdatedDate    time.Time      `json:"updatedDate"`
+	ActiveAddress  bool           `json:"activeAddress"`
+	IdProfile      uuid.UUID      `json:"idProfile"` // Clave foránea a Profile
+	Version        int            `json:"version"`   // se incrementa en cada modificación (ETag)
 }
 
+// CountryArgentina es el país por defecto de las direcciones.
+const CountryArgentina = "AR"
+
 // NewAddress crea una nueva instancia de Address con un ID generado.
 func NewAddress(CP, street string, floor *string, mainAddress, activeAddress bool, number int, profileID uuid.UUID) *Address {
 	return &Address{
@@ -28,6 +43,7 @@ func NewAddress(CP, street string, floor *string, mainAddress, activeAddress boo
 		Street:        street,
 		Number:        number,
 		Floor:         floor,
+		Country:       CountryArgentina,
 		MainAddress:   mainAddress,
 		ActiveAddress: activeAddress,
 		IdProfile:     profileID,
@@ -1,5 +1,21 @@
 package domain
 
+import "slices"
+
+// Permisos reconocidos por profilego en AuthUser.Permissions.
+const (
+	// PermProfileReadAny permite leer perfiles y direcciones de otros usuarios.
+	PermProfileReadAny = "profile:read:any"
+	// PermProfileWriteAny permite modificar perfiles y direcciones de otros usuarios.
+	PermProfileWriteAny = "profile:write:any"
+	// PermPointsGrant permite otorgar puntos a un perfil.
+	PermPointsGrant = "points:grant"
+	// PermLevelSet permite cambiar el nivel de un perfil.
+	PermLevelSet = "level:set"
+	// PermAdminLogLevel permite cambiar el nivel de log en tiempo de ejecución.
+	PermAdminLogLevel = "admin:log-level"
+)
+
 type AuthUser struct {
 	ID          string   `json:"id"`
 	Name        string   `json:"name"`
@@ -7,3 +23,8 @@ type AuthUser struct {
 	Login       string   `json:"login"`
 	Enabled     bool     `json:"enabled"`
 }
+
+// HasPermission indica si el usuario tiene el permiso indicado.
+func (u *AuthUser) HasPermission(permission string) bool {
+	return u != nil && slices.Contains(u.Permissions, permission)
+}
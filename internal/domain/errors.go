@@ -0,0 +1,88 @@
+package domain
+
+import "errors"
+
+// Categorías de error. Todo *Error pertenece a una y errors.Is(err, ErrNotFound) etc. la
+// reconoce aunque el error venga envuelto, así las capas superiores deciden por categoría.
+var (
+	ErrNotFound        = errors.New("no encontrado")
+	ErrAlreadyExists   = errors.New("ya existe")
+	ErrValidation      = errors.New("datos inválidos")
+	ErrUnauthenticated = errors.New("no autenticado")
+	ErrForbidden       = errors.New("acceso denegado")
+	ErrConflict        = errors.New("conflicto")
+	ErrUpstream        = errors.New("dependencia no disponible")
+)
+
+// FieldError describe un campo que no pasó la validación.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error es un error de negocio con un código estable (p. ej. "profile_not_found") que los
+// clientes pueden usar sin depender del mensaje. Dos *Error con el mismo código son
+// equivalentes para errors.Is.
+type Error struct {
+	Kind    error  // una de las categorías Err*
+	Code    string // identificador estable, en snake_case
+	Message string
+	Fields  []FieldError // sólo en errores de validación
+	Err     error        // causa, si la hay
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap devuelve una copia del error con la causa indicada.
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.Err = cause
+	return &wrapped
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+func AlreadyExists(code, message string) *Error {
+	return &Error{Kind: ErrAlreadyExists, Code: code, Message: message}
+}
+
+// Validation crea un error de validación con el detalle de cada campo inválido.
+func Validation(code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Message: message, Fields: fields}
+}
+
+func Unauthenticated(code, message string) *Error {
+	return &Error{Kind: ErrUnauthenticated, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: message}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message}
+}
+
+// Upstream indica que falló una dependencia externa (servicio de autenticación, RabbitMQ, ...).
+func Upstream(code, message string, cause error) *Error {
+	return &Error{Kind: ErrUpstream, Code: code, Message: message, Err: cause}
+}
@@ -0,0 +1,28 @@
+package domain
+
+// FiscalCondition es la condición frente al IVA (categorías de AFIP).
+type FiscalCondition string
+
+const (
+	FiscalConditionResponsableInscripto FiscalCondition = "responsable_inscripto"
+	FiscalConditionMonotributista       FiscalCondition = "monotributista"
+	FiscalConditionExento               FiscalCondition = "exento"
+	FiscalConditionConsumidorFinal      FiscalCondition = "consumidor_final"
+	FiscalConditionNoResponsable        FiscalCondition = "no_responsable"
+)
+
+// IIBBRegime es el régimen de inscripción en Ingresos Brutos.
+type IIBBRegime string
+
+const (
+	IIBBRegimeLocal                IIBBRegime = "local"
+	IIBBRegimeConvenioMultilateral IIBBRegime = "convenio_multilateral"
+	IIBBRegimeExento               IIBBRegime = "exento"
+)
+
+// IIBB es la inscripción en Ingresos Brutos.
+type IIBB struct {
+	Regime       IIBBRegime `json:"regime"`
+	Number       string     `json:"number,omitempty"`
+	Jurisdiction string     `json:"jurisdiction,omitempty"` // código de jurisdicción (901 = CABA, ..., 924 = Tucumán)
+}
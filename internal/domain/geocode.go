@@ -0,0 +1,57 @@
+package domain
+
+// GeocodeQuality indica cómo se obtuvieron las coordenadas de una dirección.
+type GeocodeQuality string
+
+const (
+	// GeocodePending: todavía no se geocodificó (o cambió la dirección y hay que repetirlo).
+	GeocodePending GeocodeQuality = "pending"
+	// GeocodeExact: coordenadas de la altura exacta.
+	GeocodeExact GeocodeQuality = "exact"
+	// GeocodeStreet: coordenadas aproximadas sobre la calle.
+	GeocodeStreet GeocodeQuality = "street"
+	// GeocodeLocality: centro de la localidad.
+	GeocodeLocality GeocodeQuality = "locality"
+	// GeocodeNotFound: el proveedor no encontró la dirección.
+	GeocodeNotFound GeocodeQuality = "not_found"
+)
+
+// Geocode es el resultado de geocodificar una dirección. Con GeocodeNotFound no hay coordenadas.
+type Geocode struct {
+	Latitude  float64
+	Longitude float64
+	Quality   GeocodeQuality
+}
+
+// Found indica si el resultado tiene coordenadas.
+func (g Geocode) Found() bool {
+	return g.Quality != GeocodeNotFound && g.Quality != GeocodePending && g.Quality != ""
+}
+
+// ResetGeocode borra las coordenadas de la dirección y la deja pendiente de geocodificar.
+func (a *Address) ResetGeocode() {
+	a.Latitude, a.Longitude, a.GeocodeQuality = nil, nil, GeocodePending
+}
+
+// ApplyGeocode guarda en la dirección el resultado de geocodificarla.
+func (a *Address) ApplyGeocode(g Geocode) {
+	a.Latitude, a.Longitude, a.GeocodeQuality = nil, nil, g.Quality
+	if g.Found() {
+		lat, lon := g.Latitude, g.Longitude
+		a.Latitude, a.Longitude = &lat, &lon
+	}
+}
+
+// AddressDistance es una dirección con su distancia a un punto.
+type AddressDistance struct {
+	Address
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+// NearbyFilter busca direcciones activas con coordenadas a lo sumo a RadiusKm de un punto.
+type NearbyFilter struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+	Limit     int
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// AddressOperation es el tipo de cambio que generó una revisión de una dirección.
+type AddressOperation string
+
+const (
+	AddressCreated     AddressOperation = "create"
+	AddressUpdated     AddressOperation = "update"
+	AddressDeactivated AddressOperation = "deactivate"
+	AddressReactivated AddressOperation = "reactivate"
+	// AddressSnapshot es el estado que tenía la dirección cuando se habilitó el historial.
+	AddressSnapshot AddressOperation = "snapshot"
+)
+
+// AddressRevision es una versión inmutable de una dirección: su estado después de un cambio,
+// quién lo hizo y cuándo. Address no incluye las coordenadas, que no forman parte del historial.
+type AddressRevision struct {
+	Version   int              `json:"version"`
+	Operation AddressOperation `json:"operation"`
+	ChangedBy string           `json:"changedBy,omitempty"` // userId; vacío si no hubo un usuario
+	ChangedAt time.Time        `json:"changedAt"`
+	Address   Address          `json:"address"`
+	// Changes son los campos que cambiaron respecto de la revisión anterior.
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange es el valor anterior y el nuevo de un campo; null si no tenía valor.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// revisionMetadata son los campos de Address que cambian con cada revisión o que no forman
+// parte del historial, y que por eso no se informan en Changes.
+var revisionMetadata = []string{"addressId", "idProfile", "version", "creationDate", "updatedDate",
+	"latitude", "longitude", "geocodeQuality"}
+
+// AddressFieldChanges compara dos versiones de una dirección y devuelve los campos que
+// cambiaron, con el nombre que tienen en JSON y ordenados por nombre.
+func AddressFieldChanges(old, new *Address) []FieldChange {
+	before, after := addressValues(old), addressValues(new)
+	fields := make(map[string]bool)
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+
+	var changes []FieldChange
+	for field := range fields {
+		if slices.Contains(revisionMetadata, field) || reflect.DeepEqual(before[field], after[field]) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field, Old: before[field], New: after[field]})
+	}
+	slices.SortFunc(changes, func(a, b FieldChange) int { return cmp.Compare(a.Field, b.Field) })
+	return changes
+}
+
+// addressValues devuelve los campos de la dirección tal como se ven en JSON.
+func addressValues(a *Address) map[string]interface{} {
+	values := make(map[string]interface{})
+	data, _ := json.Marshal(a)
+	_ = json.Unmarshal(data, &values)
+	return values
+}
+
+type actorKey struct{}
+
+// WithActor devuelve un contexto que registra al usuario que hace los cambios; el historial de
+// direcciones lo guarda como autor.
+func WithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// ActorFromContext devuelve el usuario registrado con WithActor, o "" si no hay.
+func ActorFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(actorKey{}).(string)
+	return userID
+}
@@ -0,0 +1,17 @@
+package domain
+
+// Locality es una localidad del catálogo de códigos postales.
+type Locality struct {
+	Province string `json:"province"` // código ISO 3166-2:AR
+	Name     string `json:"name"`
+	CP       string `json:"CP"` // código de 4 dígitos
+}
+
+// LocalityFilter es una búsqueda en el catálogo de localidades; los campos vacíos no filtran.
+type LocalityFilter struct {
+	Province string
+	CP       string // prefijo del código de 4 dígitos
+	Query    string // prefijo de alguna palabra del nombre, ya normalizado (ver geo.SearchKey)
+	Limit    int
+	Offset   int
+}
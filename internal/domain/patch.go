@@ -0,0 +1,131 @@
+package domain
+
+import "encoding/json"
+
+// Field es un campo de una actualización parcial (JSON Merge Patch, RFC 7396).
+// Set indica si el campo vino en el documento y Null si vino explícitamente en null,
+// lo que permite distinguir "no cambiar" de "borrar".
+type Field[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// SetField devuelve un Field con el valor indicado.
+func SetField[T any](v T) Field[T] {
+	return Field[T]{Set: true, Value: v}
+}
+
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if string(data) == "null" {
+		f.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+// ApplyField aplica el campo del patch sobre dst; null no cambia dst (los campos obligatorios
+// no admiten null y se rechazan antes).
+func ApplyField[T any](dst *T, f Field[T]) {
+	if f.Set && !f.Null {
+		*dst = f.Value
+	}
+}
+
+// ApplyNullable aplica el campo del patch sobre un valor opcional; null lo borra.
+func ApplyNullable[T any](dst **T, f Field[T]) {
+	switch {
+	case !f.Set:
+	case f.Null:
+		*dst = nil
+	default:
+		v := f.Value
+		*dst = &v
+	}
+}
+
+// ChangedField devuelve el campo que lleva de old a new, o uno vacío si no cambió.
+func ChangedField[T comparable](old, new T) Field[T] {
+	if old == new {
+		return Field[T]{}
+	}
+	return SetField(new)
+}
+
+// ChangedNullable es ChangedField para valores opcionales: pasar a nil es un null.
+func ChangedNullable[T comparable](old, new *T) Field[T] {
+	switch {
+	case old == nil && new == nil, old != nil && new != nil && *old == *new:
+		return Field[T]{}
+	case new == nil:
+		return Field[T]{Set: true, Null: true}
+	default:
+		return SetField(*new)
+	}
+}
+
+// ProfilePatch son los datos básicos de un perfil modificables con PATCH.
+type ProfilePatch struct {
+	ProfileName  Field[string] `json:"profileName"`
+	ProfileMail  Field[string] `json:"profileMail"`
+	Phone        Field[string] `json:"phone"`
+	ProfileImage Field[string] `json:"profileImage"` // null borra la imagen
+}
+
+// AddressPatch son los datos de una dirección modificables con PATCH.
+type AddressPatch struct {
+	CP             Field[string] `json:"CP"`
+	Street         Field[string] `json:"street"`
+	Number         Field[int]    `json:"number"`
+	Floor          Field[string] `json:"floor"`     // null borra el piso
+	Apartment      Field[string] `json:"apartment"` // null borra el departamento
+	Locality       Field[string] `json:"locality"`
+	Province       Field[string] `json:"province"`
+	Country        Field[string] `json:"country"`
+	BetweenStreets Field[string] `json:"betweenStreets"` // null las borra
+	References     Field[string] `json:"references"`     // null las borra
+	MainAddress    Field[bool]   `json:"mainAddress"`
+	// Las coordenadas no se reciben en el patch: las actualiza el servicio al cambiar la dirección.
+	Latitude       Field[float64]        `json:"-"`
+	Longitude      Field[float64]        `json:"-"`
+	GeocodeQuality Field[GeocodeQuality] `json:"-"`
+}
+
+// ApplyTo aplica el patch sobre la dirección.
+func (p AddressPatch) ApplyTo(a *Address) {
+	ApplyField(&a.CP, p.CP)
+	ApplyField(&a.Street, p.Street)
+	ApplyField(&a.Number, p.Number)
+	ApplyNullable(&a.Floor, p.Floor)
+	ApplyNullable(&a.Apartment, p.Apartment)
+	ApplyField(&a.Locality, p.Locality)
+	ApplyField(&a.Province, p.Province)
+	ApplyField(&a.Country, p.Country)
+	ApplyNullable(&a.BetweenStreets, p.BetweenStreets)
+	ApplyNullable(&a.References, p.References)
+	ApplyField(&a.MainAddress, p.MainAddress)
+	ApplyNullable(&a.Latitude, p.Latitude)
+	ApplyNullable(&a.Longitude, p.Longitude)
+	ApplyField(&a.GeocodeQuality, p.GeocodeQuality)
+}
+
+// AddressChanges devuelve el patch que lleva de old a new con sólo los campos que cambian.
+func AddressChanges(old, new *Address) AddressPatch {
+	return AddressPatch{
+		CP:             ChangedField(old.CP, new.CP),
+		Street:         ChangedField(old.Street, new.Street),
+		Number:         ChangedField(old.Number, new.Number),
+		Floor:          ChangedNullable(old.Floor, new.Floor),
+		Apartment:      ChangedNullable(old.Apartment, new.Apartment),
+		Locality:       ChangedField(old.Locality, new.Locality),
+		Province:       ChangedField(old.Province, new.Province),
+		Country:        ChangedField(old.Country, new.Country),
+		BetweenStreets: ChangedNullable(old.BetweenStreets, new.BetweenStreets),
+		References:     ChangedNullable(old.References, new.References),
+		MainAddress:    ChangedField(old.MainAddress, new.MainAddress),
+		Latitude:       ChangedNullable(old.Latitude, new.Latitude),
+		Longitude:      ChangedNullable(old.Longitude, new.Longitude),
+		GeocodeQuality: ChangedField(old.GeocodeQuality, new.GeocodeQuality),
+	}
+}
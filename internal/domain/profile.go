@@ -8,24 +8,26 @@ import (
 
 // Profile representa el modelo de datos para un perfil de usuario.
 type Profile struct {
-	ProfileID       uuid.UUID `json:"profileId"`
-	UserID          string    `json:"userId"`
-	ProfileImage    *string   `json:"profileImage,omitempty"`
-	ProfileName     string    `json:"profileName"`
-	ProfileLevel    int       `json:"profileLevel"`
-	ProfilePoints   int       `json:"profilePoints"`
-	ProfileMail     string    `json:"profileMail"`
-	Phone           string    `json:"phone"`
-	CUIL            string    `json:"CUIL"`
-	FiscalAdress    string    `json:"fiscalAdress"`
-	FiscalCondition string    `json:"fiscalCondition"`
-	IIBB            string    `json:"IIBB"`
-	CreationDate    time.Time `json:"creationDate"`
-	UpdatedDate     time.Time `json:"updatedDate"`
+	ProfileID           uuid.UUID       `json:"profileId"`
+	UserID              string          `json:"userId"`
+	ProfileImage        *string         `json:"profileImage,omitempty"`
+	ProfileName         string          `json:"profileName"`
+	ProfileLevel        int             `json:"profileLevel"`
+	ProfilePoints       int             `json:"profilePoints"`
+	ProfileMail         string          `json:"profileMail"`
+	Phone               string          `json:"phone"`
+	CUIL                string          `json:"CUIL"`
+	FiscalAdress        string          `json:"fiscalAdress"`
+	FiscalCondition     FiscalCondition `json:"fiscalCondition"`
+	MonotributoCategory string          `json:"monotributoCategory,omitempty"` // A a K, sólo monotributistas
+	IIBB                *IIBB           `json:"IIBB,omitempty"`
+	CreationDate        time.Time       `json:"creationDate"`
+	UpdatedDate         time.Time       `json:"updatedDate"`
+	Version             int             `json:"version"` // se incrementa en cada modificación (ETag)
 }
 
 // NewProfile crea una nueva instancia de Profile con un ID generado.
-func NewProfile(userID, profileName, profileMail, phone, CUIL, fiscalAdress, fiscalCondition, IIBB string, profileLevel, profilePoints int, profileImage *string) *Profile {
+func NewProfile(userID, profileName, profileMail, phone, CUIL, fiscalAdress string, fiscalCondition FiscalCondition, IIBB *IIBB, profileLevel, profilePoints int, profileImage *string) *Profile {
 	return &Profile{
 		ProfileID:       uuid.New(),
 		UserID:          userID,
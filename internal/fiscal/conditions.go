@@ -0,0 +1,185 @@
+package fiscal
+
+import (
+	"slices"
+	"strings"
+
+	"profilego/internal/domain"
+)
+
+// Condition describe una condición frente al IVA del catálogo.
+type Condition struct {
+	Code domain.FiscalCondition `json:"code"`
+	Name string                 `json:"name"`
+	// RequiresIIBB indica si la condición exige la inscripción en Ingresos Brutos.
+	RequiresIIBB bool `json:"requiresIIBB"`
+	// Categories son las categorías admitidas; vacío si la condición no tiene categorías.
+	Categories []string `json:"categories,omitempty"`
+}
+
+// Regime describe un régimen de Ingresos Brutos del catálogo.
+type Regime struct {
+	Code                 domain.IIBBRegime `json:"code"`
+	Name                 string            `json:"name"`
+	RequiresNumber       bool              `json:"requiresNumber"`
+	RequiresJurisdiction bool              `json:"requiresJurisdiction"`
+}
+
+// Jurisdiction es una jurisdicción de Ingresos Brutos (códigos de la Comisión Arbitral).
+type Jurisdiction struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// monotributoCategories son las categorías vigentes del Monotributo.
+var monotributoCategories = []string{"A", "B", "C", "D", "E", "F", "G", "H", "I", "J", "K"}
+
+// Conditions es el catálogo de condiciones frente al IVA.
+var Conditions = []Condition{
+	{Code: domain.FiscalConditionResponsableInscripto, Name: "IVA Responsable Inscripto", RequiresIIBB: true},
+	{Code: domain.FiscalConditionMonotributista, Name: "Responsable Monotributo", RequiresIIBB: true, Categories: monotributoCategories},
+	{Code: domain.FiscalConditionExento, Name: "IVA Sujeto Exento", RequiresIIBB: true},
+	{Code: domain.FiscalConditionConsumidorFinal, Name: "Consumidor Final"},
+	{Code: domain.FiscalConditionNoResponsable, Name: "IVA No Responsable"},
+}
+
+// Regimes es el catálogo de regímenes de Ingresos Brutos.
+var Regimes = []Regime{
+	{Code: domain.IIBBRegimeLocal, Name: "Contribuyente local", RequiresNumber: true, RequiresJurisdiction: true},
+	{Code: domain.IIBBRegimeConvenioMultilateral, Name: "Convenio Multilateral", RequiresNumber: true},
+	{Code: domain.IIBBRegimeExento, Name: "Exento", RequiresJurisdiction: true},
+}
+
+// Jurisdictions es el catálogo de jurisdicciones de Ingresos Brutos.
+var Jurisdictions = []Jurisdiction{
+	{"901", "Ciudad Autónoma de Buenos Aires"},
+	{"902", "Buenos Aires"},
+	{"903", "Catamarca"},
+	{"904", "Córdoba"},
+	{"905", "Corrientes"},
+	{"906", "Chaco"},
+	{"907", "Chubut"},
+	{"908", "Entre Ríos"},
+	{"909", "Formosa"},
+	{"910", "Jujuy"},
+	{"911", "La Pampa"},
+	{"912", "La Rioja"},
+	{"913", "Mendoza"},
+	{"914", "Misiones"},
+	{"915", "Neuquén"},
+	{"916", "Río Negro"},
+	{"917", "Salta"},
+	{"918", "San Juan"},
+	{"919", "San Luis"},
+	{"920", "Santa Cruz"},
+	{"921", "Santa Fe"},
+	{"922", "Santiago del Estero"},
+	{"923", "Tierra del Fuego"},
+	{"924", "Tucumán"},
+}
+
+func findCondition(code domain.FiscalCondition) (Condition, bool) {
+	i := slices.IndexFunc(Conditions, func(c Condition) bool { return c.Code == code })
+	if i < 0 {
+		return Condition{}, false
+	}
+	return Conditions[i], true
+}
+
+func findRegime(code domain.IIBBRegime) (Regime, bool) {
+	i := slices.IndexFunc(Regimes, func(r Regime) bool { return r.Code == code })
+	if i < 0 {
+		return Regime{}, false
+	}
+	return Regimes[i], true
+}
+
+func validJurisdiction(code string) bool {
+	return slices.ContainsFunc(Jurisdictions, func(j Jurisdiction) bool { return j.Code == code })
+}
+
+// ValidateCondition valida la condición fiscal, la categoría de monotributo y la inscripción
+// en IIBB del perfil, y que sean coherentes entre sí y con el tipo de persona del CUIT
+// (personType vacío si el CUIT no es válido). Normaliza la categoría (mayúscula) y el
+// número de IIBB (sin guiones ni espacios).
+func ValidateCondition(profile *domain.Profile, personType PersonType) []domain.FieldError {
+	var errs []domain.FieldError
+	add := func(field, message string) {
+		errs = append(errs, domain.FieldError{Field: field, Message: message})
+	}
+
+	condition, ok := findCondition(profile.FiscalCondition)
+	switch {
+	case profile.FiscalCondition == "":
+		add("fiscalCondition", "es obligatorio")
+	case !ok:
+		add("fiscalCondition", "no es una condición fiscal válida")
+	}
+
+	profile.MonotributoCategory = strings.ToUpper(strings.TrimSpace(profile.MonotributoCategory))
+	switch {
+	case !ok:
+	case len(condition.Categories) == 0 && profile.MonotributoCategory != "":
+		add("monotributoCategory", "sólo corresponde a monotributistas")
+	case len(condition.Categories) > 0 && profile.MonotributoCategory == "":
+		add("monotributoCategory", "es obligatorio para monotributistas")
+	case len(condition.Categories) > 0 && !slices.Contains(condition.Categories, profile.MonotributoCategory):
+		add("monotributoCategory", "debe ser una categoría de la A a la K")
+	}
+	if profile.FiscalCondition == domain.FiscalConditionMonotributista && personType == PersonLegal {
+		add("fiscalCondition", "una persona jurídica no puede ser monotributista")
+	}
+
+	if profile.IIBB == nil {
+		if condition.RequiresIIBB {
+			add("IIBB", "es obligatorio para esta condición fiscal")
+		}
+		return errs
+	}
+	return append(errs, validateIIBB(profile.IIBB)...)
+}
+
+// validateIIBB valida la inscripción en Ingresos Brutos según su régimen.
+func validateIIBB(iibb *domain.IIBB) []domain.FieldError {
+	var errs []domain.FieldError
+	add := func(field, message string) {
+		errs = append(errs, domain.FieldError{Field: "IIBB." + field, Message: message})
+	}
+
+	regime, ok := findRegime(iibb.Regime)
+	switch {
+	case iibb.Regime == "":
+		add("regime", "es obligatorio")
+	case !ok:
+		add("regime", "debe ser local, convenio_multilateral o exento")
+	}
+
+	iibb.Number = strings.NewReplacer("-", "", " ", "").Replace(iibb.Number)
+	switch {
+	case iibb.Number == "":
+		if regime.RequiresNumber {
+			add("number", "es obligatorio para este régimen")
+		}
+	case !isDigits(iibb.Number) || len(iibb.Number) < 6 || len(iibb.Number) > 13:
+		add("number", "debe tener entre 6 y 13 dígitos")
+	}
+
+	switch {
+	case iibb.Jurisdiction == "":
+		if regime.RequiresJurisdiction {
+			add("jurisdiction", "es obligatorio para este régimen")
+		}
+	case !validJurisdiction(iibb.Jurisdiction):
+		add("jurisdiction", "no es una jurisdicción válida (901 a 924)")
+	}
+	return errs
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
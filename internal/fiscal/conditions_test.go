@@ -0,0 +1,154 @@
+package fiscal
+
+import (
+	"reflect"
+	"testing"
+
+	"profilego/internal/domain"
+)
+
+func TestValidateCondition(t *testing.T) {
+	local := func() *domain.IIBB {
+		return &domain.IIBB{Regime: domain.IIBBRegimeLocal, Number: "1234567", Jurisdiction: "901"}
+	}
+
+	tests := []struct {
+		name         string
+		condition    domain.FiscalCondition
+		category     string
+		iibb         *domain.IIBB
+		personType   PersonType
+		wantErrs     []domain.FieldError
+		wantCategory string
+		wantNumber   string
+	}{
+		{
+			name: "responsable inscripto local", condition: domain.FiscalConditionResponsableInscripto,
+			iibb: local(), personType: PersonLegal, wantNumber: "1234567",
+		},
+		{
+			name: "consumidor final sin IIBB", condition: domain.FiscalConditionConsumidorFinal, personType: PersonNatural,
+		},
+		{
+			name: "sin condición", personType: PersonNatural,
+			wantErrs: []domain.FieldError{{Field: "fiscalCondition", Message: "es obligatorio"}},
+		},
+		{
+			name: "condición desconocida", condition: "autonomo", personType: PersonNatural,
+			wantErrs: []domain.FieldError{{Field: "fiscalCondition", Message: "no es una condición fiscal válida"}},
+		},
+
+		// Categoría de monotributo
+		{
+			name: "monotributista con categoría en minúscula", condition: domain.FiscalConditionMonotributista, category: " c ",
+			iibb: local(), personType: PersonNatural, wantCategory: "C", wantNumber: "1234567",
+		},
+		{
+			name: "monotributista sin categoría", condition: domain.FiscalConditionMonotributista,
+			iibb: local(), personType: PersonNatural, wantNumber: "1234567",
+			wantErrs: []domain.FieldError{{Field: "monotributoCategory", Message: "es obligatorio para monotributistas"}},
+		},
+		{
+			name: "categoría inexistente", condition: domain.FiscalConditionMonotributista, category: "Z",
+			iibb: local(), personType: PersonNatural, wantCategory: "Z", wantNumber: "1234567",
+			wantErrs: []domain.FieldError{{Field: "monotributoCategory", Message: "debe ser una categoría de la A a la K"}},
+		},
+		{
+			name: "categoría en otra condición", condition: domain.FiscalConditionResponsableInscripto, category: "A",
+			iibb: local(), personType: PersonNatural, wantCategory: "A", wantNumber: "1234567",
+			wantErrs: []domain.FieldError{{Field: "monotributoCategory", Message: "sólo corresponde a monotributistas"}},
+		},
+
+		// Tipo de persona del CUIT
+		{
+			name: "persona jurídica monotributista", condition: domain.FiscalConditionMonotributista, category: "A",
+			iibb: local(), personType: PersonLegal, wantCategory: "A", wantNumber: "1234567",
+			wantErrs: []domain.FieldError{{Field: "fiscalCondition", Message: "una persona jurídica no puede ser monotributista"}},
+		},
+		{
+			name: "monotributista con CUIT inválido", condition: domain.FiscalConditionMonotributista, category: "A",
+			iibb: local(), wantCategory: "A", wantNumber: "1234567",
+		},
+
+		// Régimen y jurisdicción de IIBB
+		{
+			name: "condición que exige IIBB", condition: domain.FiscalConditionExento, personType: PersonLegal,
+			wantErrs: []domain.FieldError{{Field: "IIBB", Message: "es obligatorio para esta condición fiscal"}},
+		},
+		{
+			name: "convenio multilateral sin jurisdicción", condition: domain.FiscalConditionResponsableInscripto,
+			iibb:       &domain.IIBB{Regime: domain.IIBBRegimeConvenioMultilateral, Number: "901-123456-7"},
+			personType: PersonLegal, wantNumber: "9011234567",
+		},
+		{
+			name: "local sin jurisdicción", condition: domain.FiscalConditionResponsableInscripto,
+			iibb:       &domain.IIBB{Regime: domain.IIBBRegimeLocal, Number: "1234567"},
+			personType: PersonLegal, wantNumber: "1234567",
+			wantErrs: []domain.FieldError{{Field: "IIBB.jurisdiction", Message: "es obligatorio para este régimen"}},
+		},
+		{
+			name: "exento sin número", condition: domain.FiscalConditionExento,
+			iibb:       &domain.IIBB{Regime: domain.IIBBRegimeExento, Jurisdiction: "904"},
+			personType: PersonLegal,
+		},
+		{
+			name: "exento sin jurisdicción", condition: domain.FiscalConditionExento,
+			iibb:       &domain.IIBB{Regime: domain.IIBBRegimeExento},
+			personType: PersonLegal,
+			wantErrs:   []domain.FieldError{{Field: "IIBB.jurisdiction", Message: "es obligatorio para este régimen"}},
+		},
+		{
+			name: "jurisdicción fuera del catálogo", condition: domain.FiscalConditionResponsableInscripto,
+			iibb:       &domain.IIBB{Regime: domain.IIBBRegimeLocal, Number: "1234567", Jurisdiction: "925"},
+			personType: PersonLegal, wantNumber: "1234567",
+			wantErrs: []domain.FieldError{{Field: "IIBB.jurisdiction", Message: "no es una jurisdicción válida (901 a 924)"}},
+		},
+		{
+			name: "régimen desconocido", condition: domain.FiscalConditionResponsableInscripto,
+			iibb:       &domain.IIBB{Regime: "simplificado", Number: "1234567", Jurisdiction: "901"},
+			personType: PersonLegal, wantNumber: "1234567",
+			wantErrs: []domain.FieldError{{Field: "IIBB.regime", Message: "debe ser local, convenio_multilateral o exento"}},
+		},
+		{
+			name: "sin régimen", condition: domain.FiscalConditionResponsableInscripto,
+			iibb:       &domain.IIBB{Number: "12"},
+			personType: PersonLegal, wantNumber: "12",
+			wantErrs: []domain.FieldError{
+				{Field: "IIBB.regime", Message: "es obligatorio"},
+				{Field: "IIBB.number", Message: "debe tener entre 6 y 13 dígitos"},
+			},
+		},
+		{
+			name: "número con letras", condition: domain.FiscalConditionResponsableInscripto,
+			iibb:       &domain.IIBB{Regime: domain.IIBBRegimeConvenioMultilateral, Number: "90A123456"},
+			personType: PersonLegal, wantNumber: "90A123456",
+			wantErrs: []domain.FieldError{{Field: "IIBB.number", Message: "debe tener entre 6 y 13 dígitos"}},
+		},
+		{
+			name: "varios errores juntos", condition: domain.FiscalConditionMonotributista,
+			iibb:       &domain.IIBB{Regime: domain.IIBBRegimeLocal},
+			personType: PersonLegal,
+			wantErrs: []domain.FieldError{
+				{Field: "monotributoCategory", Message: "es obligatorio para monotributistas"},
+				{Field: "fiscalCondition", Message: "una persona jurídica no puede ser monotributista"},
+				{Field: "IIBB.number", Message: "es obligatorio para este régimen"},
+				{Field: "IIBB.jurisdiction", Message: "es obligatorio para este régimen"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := &domain.Profile{FiscalCondition: tt.condition, MonotributoCategory: tt.category, IIBB: tt.iibb}
+			errs := ValidateCondition(profile, tt.personType)
+			if !reflect.DeepEqual(errs, tt.wantErrs) {
+				t.Fatalf("errores %v, esperaba %v", errs, tt.wantErrs)
+			}
+			if profile.MonotributoCategory != tt.wantCategory {
+				t.Fatalf("categoría %q, esperaba %q", profile.MonotributoCategory, tt.wantCategory)
+			}
+			if profile.IIBB != nil && profile.IIBB.Number != tt.wantNumber {
+				t.Fatalf("número de IIBB %q, esperaba %q", profile.IIBB.Number, tt.wantNumber)
+			}
+		})
+	}
+}
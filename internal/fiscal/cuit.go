@@ -0,0 +1,89 @@
+// Package fiscal valida identificadores tributarios argentinos.
+package fiscal
+
+import (
+	"errors"
+	"strings"
+)
+
+// PersonType es el tipo de contribuyente que indica el prefijo de un CUIT/CUIL.
+type PersonType string
+
+const (
+	PersonNatural PersonType = "persona_fisica"
+	PersonLegal   PersonType = "persona_juridica"
+)
+
+// prefixes son los prefijos que asigna AFIP y el tipo de persona de cada uno.
+var prefixes = map[string]PersonType{
+	"20": PersonNatural,
+	"23": PersonNatural,
+	"24": PersonNatural,
+	"27": PersonNatural,
+	"30": PersonLegal,
+	"33": PersonLegal,
+	"34": PersonLegal,
+}
+
+// checkWeights son los pesos del dígito verificador (módulo 11) para los primeros 10 dígitos.
+var checkWeights = [10]int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2}
+
+// Errores de Parse. Sus mensajes están pensados para informarse como error del campo.
+var (
+	ErrEmpty      = errors.New("es obligatorio")
+	ErrCharacters = errors.New("sólo puede contener dígitos y guiones")
+	ErrLength     = errors.New("debe tener 11 dígitos")
+	ErrPrefix     = errors.New("el prefijo debe ser 20, 23, 24, 27, 30, 33 o 34")
+	ErrCheckDigit = errors.New("el dígito verificador no es válido")
+)
+
+// CUIT es un CUIT/CUIL válido. Number está normalizado: 11 dígitos sin guiones.
+type CUIT struct {
+	Number string     `json:"number"`
+	Type   PersonType `json:"type"`
+}
+
+// Formatted devuelve el número con guiones (XX-XXXXXXXX-X).
+func (c CUIT) Formatted() string {
+	return c.Number[:2] + "-" + c.Number[2:10] + "-" + c.Number[10:]
+}
+
+// Parse normaliza (quita guiones y espacios) y valida un CUIT/CUIL: 11 dígitos, prefijo
+// válido y dígito verificador.
+func Parse(s string) (CUIT, error) {
+	number := strings.NewReplacer("-", "", " ", "").Replace(s)
+	if number == "" {
+		return CUIT{}, ErrEmpty
+	}
+	if !isDigits(number) {
+		return CUIT{}, ErrCharacters
+	}
+	if len(number) != 11 {
+		return CUIT{}, ErrLength
+	}
+	personType, ok := prefixes[number[:2]]
+	if !ok {
+		return CUIT{}, ErrPrefix
+	}
+	if checkDigit(number[:10]) != int(number[10]-'0') {
+		return CUIT{}, ErrCheckDigit
+	}
+	return CUIT{Number: number, Type: personType}, nil
+}
+
+// checkDigit calcula el dígito verificador de los primeros 10 dígitos. Un resto de 10 no
+// tiene dígito posible (AFIP asigna otro prefijo en ese caso), así que devuelve -1.
+func checkDigit(digits string) int {
+	sum := 0
+	for i, w := range checkWeights {
+		sum += int(digits[i]-'0') * w
+	}
+	switch d := 11 - sum%11; d {
+	case 11:
+		return 0
+	case 10:
+		return -1
+	default:
+		return d
+	}
+}
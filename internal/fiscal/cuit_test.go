@@ -0,0 +1,77 @@
+package fiscal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantNumber string
+		wantType   PersonType
+		wantErr    error
+	}{
+		{"persona jurídica con guiones", "33-69345023-9", "33693450239", PersonLegal, nil},
+		{"persona jurídica sin guiones", "30500010912", "30500010912", PersonLegal, nil},
+		{"persona física con guiones", "20-17254359-7", "20172543597", PersonNatural, nil},
+		{"persona física con espacios", "20 12345678 6", "20123456786", PersonNatural, nil},
+		{"resto 10 con prefijo 20", "20-12345600-0", "", "", ErrCheckDigit},
+		{"resto 10 remapeado a 23", "23-12345600-9", "23123456009", PersonNatural, nil},
+		{"resto 10 remapeado a 27", "27-12345600-4", "27123456004", PersonNatural, nil},
+		{"resto 10 remapeado a 33", "33-30000005-9", "33300000059", PersonLegal, nil},
+		{"dígito verificador incorrecto", "20-17254359-8", "", "", ErrCheckDigit},
+		{"prefijo inválido", "21-17254359-7", "", "", ErrPrefix},
+		{"vacío", " - ", "", "", ErrEmpty},
+		{"letras", "20-1725435A-7", "", "", ErrCharacters},
+		{"corto", "20-1725435-7", "", "", ErrLength},
+		{"largo", "20-172543590-7", "", "", ErrLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q): esperaba %v, obtuve %v", tt.input, tt.wantErr, err)
+			}
+			if got.Number != tt.wantNumber || got.Type != tt.wantType {
+				t.Fatalf("Parse(%q) = %+v, esperaba %s (%s)", tt.input, got, tt.wantNumber, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   int
+	}{
+		{"2017254359", 7},
+		{"3369345023", 9},
+		{"2012345678", 6},
+		// Resto 10: no hay dígito posible con este prefijo, AFIP asigna 23/27 (o 33)
+		{"2012345600", -1},
+		{"2312345600", 9},
+		{"2712345600", 4},
+		{"3030000005", -1},
+		{"3330000005", 9},
+	}
+	for _, tt := range tests {
+		if got := checkDigit(tt.digits); got != tt.want {
+			t.Errorf("checkDigit(%s) = %d, esperaba %d", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestCUITFormatted(t *testing.T) {
+	cuit, err := Parse("20172543597")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cuit.Formatted(); got != "20-17254359-7" {
+		t.Fatalf("Formatted() = %q", got)
+	}
+	if again, err := Parse(cuit.Formatted()); err != nil || again != cuit {
+		t.Fatalf("el formato con guiones debe volver a parsearse igual: (%+v, %v)", again, err)
+	}
+}
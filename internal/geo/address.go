@@ -0,0 +1,76 @@
+package geo
+
+import (
+	"fmt"
+	"strings"
+
+	"profilego/internal/domain"
+)
+
+// ValidateAddress valida los datos geográficos de la dirección: país, provincia, localidad y
+// código postal. Las reglas de provincia y CPA sólo se aplican a direcciones de Argentina (el
+// país por defecto). Normaliza el país y la provincia (mayúsculas), el código postal (sin
+// espacios) y descarta los opcionales vacíos.
+func ValidateAddress(address *domain.Address) []domain.FieldError {
+	var errs []domain.FieldError
+	add := func(field, message string) {
+		errs = append(errs, domain.FieldError{Field: field, Message: message})
+	}
+
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	if address.Country == "" {
+		address.Country = domain.CountryArgentina
+	}
+	if len(address.Country) != 2 || !isLetters(address.Country) {
+		add("country", "debe ser un código ISO 3166-1 de 2 letras")
+	}
+
+	address.Locality = strings.TrimSpace(address.Locality)
+	if address.Locality == "" {
+		add("locality", "es obligatorio")
+	}
+	address.Apartment = trimOptional(address.Apartment)
+	address.BetweenStreets = trimOptional(address.BetweenStreets)
+	address.References = trimOptional(address.References)
+
+	if address.Country != domain.CountryArgentina {
+		address.Province = strings.TrimSpace(address.Province)
+		address.CP = strings.TrimSpace(address.CP)
+		if address.CP == "" {
+			add("CP", "es obligatorio")
+		}
+		return errs
+	}
+
+	address.Province = strings.ToUpper(strings.TrimSpace(address.Province))
+	province, ok := FindProvince(address.Province)
+	switch {
+	case address.Province == "":
+		add("province", "es obligatorio")
+	case !ok:
+		add("province", "no es un código de provincia válido (ISO 3166-2:AR, p. ej. AR-C)")
+	}
+
+	cp, err := ParsePostalCode(address.CP)
+	switch {
+	case err != nil:
+		add("CP", err.Error())
+	case ok && cp.CPA && cp.ProvinceLetter() != province.Letter():
+		add("CP", fmt.Sprintf("el CPA no corresponde a %s (debe empezar con %c)", province.Name, province.Letter()))
+	default:
+		address.CP = cp.Code
+	}
+	return errs
+}
+
+// trimOptional recorta un valor opcional; vacío equivale a no informarlo.
+func trimOptional(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*s)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,108 @@
+package geo
+
+import (
+	"reflect"
+	"testing"
+
+	"profilego/internal/domain"
+)
+
+func TestValidateAddress(t *testing.T) {
+	tests := []struct {
+		name         string
+		address      domain.Address
+		wantErrs     []domain.FieldError
+		wantCP       string
+		wantProvince string
+		wantCountry  string
+	}{
+		{
+			name:    "CPA de la provincia",
+			address: domain.Address{CP: "C1425ABC", Province: "AR-C", Locality: "Palermo"},
+			wantCP:  "C1425ABC", wantProvince: "AR-C", wantCountry: "AR",
+		},
+		{
+			name:    "minúsculas y espacios",
+			address: domain.Address{CP: " x5000 abc ", Province: " ar-x ", Locality: " Córdoba ", Country: "ar"},
+			wantCP:  "X5000ABC", wantProvince: "AR-X", wantCountry: "AR",
+		},
+		{
+			name:    "CP de 4 dígitos no se compara con la provincia",
+			address: domain.Address{CP: "5000", Province: "AR-C", Locality: "Córdoba"},
+			wantCP:  "5000", wantProvince: "AR-C", wantCountry: "AR",
+		},
+		{
+			name:    "CPA de otra provincia",
+			address: domain.Address{CP: "X5000ABC", Province: "AR-C", Locality: "Palermo"},
+			wantErrs: []domain.FieldError{
+				{Field: "CP", Message: "el CPA no corresponde a Ciudad Autónoma de Buenos Aires (debe empezar con C)"},
+			},
+			wantCP: "X5000ABC", wantProvince: "AR-C", wantCountry: "AR",
+		},
+		{
+			name:    "provincia inválida no informa el CPA",
+			address: domain.Address{CP: "C1425ABC", Province: "AR-I", Locality: "Palermo"},
+			wantErrs: []domain.FieldError{
+				{Field: "province", Message: "no es un código de provincia válido (ISO 3166-2:AR, p. ej. AR-C)"},
+			},
+			wantCP: "C1425ABC", wantProvince: "AR-I", wantCountry: "AR",
+		},
+		{
+			name:    "obligatorios",
+			address: domain.Address{},
+			wantErrs: []domain.FieldError{
+				{Field: "locality", Message: "es obligatorio"},
+				{Field: "province", Message: "es obligatorio"},
+				{Field: "CP", Message: "es obligatorio"},
+			},
+			wantCountry: "AR",
+		},
+		{
+			name:    "CP con formato inválido",
+			address: domain.Address{CP: "C14", Province: "AR-C", Locality: "Palermo"},
+			wantErrs: []domain.FieldError{
+				{Field: "CP", Message: ErrPostalCodeFormat.Error()},
+			},
+			wantCP: "C14", wantProvince: "AR-C", wantCountry: "AR",
+		},
+		{
+			name:    "otro país no valida provincia ni CPA",
+			address: domain.Address{CP: " 11000 ", Province: " Montevideo ", Locality: "Centro", Country: "uy"},
+			wantCP:  "11000", wantProvince: "Montevideo", wantCountry: "UY",
+		},
+		{
+			name:    "país inválido",
+			address: domain.Address{CP: "11000", Locality: "Centro", Country: "URY"},
+			wantErrs: []domain.FieldError{
+				{Field: "country", Message: "debe ser un código ISO 3166-1 de 2 letras"},
+			},
+			wantCP: "11000", wantCountry: "URY",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := tt.address
+			errs := ValidateAddress(&address)
+			if !reflect.DeepEqual(errs, tt.wantErrs) {
+				t.Fatalf("errores %+v, esperaba %+v", errs, tt.wantErrs)
+			}
+			if address.CP != tt.wantCP || address.Province != tt.wantProvince || address.Country != tt.wantCountry {
+				t.Fatalf("normalizó a CP %q, provincia %q, país %q", address.CP, address.Province, address.Country)
+			}
+		})
+	}
+}
+
+func TestValidateAddressOptionals(t *testing.T) {
+	blank, refs := "  ", " timbre 2 "
+	address := domain.Address{CP: "1425", Province: "AR-C", Locality: "Palermo", Apartment: &blank, References: &refs}
+	if errs := ValidateAddress(&address); errs != nil {
+		t.Fatalf("ValidateAddress: %+v", errs)
+	}
+	if address.Apartment != nil {
+		t.Fatalf("un opcional vacío debe descartarse: %q", *address.Apartment)
+	}
+	if address.References == nil || *address.References != "timbre 2" {
+		t.Fatalf("los opcionales se recortan: %v", address.References)
+	}
+}
@@ -0,0 +1,49 @@
+package geo
+
+import "math"
+
+// EarthRadiusKm es el radio medio de la Tierra que usan DistanceKm y BoundingBox.
+const EarthRadiusKm = 6371.0088
+
+// DistanceKm devuelve la distancia en km entre dos puntos por la fórmula de haversine. Es la
+// misma fórmula que usa AddressRepository en SQL.
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := radians(lat2 - lat1)
+	dLon := radians(lon2 - lon1)
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(radians(lat1))*math.Cos(radians(lat2))*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// Box es un rectángulo de latitudes y longitudes en grados.
+type Box struct {
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+}
+
+// BoundingBox devuelve un rectángulo que contiene todos los puntos a menos de radiusKm de
+// (lat, lon). Sirve de prefiltro barato (y para usar índices) antes de calcular DistanceKm.
+// Si el círculo toca un polo o cruza el antimeridiano cubre todas las longitudes.
+func BoundingBox(lat, lon, radiusKm float64) Box {
+	dLat := radiusKm / EarthRadiusKm * 180 / math.Pi
+	box := Box{MinLat: lat - dLat, MaxLat: lat + dLat, MinLon: -180, MaxLon: 180}
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.MinLat, box.MaxLat = math.Max(box.MinLat, -90), math.Min(box.MaxLat, 90)
+		return box
+	}
+
+	// Meridianos tangentes al círculo (ver http://janmatuschek.de/LatitudeLongitudeBoundingCoordinates).
+	dLon := math.Asin(math.Sin(radians(dLat))/math.Cos(radians(lat))) * 180 / math.Pi
+	if lon-dLon > -180 && lon+dLon < 180 {
+		box.MinLon, box.MaxLon = lon-dLon, lon+dLon
+	}
+	return box
+}
+
+// Contains indica si el punto está dentro del rectángulo.
+func (b Box) Contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
@@ -0,0 +1,85 @@
+package geo
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"profilego/internal/domain"
+)
+
+// Geocoder obtiene las coordenadas de una dirección. Una dirección que el proveedor no
+// encuentra no es un error: se informa con domain.GeocodeNotFound. Los errores son fallas del
+// proveedor y la dirección queda pendiente para reintentarla.
+type Geocoder interface {
+	Geocode(ctx context.Context, address domain.Address) (domain.Geocode, error)
+}
+
+// AddressKey es la dirección normalizada que se geocodifica: calle, altura, localidad,
+// provincia, código postal y país. Dos direcciones con la misma clave tienen las mismas
+// coordenadas, así que sirve de clave del cache y para saber si hay que volver a geocodificar.
+func AddressKey(address domain.Address) string {
+	return strings.Join([]string{
+		SearchKey(address.Street),
+		fmt.Sprint(address.Number),
+		SearchKey(address.Locality),
+		strings.ToUpper(address.Province),
+		strings.ToUpper(address.CP),
+		strings.ToUpper(address.Country),
+	}, "|")
+}
+
+// CachedGeocoder guarda los resultados de otro Geocoder por AddressKey en un LRU acotado.
+// Los errores no se guardan. Es seguro para uso concurrente.
+type CachedGeocoder struct {
+	next Geocoder
+	size int
+
+	mu      sync.Mutex
+	order   *list.List // frente = usado más recientemente
+	entries map[string]*list.Element
+}
+
+type geocodeEntry struct {
+	key     string
+	geocode domain.Geocode
+}
+
+// NewCachedGeocoder envuelve next con un cache de hasta size resultados.
+func NewCachedGeocoder(next Geocoder, size int) *CachedGeocoder {
+	return &CachedGeocoder{next: next, size: size, order: list.New(), entries: make(map[string]*list.Element, size)}
+}
+
+func (c *CachedGeocoder) Geocode(ctx context.Context, address domain.Address) (domain.Geocode, error) {
+	key := AddressKey(address)
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		geocode := elem.Value.(*geocodeEntry).geocode
+		c.mu.Unlock()
+		return geocode, nil
+	}
+	c.mu.Unlock()
+
+	geocode, err := c.next.Geocode(ctx, address)
+	if err != nil {
+		return domain.Geocode{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*geocodeEntry).geocode = geocode
+		c.order.MoveToFront(elem)
+		return geocode, nil
+	}
+	c.entries[key] = c.order.PushFront(&geocodeEntry{key: key, geocode: geocode})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*geocodeEntry).key)
+	}
+	return geocode, nil
+}
@@ -0,0 +1,174 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"profilego/internal/domain"
+)
+
+// countingGeocoder devuelve la altura de la dirección como latitud y cuenta las consultas por
+// calle. Las calles de fail responden error.
+type countingGeocoder struct {
+	calls map[string]int
+	fail  map[string]bool
+}
+
+func (g *countingGeocoder) Geocode(ctx context.Context, address domain.Address) (domain.Geocode, error) {
+	g.calls[address.Street]++
+	if g.fail[address.Street] {
+		return domain.Geocode{}, errors.New("proveedor caído")
+	}
+	return domain.Geocode{Latitude: float64(address.Number), Quality: domain.GeocodeExact}, nil
+}
+
+func cachedAddress(street string) domain.Address {
+	return domain.Address{Street: street, Number: 100, Locality: "Palermo", Province: "AR-C", CP: "C1425ABC", Country: "AR"}
+}
+
+func TestCachedGeocoder(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reutiliza el resultado de la misma dirección normalizada", func(t *testing.T) {
+		next := &countingGeocoder{calls: map[string]int{}}
+		cache := NewCachedGeocoder(next, 2)
+		a := cachedAddress("Av. Santa Fe")
+		b := a
+		b.Street, b.Locality, b.Province = "AV. SANTA FE", "palermo", "ar-c"
+		b.Floor, b.Version = new(string), 7 // el piso y la versión no forman parte de la clave
+		if _, err := cache.Geocode(ctx, a); err != nil {
+			t.Fatalf("Geocode: %v", err)
+		}
+		got, err := cache.Geocode(ctx, b)
+		if err != nil || got.Latitude != 100 {
+			t.Fatalf("Geocode desde el cache: %+v %v", got, err)
+		}
+		if next.calls["Av. Santa Fe"] != 1 || next.calls["AV. SANTA FE"] != 0 {
+			t.Fatalf("esperaba una sola consulta al proveedor: %v", next.calls)
+		}
+	})
+
+	t.Run("descarta la entrada usada hace más tiempo", func(t *testing.T) {
+		next := &countingGeocoder{calls: map[string]int{}}
+		cache := NewCachedGeocoder(next, 2)
+		for _, street := range []string{"A", "B", "A", "C", "A", "B"} {
+			if _, err := cache.Geocode(ctx, cachedAddress(street)); err != nil {
+				t.Fatalf("Geocode %s: %v", street, err)
+			}
+		}
+		// C desaloja a B (A se usó después); al volver B desaloja a C
+		want := map[string]int{"A": 1, "B": 2, "C": 1}
+		for street, n := range want {
+			if next.calls[street] != n {
+				t.Fatalf("consultas al proveedor %v, esperaba %v", next.calls, want)
+			}
+		}
+		if cache.order.Len() != 2 || len(cache.entries) != 2 {
+			t.Fatalf("el cache debía quedar con 2 entradas: %d %d", cache.order.Len(), len(cache.entries))
+		}
+	})
+
+	t.Run("no guarda los errores", func(t *testing.T) {
+		next := &countingGeocoder{calls: map[string]int{}, fail: map[string]bool{"A": true}}
+		cache := NewCachedGeocoder(next, 2)
+		for range 2 {
+			if _, err := cache.Geocode(ctx, cachedAddress("A")); err == nil {
+				t.Fatal("esperaba el error del proveedor")
+			}
+		}
+		next.fail["A"] = false
+		if got, err := cache.Geocode(ctx, cachedAddress("A")); err != nil || got.Quality != domain.GeocodeExact {
+			t.Fatalf("después de la falla debía consultar de nuevo: %+v %v", got, err)
+		}
+		if next.calls["A"] != 3 || len(cache.entries) != 1 {
+			t.Fatalf("consultas %d, entradas %d", next.calls["A"], len(cache.entries))
+		}
+	})
+}
+
+const testCoordinates = `province,locality,street,number,lat,lon
+AR-C,Palermo,,,-34.5889,-58.4306
+AR-C,Palermo,Av. Santa Fe,3253,-34.5880,-58.4110
+AR-X,Córdoba,,,-31.4201,-64.1888
+`
+
+func TestLocalGeocoder(t *testing.T) {
+	g, err := NewLocalGeocoder(strings.NewReader(testCoordinates))
+	if err != nil {
+		t.Fatalf("NewLocalGeocoder: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		address domain.Address
+		want    domain.Geocode
+	}{
+		{
+			name:    "dirección exacta",
+			address: domain.Address{Province: "AR-C", Locality: "Palermo", Street: "Av. Santa Fe", Number: 3253},
+			want:    domain.Geocode{Latitude: -34.5880, Longitude: -58.4110, Quality: domain.GeocodeExact},
+		},
+		{
+			name:    "sin distinguir mayúsculas ni acentos",
+			address: domain.Address{Province: "ar-c", Locality: "PALERMO", Street: "av. santa fé", Number: 3253},
+			want:    domain.Geocode{Latitude: -34.5880, Longitude: -58.4110, Quality: domain.GeocodeExact},
+		},
+		{
+			name:    "otra altura usa el centro de la localidad",
+			address: domain.Address{Province: "AR-C", Locality: "Palermo", Street: "Av. Santa Fe", Number: 3300},
+			want:    domain.Geocode{Latitude: -34.5889, Longitude: -58.4306, Quality: domain.GeocodeLocality},
+		},
+		{
+			name:    "localidad sin acento",
+			address: domain.Address{Province: "AR-X", Locality: "Cordoba", Street: "Colón", Number: 100},
+			want:    domain.Geocode{Latitude: -31.4201, Longitude: -64.1888, Quality: domain.GeocodeLocality},
+		},
+		{
+			name:    "la misma localidad en otra provincia",
+			address: domain.Address{Province: "AR-B", Locality: "Palermo", Street: "Av. Santa Fe", Number: 3253},
+			want:    domain.Geocode{Quality: domain.GeocodeNotFound},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.Geocode(context.Background(), tt.address)
+			if err != nil || got != tt.want {
+				t.Fatalf("Geocode = %+v, %v; esperaba %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLocalGeocoderInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"sin encabezado", ""},
+		{"otro encabezado", "provincia,localidad,calle,altura,lat,lon\n"},
+		{"latitud fuera de rango", "province,locality,street,number,lat,lon\nAR-C,Palermo,,,-134.5,-58.4\n"},
+		{"coordenada que no es un número", "province,locality,street,number,lat,lon\nAR-C,Palermo,,,norte,-58.4\n"},
+		{"altura inválida", "province,locality,street,number,lat,lon\nAR-C,Palermo,Av. Santa Fe,s/n,-34.5,-58.4\n"},
+		{"columnas de menos", "province,locality,street,number,lat,lon\nAR-C,Palermo,-34.5,-58.4\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLocalGeocoder(strings.NewReader(tt.csv)); err == nil {
+				t.Fatal("esperaba un error")
+			}
+		})
+	}
+}
+
+func TestBundledGeocoder(t *testing.T) {
+	g, err := BundledGeocoder()
+	if err != nil {
+		t.Fatalf("BundledGeocoder: %v", err)
+	}
+	got, _ := g.Geocode(context.Background(), domain.Address{Province: "AR-C", Locality: "Recoleta", Street: "Av. Callao", Number: 1000})
+	if !got.Found() {
+		t.Fatalf("la tabla incluida debía tener Recoleta: %+v", got)
+	}
+}
@@ -0,0 +1,99 @@
+package geo
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"profilego/internal/domain"
+)
+
+// bundledCoordinates son las coordenadas incluidas en el binario: el centro de las capitales
+// provinciales y de algunas ciudades y barrios del catálogo de localidades.
+//
+//go:embed data/coordinates.csv
+var bundledCoordinates []byte
+
+// LocalGeocoder geocodifica sin salir a la red a partir de una tabla de coordenadas. Es
+// determinístico: la misma dirección da siempre el mismo resultado.
+//
+// Cada fila de la tabla es una dirección exacta (con calle y altura) o el centro de una
+// localidad (calle y altura vacías). Una dirección que coincide con una fila exacta es
+// domain.GeocodeExact; si no, se usa el centro de su localidad (domain.GeocodeLocality).
+type LocalGeocoder struct {
+	exact      map[string]domain.Geocode
+	localities map[string]domain.Geocode
+}
+
+// NewLocalGeocoder lee la tabla en CSV con encabezado province,locality,street,number,lat,lon.
+func NewLocalGeocoder(r io.Reader) (*LocalGeocoder, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 6
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el encabezado: %w", err)
+	}
+	if strings.Join(header, ",") != "province,locality,street,number,lat,lon" {
+		return nil, errors.New("el encabezado debe ser province,locality,street,number,lat,lon")
+	}
+
+	g := &LocalGeocoder{exact: make(map[string]domain.Geocode), localities: make(map[string]domain.Geocode)}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return g, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		lat, errLat := strconv.ParseFloat(record[4], 64)
+		lon, errLon := strconv.ParseFloat(record[5], 64)
+		if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("línea %d: coordenadas inválidas", line)
+		}
+
+		locality := localityKey(record[0], record[1])
+		if record[2] == "" {
+			g.localities[locality] = domain.Geocode{Latitude: lat, Longitude: lon, Quality: domain.GeocodeLocality}
+			continue
+		}
+		number, err := strconv.Atoi(record[3])
+		if err != nil {
+			return nil, fmt.Errorf("línea %d: altura inválida %q", line, record[3])
+		}
+		g.exact[exactKey(locality, record[2], number)] = domain.Geocode{Latitude: lat, Longitude: lon, Quality: domain.GeocodeExact}
+	}
+}
+
+// BundledGeocoder devuelve un LocalGeocoder con las coordenadas incluidas en el binario.
+func BundledGeocoder() (*LocalGeocoder, error) {
+	return NewLocalGeocoder(bytes.NewReader(bundledCoordinates))
+}
+
+func (g *LocalGeocoder) Geocode(ctx context.Context, address domain.Address) (domain.Geocode, error) {
+	locality := localityKey(address.Province, address.Locality)
+	if geocode, ok := g.exact[exactKey(locality, address.Street, address.Number)]; ok {
+		return geocode, nil
+	}
+	if geocode, ok := g.localities[locality]; ok {
+		return geocode, nil
+	}
+	return domain.Geocode{Quality: domain.GeocodeNotFound}, nil
+}
+
+func localityKey(province, locality string) string {
+	return strings.ToUpper(strings.TrimSpace(province)) + "|" + SearchKey(locality)
+}
+
+func exactKey(locality, street string, number int) string {
+	return fmt.Sprintf("%s|%s|%d", locality, SearchKey(street), number)
+}
@@ -0,0 +1,129 @@
+package geo
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"profilego/internal/domain"
+)
+
+// bundledLocalities es el catálogo de localidades incluido en el binario: las ciudades
+// principales de cada provincia. Para validar todo el país se carga el padrón completo con
+// `profilego geo load archivo.csv`.
+//
+//go:embed data/localities.csv
+var bundledLocalities []byte
+
+// BundledLocalities devuelve el catálogo de localidades incluido en el binario.
+func BundledLocalities() ([]domain.Locality, error) {
+	return ReadLocalities(bytes.NewReader(bundledLocalities))
+}
+
+// ReadLocalities lee un catálogo de localidades en CSV con encabezado province,locality,cp.
+// Valida la provincia (ISO 3166-2:AR) y el código postal de 4 dígitos de cada fila.
+func ReadLocalities(r io.Reader) ([]domain.Locality, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el encabezado: %w", err)
+	}
+	if strings.Join(header, ",") != "province,locality,cp" {
+		return nil, errors.New("el encabezado debe ser province,locality,cp")
+	}
+
+	var localities []domain.Locality
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return localities, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		locality := domain.Locality{
+			Province: strings.ToUpper(strings.TrimSpace(record[0])),
+			Name:     strings.TrimSpace(record[1]),
+			CP:       strings.TrimSpace(record[2]),
+		}
+		if _, ok := FindProvince(locality.Province); !ok {
+			return nil, fmt.Errorf("línea %d: provincia inválida %q", line, record[0])
+		}
+		if locality.Name == "" {
+			return nil, fmt.Errorf("línea %d: falta el nombre de la localidad", line)
+		}
+		if cp, err := ParsePostalCode(locality.CP); err != nil || cp.CPA {
+			return nil, fmt.Errorf("línea %d: código postal inválido %q", line, record[2])
+		}
+		localities = append(localities, locality)
+	}
+}
+
+// SearchKey normaliza un nombre para buscarlo: minúsculas, sin acentos y con los signos
+// reemplazados por un espacio ("San Ramón de la Nueva Orán" → "san ramon de la nueva oran").
+func SearchKey(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if f, ok := unaccent[r]; ok {
+			r = f
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+var unaccent = map[rune]rune{
+	'á': 'a', 'à': 'a', 'ä': 'a', 'â': 'a',
+	'é': 'e', 'è': 'e', 'ë': 'e', 'ê': 'e',
+	'í': 'i', 'ì': 'i', 'ï': 'i', 'î': 'i',
+	'ó': 'o', 'ò': 'o', 'ö': 'o', 'ô': 'o',
+	'ú': 'u', 'ù': 'u', 'ü': 'u', 'û': 'u',
+	'ñ': 'n', 'ç': 'c',
+}
+
+// MatchesKey indica si la clave de búsqueda key empieza con query o tiene una palabra que
+// empieza con query (ambas normalizadas con SearchKey).
+func MatchesKey(key, query string) bool {
+	return query == "" || strings.HasPrefix(key, query) || strings.Contains(key, " "+query)
+}
+
+// PostalCodePrefix interpreta lo que el usuario lleva escrito de un código postal: un prefijo
+// del código de 4 dígitos ("14", "1425") o de un CPA ("C14", "C1425ABC"). Devuelve la
+// provincia del CPA (vacía si no tiene letra) y los dígitos escritos.
+func PostalCodePrefix(s string) (province, digits string, ok bool) {
+	code := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	if code != "" && code[0] >= 'A' && code[0] <= 'Z' {
+		if !isProvinceLetter(code[0]) {
+			return "", "", false
+		}
+		province, code = "AR-"+code[:1], code[1:]
+		if len(code) > 4 {
+			if len(code) > 7 || !isLetters(code[4:]) {
+				return "", "", false
+			}
+			code = code[:4]
+		}
+	}
+	if len(code) > 4 || !isDigits(code) || strings.HasPrefix(code, "0") {
+		return "", "", false
+	}
+	return province, code, true
+}
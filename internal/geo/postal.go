@@ -0,0 +1,70 @@
+package geo
+
+import (
+	"errors"
+	"strings"
+)
+
+// Errores de ParsePostalCode. Sus mensajes están pensados para informarse como error del campo.
+var (
+	ErrPostalCodeEmpty  = errors.New("es obligatorio")
+	ErrPostalCodeFormat = errors.New("debe ser un código de 4 dígitos o un CPA (letra, 4 dígitos y 3 letras, p. ej. C1425ABC)")
+)
+
+// PostalCode es un código postal argentino normalizado (sin espacios, en mayúsculas).
+type PostalCode struct {
+	Code string
+	// CPA indica si es un Código Postal Argentino de 8 caracteres; si no, es el código de 4 dígitos.
+	CPA bool
+}
+
+// ProvinceLetter devuelve la letra de provincia del CPA, o 0 si es un código de 4 dígitos.
+func (c PostalCode) ProvinceLetter() byte {
+	if !c.CPA {
+		return 0
+	}
+	return c.Code[0]
+}
+
+// ParsePostalCode normaliza y valida un código postal: el formato anterior de 4 dígitos
+// (1000 a 9999) o el CPA (letra de provincia + 4 dígitos + 3 letras de manzana).
+func ParsePostalCode(s string) (PostalCode, error) {
+	code := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	switch {
+	case code == "":
+		return PostalCode{}, ErrPostalCodeEmpty
+	case len(code) == 4 && code[0] != '0' && isDigits(code):
+		return PostalCode{Code: code}, nil
+	case len(code) == 8 && isProvinceLetter(code[0]) && isDigits(code[1:5]) && isLetters(code[5:]):
+		return PostalCode{Code: code, CPA: true}, nil
+	default:
+		return PostalCode{}, ErrPostalCodeFormat
+	}
+}
+
+func isProvinceLetter(b byte) bool {
+	for _, p := range Provinces {
+		if p.Letter() == b {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package geo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePostalCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantCode   string
+		wantCPA    bool
+		wantLetter byte
+		wantErr    error
+	}{
+		{"CPA", "C1425ABC", "C1425ABC", true, 'C', nil},
+		{"CPA en minúsculas", "c1425abc", "C1425ABC", true, 'C', nil},
+		{"CPA con espacios", " X 5000 ABC ", "X5000ABC", true, 'X', nil},
+		{"CP de 4 dígitos", "1425", "1425", false, 0, nil},
+		{"CP de 4 dígitos con espacios", " 5000 ", "5000", false, 0, nil},
+		{"vacío", "  ", "", false, 0, ErrPostalCodeEmpty},
+		{"4 dígitos empezando con 0", "0999", "", false, 0, ErrPostalCodeFormat},
+		{"3 dígitos", "142", "", false, 0, ErrPostalCodeFormat},
+		{"5 dígitos", "14250", "", false, 0, ErrPostalCodeFormat},
+		{"letra que no es de provincia", "I1425ABC", "", false, 0, ErrPostalCodeFormat},
+		{"CPA con dígitos en la manzana", "C1425AB1", "", false, 0, ErrPostalCodeFormat},
+		{"CPA corto", "C1425AB", "", false, 0, ErrPostalCodeFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePostalCode(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParsePostalCode(%q): esperaba %v, obtuve %v", tt.input, tt.wantErr, err)
+			}
+			if got.Code != tt.wantCode || got.CPA != tt.wantCPA || got.ProvinceLetter() != tt.wantLetter {
+				t.Fatalf("ParsePostalCode(%q) = %+v (letra %q)", tt.input, got, got.ProvinceLetter())
+			}
+		})
+	}
+}
+
+func TestProvinceLetter(t *testing.T) {
+	tests := []struct {
+		code   string
+		letter byte
+	}{
+		{"AR-C", 'C'},
+		{"AR-B", 'B'},
+		{"AR-X", 'X'},
+		{"AR-S", 'S'},
+		{"AR-V", 'V'},
+		{"AR-T", 'T'},
+	}
+	for _, tt := range tests {
+		province, ok := FindProvince(tt.code)
+		if !ok {
+			t.Fatalf("FindProvince(%q) no encontró la provincia", tt.code)
+		}
+		if province.Letter() != tt.letter {
+			t.Errorf("%s: letra %q, esperaba %q", tt.code, province.Letter(), tt.letter)
+		}
+	}
+
+	// Cada letra del CPA corresponde a una sola provincia
+	seen := make(map[byte]string)
+	for _, p := range Provinces {
+		if other, ok := seen[p.Letter()]; ok {
+			t.Fatalf("%s y %s comparten la letra %q", p.Code, other, p.Letter())
+		}
+		seen[p.Letter()] = p.Code
+	}
+	if len(seen) != 24 {
+		t.Fatalf("esperaba 24 provincias, hay %d", len(seen))
+	}
+	if _, ok := FindProvince("ar-c"); ok {
+		t.Fatal("FindProvince espera el código normalizado en mayúsculas")
+	}
+}
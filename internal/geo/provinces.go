@@ -0,0 +1,53 @@
+// Package geo valida y normaliza datos geográficos de Argentina: provincias y códigos postales.
+package geo
+
+import "slices"
+
+// Province es una provincia argentina. Code es el código ISO 3166-2:AR; su letra coincide
+// con la primera letra del CPA de la provincia.
+type Province struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// Letter devuelve la letra de la provincia en el CPA.
+func (p Province) Letter() byte {
+	return p.Code[len(p.Code)-1]
+}
+
+// Provinces es el catálogo de provincias (ISO 3166-2:AR).
+var Provinces = []Province{
+	{"AR-C", "Ciudad Autónoma de Buenos Aires"},
+	{"AR-B", "Buenos Aires"},
+	{"AR-K", "Catamarca"},
+	{"AR-H", "Chaco"},
+	{"AR-U", "Chubut"},
+	{"AR-X", "Córdoba"},
+	{"AR-W", "Corrientes"},
+	{"AR-E", "Entre Ríos"},
+	{"AR-P", "Formosa"},
+	{"AR-Y", "Jujuy"},
+	{"AR-L", "La Pampa"},
+	{"AR-F", "La Rioja"},
+	{"AR-M", "Mendoza"},
+	{"AR-N", "Misiones"},
+	{"AR-Q", "Neuquén"},
+	{"AR-R", "Río Negro"},
+	{"AR-A", "Salta"},
+	{"AR-J", "San Juan"},
+	{"AR-D", "San Luis"},
+	{"AR-Z", "Santa Cruz"},
+	{"AR-S", "Santa Fe"},
+	{"AR-G", "Santiago del Estero"},
+	{"AR-V", "Tierra del Fuego"},
+	{"AR-T", "Tucumán"},
+}
+
+// FindProvince busca una provincia por su código ISO 3166-2:AR.
+func FindProvince(code string) (Province, bool) {
+	i := slices.IndexFunc(Provinces, func(p Province) bool { return p.Code == code })
+	if i < 0 {
+		return Province{}, false
+	}
+	return Provinces[i], true
+}
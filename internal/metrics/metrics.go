@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "profilego"
+
+var (
+	// HTTP
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Cantidad de requests HTTP por método, ruta y status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duración de los requests HTTP por método, ruta y status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// RabbitMQ
+	MQPublishTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "amqp",
+		Name:      "publish_total",
+		Help:      "Mensajes publicados por cola y resultado (success/failure).",
+	}, []string{"queue", "result"})
+
+	MQPublishDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "amqp",
+		Name:      "publish_duration_seconds",
+		Help:      "Duración de PublishMessage por cola.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"queue"})
+
+	MQConsumedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "amqp",
+		Name:      "consumed_total",
+		Help:      "Mensajes consumidos por cola y resultado (processed/requeued/rejected/failed/invalid).",
+	}, []string{"queue", "result"})
+
+	MQConsumeLag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "amqp",
+		Name:      "consume_lag_seconds",
+		Help:      "Tiempo entre la publicación de un mensaje y el inicio de su procesamiento.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 15, 60, 300},
+	}, []string{"queue"})
+
+	// Servicio de autenticación
+	AuthRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "request_duration_seconds",
+		Help:      "Duración de AuthClient.GetCurrentUser por status HTTP (o \"error\" si falló la conexión).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"status"})
+
+	AuthCacheTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "cache_total",
+		Help:      "Consultas al cache de tokens de AuthClient por resultado (hit/negative_hit/stale_hit/miss).",
+	}, []string{"result"})
+
+	AuthRetriesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "retries_total",
+		Help:      "Reintentos de llamadas al servicio de autenticación.",
+	})
+
+	AuthCircuitState = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "circuit_state",
+		Help:      "Estado del circuit breaker del servicio de autenticación (0 cerrado, 1 abierto, 2 semiabierto).",
+	})
+
+	AuthVerificationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "verifications_total",
+		Help:      "Verificaciones de tokens por origen (local/remote) y resultado (success/failure).",
+	}, []string{"source", "result"})
+
+	// Negocio
+	PointsAwardedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "points_awarded_total",
+		Help:      "Puntos otorgados a perfiles.",
+	})
+
+	LevelUpsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "level_ups_total",
+		Help:      "Subidas de nivel aplicadas por UpdateProfileLevel.",
+	})
+)
+
+// RegisterDB expone las estadísticas del pool (sql.DBStats) como métricas.
+func RegisterDB(db *sql.DB, name string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Since devuelve los segundos transcurridos desde start, para observar histogramas.
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
@@ -1,40 +1,107 @@
 package middleware
 
 import (
-	"net/http"
+	"errors"
+	"profilego/internal/auth"
 	"profilego/internal/client"
+	"profilego/internal/domain"
+	"profilego/pkg/logger"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(authClient *client.AuthClient) gin.HandlerFunc {
+// AuthUserKey es la clave del contexto de Gin con el *domain.AuthUser autenticado.
+const AuthUserKey = "authUser"
+
+// authRetryAfter es el Retry-After (en segundos) sugerido cuando no se puede verificar el token.
+const authRetryAfter = "5"
+
+var (
+	errTokenMissing   = domain.Unauthenticated("token_missing", "no se proporcionó un token")
+	errTokenMalformed = domain.Unauthenticated("token_invalid", "formato de token inválido")
+	errTokenInvalid   = domain.Unauthenticated("token_invalid", "token inválido o usuario no autenticado")
+	errNoUser         = domain.Unauthenticated("unauthenticated", "usuario no autenticado")
+	errUserDisabled   = domain.Forbidden("user_disabled", "usuario deshabilitado")
+	errAuthDown       = domain.Upstream("auth_unavailable", "servicio de autenticación no disponible, intente nuevamente", nil)
+)
+
+// AuthMiddleware exige un Bearer token y resuelve el usuario con resolver
+// (verificación local del JWT y/o consulta al servicio de autenticación).
+func AuthMiddleware(resolver auth.UserResolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "No se proporcionó un token"})
-			c.Abort()
+			Fail(c, errTokenMissing)
 			return
 		}
 
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 		if token == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Formato de token inválido"})
-			c.Abort()
+			Fail(c, errTokenMalformed)
 			return
 		}
 
-		// Llamar al microservicio de autenticación para obtener el userId
-		user, err := authClient.GetCurrentUser(c.Request.Context(), token)
+		user, err := resolver.GetCurrentUser(c.Request.Context(), token)
+		if isAuthUnavailable(err) {
+			// No se pudo verificar el token: no es culpa del cliente, que puede reintentar
+			c.Header("Retry-After", authRetryAfter)
+			Fail(c, errAuthDown.Wrap(err))
+			return
+		}
 		if err != nil || user.ID == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido o usuario no autenticado"})
-			c.Abort()
+			Fail(c, errTokenInvalid)
+			return
+		}
+		if !user.Enabled {
+			logger.FromContext(c.Request.Context()).Warn("usuario deshabilitado", "user_id", user.ID)
+			Fail(c, errUserDisabled)
 			return
 		}
 
-		// Guardar el userId en el contexto de Gin para que los handlers lo usen
+		// Guardar el usuario en el contexto de Gin para que los handlers lo usen
+		c.Set(AuthUserKey, user)
 		c.Set("userId", user.ID)
+		ctx := domain.WithActor(c.Request.Context(), user.ID)
+		c.Request = c.Request.WithContext(logger.With(ctx, "user_id", user.ID))
+
+		c.Next()
+	}
+}
+
+// isAuthUnavailable distingue las fallas de infraestructura (servicio de autenticación caído,
+// claves de firma no disponibles) de los tokens rechazados.
+func isAuthUnavailable(err error) bool {
+	return errors.Is(err, client.ErrAuthUnavailable) || errors.Is(err, auth.ErrKeysUnavailable)
+}
+
+// CurrentUser devuelve el usuario autenticado por AuthMiddleware.
+func CurrentUser(c *gin.Context) (*domain.AuthUser, bool) {
+	v, ok := c.Get(AuthUserKey)
+	if !ok {
+		return nil, false
+	}
+	user, ok := v.(*domain.AuthUser)
+	return user, ok && user != nil
+}
 
+// RequirePermission exige que el usuario autenticado tenga todos los permisos indicados.
+// Debe registrarse después de AuthMiddleware.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok {
+			Fail(c, errNoUser)
+			return
+		}
+
+		for _, permission := range permissions {
+			if !user.HasPermission(permission) {
+				logger.FromContext(c.Request.Context()).Warn("permiso denegado", "permission", permission)
+				Fail(c, domain.Forbidden("permission_denied", "falta el permiso "+permission))
+				return
+			}
+		}
 		c.Next()
 	}
 }
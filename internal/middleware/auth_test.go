@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"profilego/internal/client"
+	"profilego/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeResolver resuelve los tokens de users; cualquier otro token es rechazado con err
+// (o con client.ErrUnauthorized si err es nil).
+type fakeResolver struct {
+	users map[string]*domain.AuthUser
+	err   error
+}
+
+func (r fakeResolver) GetCurrentUser(ctx context.Context, token string) (*domain.AuthUser, error) {
+	if user, ok := r.users[token]; ok {
+		return user, nil
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return nil, client.ErrUnauthorized
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := map[string]*domain.AuthUser{
+		"ok":       {ID: "u1", Enabled: true},
+		"disabled": {ID: "u2", Enabled: false},
+		"sin-id":   {Enabled: true},
+	}
+
+	tests := []struct {
+		name           string
+		authorization  string
+		resolverErr    error
+		wantStatus     int
+		wantCode       string
+		wantRetryAfter bool
+	}{
+		{name: "usuario válido", authorization: "Bearer ok", wantStatus: http.StatusNoContent},
+		{name: "sin token", wantStatus: http.StatusUnauthorized, wantCode: "token_missing"},
+		{name: "sin Bearer", authorization: "Basic ok", wantStatus: http.StatusUnauthorized, wantCode: "token_invalid"},
+		{name: "token rechazado", authorization: "Bearer otro", wantStatus: http.StatusUnauthorized, wantCode: "token_invalid"},
+		{name: "usuario sin id", authorization: "Bearer sin-id", wantStatus: http.StatusUnauthorized, wantCode: "token_invalid"},
+		{name: "usuario deshabilitado", authorization: "Bearer disabled", wantStatus: http.StatusForbidden, wantCode: "user_disabled"},
+		{
+			name: "servicio de autenticación caído", authorization: "Bearer otro", resolverErr: client.ErrAuthUnavailable,
+			wantStatus: http.StatusServiceUnavailable, wantCode: "auth_unavailable", wantRetryAfter: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(Problems(), AuthMiddleware(fakeResolver{users: users, err: tt.resolverErr}))
+			router.GET("/recurso", func(c *gin.Context) {
+				if user, ok := CurrentUser(c); !ok || user.ID != "u1" {
+					t.Errorf("el handler debe recibir el usuario autenticado: %+v", user)
+				}
+				c.Status(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/recurso", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				assertCode(t, rec, tt.wantCode)
+			}
+			if got := rec.Header().Get("Retry-After") != ""; got != tt.wantRetryAfter {
+				t.Fatalf("Retry-After %q", rec.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		user       *domain.AuthUser
+		wantStatus int
+		wantCode   string
+	}{
+		{"todos los permisos", &domain.AuthUser{ID: "u1", Enabled: true, Permissions: []string{domain.PermPointsGrant, domain.PermLevelSet}}, http.StatusNoContent, ""},
+		{"falta un permiso", &domain.AuthUser{ID: "u1", Enabled: true, Permissions: []string{domain.PermPointsGrant}}, http.StatusForbidden, "permission_denied"},
+		{"sin permisos", &domain.AuthUser{ID: "u1", Enabled: true}, http.StatusForbidden, "permission_denied"},
+		{"sin usuario autenticado", nil, http.StatusUnauthorized, "unauthenticated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(Problems(), func(c *gin.Context) {
+				if tt.user != nil {
+					c.Set(AuthUserKey, tt.user)
+				}
+			})
+			router.POST("/recurso", RequirePermission(domain.PermPointsGrant, domain.PermLevelSet), func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/recurso", nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				assertCode(t, rec, tt.wantCode)
+			}
+		})
+	}
+}
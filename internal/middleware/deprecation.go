@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marca las respuestas como obsoletas (header Deprecation) e indica en Link
+// la ruta de la versión que las reemplaza.
+func Deprecated(successor string) gin.HandlerFunc {
+	link := fmt.Sprintf("<%s>; rel=\"successor-version\"", successor)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", link)
+		c.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"profilego/internal/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics registra la cantidad y duración de los requests por método, ruta y status.
+// Se usa la ruta registrada (c.FullPath) para no generar una serie por cada userId.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "sin_ruta"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		metrics.HTTPRequestsTotal.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(metrics.Since(start))
+	}
+}
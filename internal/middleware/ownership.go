@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"profilego/internal/domain"
+	"profilego/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// UserIDParam es el parámetro de ruta con el userId dueño del recurso.
+	UserIDParam = "userId"
+	// MeAlias puede usarse en lugar del userId propio (p. ej. /api/profiles/me).
+	MeAlias = "me"
+)
+
+var errResourceForbidden = domain.Forbidden("resource_forbidden", "no tienes permisos para acceder a este recurso")
+
+// OwnerResolver devuelve el userId dueño del recurso al que apunta el request.
+// Devuelve "" si el recurso no existe.
+type OwnerResolver func(c *gin.Context) (string, error)
+
+// RequireOwner exige que el :userId de la ruta sea el del usuario autenticado, salvo que éste
+// tenga profile:read:any (GET/HEAD) o profile:write:any (resto de los métodos).
+// El valor "me" se reemplaza por el userId del token antes de llegar al handler.
+func RequireOwner() gin.HandlerFunc {
+	return RequireOwnerFunc(func(c *gin.Context) (string, error) {
+		return c.Param(UserIDParam), nil
+	})
+}
+
+// RequireOwnerFunc es como RequireOwner pero obtiene el dueño con resolve, para rutas
+// cuyo parámetro no es un userId (p. ej. un profileId).
+func RequireOwnerFunc(resolve OwnerResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok {
+			Fail(c, errNoUser)
+			return
+		}
+		resolveMe(c, user.ID)
+
+		owner, err := resolve(c)
+		if err != nil {
+			Fail(c, fmt.Errorf("no se pudo determinar el dueño del recurso: %w", err))
+			return
+		}
+		if owner == user.ID {
+			c.Next()
+			return
+		}
+
+		permission := domain.PermProfileWriteAny
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			permission = domain.PermProfileReadAny
+		}
+		if !user.HasPermission(permission) {
+			logger.FromContext(c.Request.Context()).Warn("acceso denegado a recurso de otro usuario", "owner_id", owner)
+			Fail(c, errResourceForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+// resolveMe reemplaza el alias "me" del parámetro :userId por el userId autenticado.
+func resolveMe(c *gin.Context, userID string) {
+	for i, p := range c.Params {
+		if p.Key == UserIDParam && p.Value == MeAlias {
+			c.Params[i].Value = userID
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"profilego/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ownerRouter registra /recursos/:userId con el middleware owner detrás de un usuario
+// autenticado fijo (ninguno si user es nil). El handler devuelve en X-User-Id el :userId que recibió.
+func ownerRouter(user *domain.AuthUser, owner gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Problems(), func(c *gin.Context) {
+		if user != nil {
+			c.Set(AuthUserKey, user)
+		}
+	})
+	router.Handle(http.MethodGet, "/recursos/:userId", owner, echoUserID)
+	router.Handle(http.MethodHead, "/recursos/:userId", owner, echoUserID)
+	router.Handle(http.MethodPut, "/recursos/:userId", owner, echoUserID)
+	router.Handle(http.MethodDelete, "/recursos/:userId", owner, echoUserID)
+	return router
+}
+
+func echoUserID(c *gin.Context) {
+	c.Header("X-User-Id", c.Param(UserIDParam))
+	c.Status(http.StatusNoContent)
+}
+
+func TestRequireOwner(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		userID      string
+		permissions []string
+		noUser      bool
+		wantStatus  int
+		wantCode    string
+		wantParam   string
+	}{
+		{name: "el propio userId", method: http.MethodGet, userID: "u1", wantStatus: http.StatusNoContent, wantParam: "u1"},
+		{name: "me se reemplaza antes del handler", method: http.MethodGet, userID: "me", wantStatus: http.StatusNoContent, wantParam: "u1"},
+		{name: "me en una escritura", method: http.MethodPut, userID: "me", wantStatus: http.StatusNoContent, wantParam: "u1"},
+		{name: "leer otro usuario", method: http.MethodGet, userID: "u2", wantStatus: http.StatusForbidden, wantCode: "resource_forbidden"},
+		{name: "modificar otro usuario", method: http.MethodPut, userID: "u2", wantStatus: http.StatusForbidden, wantCode: "resource_forbidden"},
+		{
+			name: "read:any permite GET", method: http.MethodGet, userID: "u2",
+			permissions: []string{domain.PermProfileReadAny}, wantStatus: http.StatusNoContent, wantParam: "u2",
+		},
+		{
+			name: "read:any permite HEAD", method: http.MethodHead, userID: "u2",
+			permissions: []string{domain.PermProfileReadAny}, wantStatus: http.StatusNoContent, wantParam: "u2",
+		},
+		{
+			name: "read:any no permite escribir", method: http.MethodPut, userID: "u2",
+			permissions: []string{domain.PermProfileReadAny}, wantStatus: http.StatusForbidden, wantCode: "resource_forbidden",
+		},
+		{
+			name: "read:any no permite borrar", method: http.MethodDelete, userID: "u2",
+			permissions: []string{domain.PermProfileReadAny}, wantStatus: http.StatusForbidden, wantCode: "resource_forbidden",
+		},
+		{
+			name: "write:any permite borrar", method: http.MethodDelete, userID: "u2",
+			permissions: []string{domain.PermProfileWriteAny}, wantStatus: http.StatusNoContent, wantParam: "u2",
+		},
+		{
+			name: "write:any no incluye la lectura", method: http.MethodGet, userID: "u2",
+			permissions: []string{domain.PermProfileWriteAny}, wantStatus: http.StatusForbidden, wantCode: "resource_forbidden",
+		},
+		{name: "sin usuario autenticado", method: http.MethodGet, userID: "me", noUser: true, wantStatus: http.StatusUnauthorized, wantCode: "unauthenticated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &domain.AuthUser{ID: "u1", Enabled: true, Permissions: tt.permissions}
+			if tt.noUser {
+				user = nil
+			}
+			rec := httptest.NewRecorder()
+			ownerRouter(user, RequireOwner()).ServeHTTP(rec, httptest.NewRequest(tt.method, "/recursos/"+tt.userID, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := rec.Header().Get("X-User-Id"); got != tt.wantParam {
+				t.Fatalf("el handler recibió userId %q, esperaba %q", got, tt.wantParam)
+			}
+			if tt.wantCode != "" {
+				assertCode(t, rec, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRequireOwnerFunc(t *testing.T) {
+	// El parámetro es un profileId: el dueño sale de este índice
+	owners := map[string]string{"p1": "u1", "p2": "u2"}
+	resolve := func(c *gin.Context) (string, error) {
+		if c.Param(UserIDParam) == "falla" {
+			return "", errors.New("base de datos caída")
+		}
+		return owners[c.Param(UserIDParam)], nil
+	}
+
+	tests := []struct {
+		name        string
+		profileID   string
+		permissions []string
+		wantStatus  int
+		wantCode    string
+	}{
+		{name: "perfil propio", profileID: "p1", wantStatus: http.StatusNoContent},
+		{name: "perfil de otro usuario", profileID: "p2", wantStatus: http.StatusForbidden, wantCode: "resource_forbidden"},
+		{name: "perfil de otro usuario con read:any", profileID: "p2", permissions: []string{domain.PermProfileReadAny}, wantStatus: http.StatusNoContent},
+		{name: "perfil inexistente", profileID: "p9", wantStatus: http.StatusForbidden, wantCode: "resource_forbidden"},
+		{name: "error al resolver el dueño", profileID: "falla", wantStatus: http.StatusInternalServerError, wantCode: "internal_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &domain.AuthUser{ID: "u1", Enabled: true, Permissions: tt.permissions}
+			rec := httptest.NewRecorder()
+			ownerRouter(user, RequireOwnerFunc(resolve)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/recursos/"+tt.profileID, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				assertCode(t, rec, tt.wantCode)
+			}
+		})
+	}
+}
+
+// assertCode verifica el code del problem+json de la respuesta.
+func assertCode(t *testing.T, rec *httptest.ResponseRecorder, code string) {
+	t.Helper()
+	var body problemBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Code != code {
+		t.Fatalf("code %q, esperaba %q (%v): %s", body.Code, code, err, rec.Body)
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ifMatchKey es la clave del contexto de Gin que marca que el request se condicionó con If-Match.
+const ifMatchKey = "ifMatch"
+
+// MarkIfMatch registra que la operación se condicionó a la versión pedida en If-Match: un
+// version_conflict responde entonces 412 Precondition Failed en lugar de 409.
+func MarkIfMatch(c *gin.Context) {
+	c.Set(ifMatchKey, true)
+}
+
+// RequireIfMatch exige el header If-Match en PUT, PATCH y DELETE para evitar que dos clientes
+// se pisen los cambios. Sin él responde 428 Precondition Required.
+func RequireIfMatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			if c.GetHeader("If-Match") == "" {
+				Fail(c, &Problem{
+					Status: http.StatusPreconditionRequired,
+					Code:   "precondition_required",
+					Detail: "las modificaciones requieren el header If-Match con el ETag leído",
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
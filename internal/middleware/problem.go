@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"profilego/internal/domain"
+	"profilego/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType es el media type de los errores (RFC 7807).
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix arma el campo "type" a partir del código estable del error.
+const problemTypePrefix = "urn:profilego:problem:"
+
+// Problem es un error propio del protocolo HTTP (415, 428, ...) sin equivalente de dominio.
+type Problem struct {
+	Status int
+	Code   string
+	Detail string
+}
+
+func (p *Problem) Error() string { return p.Detail }
+
+// problemBody es el documento application/problem+json. code, requestId y errors son extensiones.
+type problemBody struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code"`
+	RequestID string              `json:"requestId,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+// kindStatus traduce las categorías de error de dominio a códigos HTTP.
+var kindStatus = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{domain.ErrValidation, http.StatusBadRequest, "validation_failed"},
+	{domain.ErrUnauthenticated, http.StatusUnauthorized, "unauthenticated"},
+	{domain.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{domain.ErrNotFound, http.StatusNotFound, "not_found"},
+	{domain.ErrAlreadyExists, http.StatusConflict, "already_exists"},
+	{domain.ErrConflict, http.StatusConflict, "conflict"},
+	{domain.ErrUpstream, http.StatusServiceUnavailable, "upstream_unavailable"},
+}
+
+// preconditionCodes son los códigos que responden 412 cuando el request se condicionó con
+// If-Match (ver MarkIfMatch). Sin If-Match el conflicto no es de una precondición del cliente
+// y se responde como un 409 conflict.
+var preconditionCodes = map[string]bool{
+	"version_conflict": true,
+}
+
+// Fail registra err para que Problems lo responda y corta la cadena de handlers.
+func Fail(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// Problems responde como application/problem+json el último error registrado con Fail
+// (o c.Error) si el handler no escribió una respuesta. Los errores de dominio se traducen
+// por categoría; cualquier otro error es un 500 sin detalles, que se registra en el log.
+// Debe registrarse después de los middlewares que miden o registran el status final.
+func Problems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		writeProblem(c, c.Errors.Last().Err)
+	}
+}
+
+func writeProblem(c *gin.Context, err error) {
+	ctx := c.Request.Context()
+	body := problemBody{
+		Status:    http.StatusInternalServerError,
+		Code:      "internal_error",
+		Instance:  c.Request.URL.Path,
+		RequestID: logger.RequestID(ctx),
+	}
+
+	var problem *Problem
+	var domainErr *domain.Error
+	switch {
+	case errors.As(err, &problem):
+		body.Status, body.Code, body.Detail = problem.Status, problem.Code, problem.Detail
+	case errors.As(err, &domainErr):
+		body.Status, body.Code = http.StatusInternalServerError, domainErr.Code
+		for _, k := range kindStatus {
+			if errors.Is(domainErr.Kind, k.kind) {
+				body.Status = k.status
+				break
+			}
+		}
+		if preconditionCodes[domainErr.Code] {
+			if c.GetBool(ifMatchKey) {
+				body.Status = http.StatusPreconditionFailed
+			} else {
+				body.Status, body.Code = http.StatusConflict, "conflict"
+			}
+		}
+		body.Detail = domainErr.Message
+		body.Errors = domainErr.Fields
+	}
+
+	switch {
+	case body.Status >= http.StatusInternalServerError:
+		logger.FromContext(ctx).Error("error procesando el request", "code", body.Code, "error", err)
+	case body.Status != http.StatusNotFound:
+		logger.FromContext(ctx).Debug("request rechazado", "code", body.Code, "error", err)
+	}
+
+	lang := problemLanguage(c.GetHeader("Accept-Language"))
+	body.Type = problemTypePrefix + body.Code
+	body.Title = problemTitle(body.Code, body.Status, lang)
+
+	c.Header("Content-Type", ProblemContentType)
+	c.Header("Content-Language", lang)
+	c.JSON(body.Status, body)
+}
+
+// problemLanguage elige el idioma de los títulos según Accept-Language (es por defecto).
+func problemLanguage(acceptLanguage string) string {
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		tag = strings.TrimSpace(strings.SplitN(tag, ";", 2)[0])
+		primary := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if _, ok := problemTitles[primary]; ok {
+			return primary
+		}
+	}
+	return "es"
+}
+
+// problemTitle devuelve el título del código en el idioma pedido; si el código no tiene
+// título propio usa el de su categoría según el status.
+func problemTitle(code string, status int, lang string) string {
+	titles := problemTitles[lang]
+	if title, ok := titles[code]; ok {
+		return title
+	}
+	for _, k := range kindStatus {
+		if k.status == status {
+			return titles[k.code]
+		}
+	}
+	return titles["internal_error"]
+}
+
+// problemTitles son los títulos localizados por código. Los códigos son estables: los
+// clientes pueden depender de ellos, los títulos y mensajes pueden cambiar.
+var problemTitles = map[string]map[string]string{
+	"es": {
+		"validation_failed":      "Datos inválidos",
+		"unauthenticated":        "No autenticado",
+		"forbidden":              "Acceso denegado",
+		"not_found":              "Recurso no encontrado",
+		"already_exists":         "El recurso ya existe",
+		"conflict":               "Conflicto con el estado del recurso",
+		"upstream_unavailable":   "Servicio no disponible",
+		"internal_error":         "Error interno",
+		"invalid_body":           "Cuerpo del request inválido",
+		"invalid_if_match":       "If-Match inválido",
+		"invalid_log_level":      "Nivel de log inválido",
+		"profile_not_found":      "Perfil no encontrado",
+		"address_not_found":      "Dirección no encontrada",
+		"profile_exists":         "El perfil ya existe",
+		"profile_not_owned":      "El perfil no pertenece al usuario",
+		"version_conflict":       "El recurso fue modificado",
+		"main_address_conflict":  "La dirección principal fue modificada",
+		"token_missing":          "Falta el token de acceso",
+		"token_invalid":          "Token inválido",
+		"user_disabled":          "Usuario deshabilitado",
+		"permission_denied":      "Permiso insuficiente",
+		"resource_forbidden":     "Sin acceso al recurso",
+		"auth_unavailable":       "Autenticación no disponible",
+		"publisher_unavailable":  "Mensajería no disponible",
+		"unsupported_media_type": "Tipo de contenido no soportado",
+		"precondition_required":  "Falta el header If-Match",
+	},
+	"en": {
+		"validation_failed":      "Invalid data",
+		"unauthenticated":        "Unauthenticated",
+		"forbidden":              "Forbidden",
+		"not_found":              "Resource not found",
+		"already_exists":         "Resource already exists",
+		"conflict":               "Conflict with the resource state",
+		"upstream_unavailable":   "Service unavailable",
+		"internal_error":         "Internal error",
+		"invalid_body":           "Invalid request body",
+		"invalid_if_match":       "Invalid If-Match",
+		"invalid_log_level":      "Invalid log level",
+		"profile_not_found":      "Profile not found",
+		"address_not_found":      "Address not found",
+		"profile_exists":         "Profile already exists",
+		"profile_not_owned":      "Profile belongs to another user",
+		"version_conflict":       "Resource was modified",
+		"main_address_conflict":  "Main address was modified",
+		"token_missing":          "Missing access token",
+		"token_invalid":          "Invalid token",
+		"user_disabled":          "User disabled",
+		"permission_denied":      "Insufficient permission",
+		"resource_forbidden":     "No access to the resource",
+		"auth_unavailable":       "Authentication unavailable",
+		"publisher_unavailable":  "Messaging unavailable",
+		"unsupported_media_type": "Unsupported media type",
+		"precondition_required":  "If-Match header required",
+	},
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"profilego/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errVersion = domain.Conflict("version_conflict", "el registro fue modificado por otra operación")
+
+func TestProblems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		err            error
+		ifMatch        bool
+		acceptLanguage string
+		wantStatus     int
+		wantCode       string
+		wantTitle      string
+		wantLanguage   string
+		wantDetail     string
+		wantFields     int
+	}{
+		{
+			name:       "validación",
+			err:        domain.Validation("validation_failed", "los datos enviados no son válidos", domain.FieldError{Field: "phone", Message: "es obligatorio"}),
+			wantStatus: http.StatusBadRequest, wantCode: "validation_failed", wantTitle: "Datos inválidos", wantLanguage: "es",
+			wantDetail: "los datos enviados no son válidos", wantFields: 1,
+		},
+		{
+			name:       "no autenticado",
+			err:        domain.Unauthenticated("token_invalid", "token inválido"),
+			wantStatus: http.StatusUnauthorized, wantCode: "token_invalid", wantTitle: "Token inválido", wantLanguage: "es",
+			wantDetail: "token inválido",
+		},
+		{
+			name:       "prohibido",
+			err:        domain.Forbidden("resource_forbidden", "sin acceso"),
+			wantStatus: http.StatusForbidden, wantCode: "resource_forbidden", wantTitle: "Sin acceso al recurso", wantLanguage: "es",
+			wantDetail: "sin acceso",
+		},
+		{
+			name:       "no encontrado envuelto",
+			err:        fmt.Errorf("buscando el perfil: %w", domain.NotFound("profile_not_found", "usuario no encontrado")),
+			wantStatus: http.StatusNotFound, wantCode: "profile_not_found", wantTitle: "Perfil no encontrado", wantLanguage: "es",
+			wantDetail: "usuario no encontrado",
+		},
+		{
+			name:       "ya existe",
+			err:        domain.AlreadyExists("profile_exists", "ya tiene perfil"),
+			wantStatus: http.StatusConflict, wantCode: "profile_exists", wantTitle: "El perfil ya existe", wantLanguage: "es",
+			wantDetail: "ya tiene perfil",
+		},
+		{
+			name:       "dependencia caída",
+			err:        domain.Upstream("auth_unavailable", "servicio de autenticación no disponible", errors.New("dial tcp: refused")),
+			wantStatus: http.StatusServiceUnavailable, wantCode: "auth_unavailable", wantTitle: "Autenticación no disponible", wantLanguage: "es",
+			wantDetail: "servicio de autenticación no disponible",
+		},
+		{
+			name:       "código sin título propio usa el de su categoría",
+			err:        domain.NotFound("locality_not_found", "localidad inexistente"),
+			wantStatus: http.StatusNotFound, wantCode: "locality_not_found", wantTitle: "Recurso no encontrado", wantLanguage: "es",
+			wantDetail: "localidad inexistente",
+		},
+		{
+			name:       "version_conflict con If-Match es 412",
+			err:        errVersion,
+			ifMatch:    true,
+			wantStatus: http.StatusPreconditionFailed, wantCode: "version_conflict", wantTitle: "El recurso fue modificado", wantLanguage: "es",
+			wantDetail: "el registro fue modificado por otra operación",
+		},
+		{
+			name:       "version_conflict sin If-Match es 409 conflict",
+			err:        fmt.Errorf("guardando: %w", errVersion),
+			wantStatus: http.StatusConflict, wantCode: "conflict", wantTitle: "Conflicto con el estado del recurso", wantLanguage: "es",
+			wantDetail: "el registro fue modificado por otra operación",
+		},
+		{
+			name:       "otro conflicto no depende de If-Match",
+			err:        domain.Conflict("main_address_conflict", "otra operación cambió la dirección principal"),
+			ifMatch:    true,
+			wantStatus: http.StatusConflict, wantCode: "main_address_conflict", wantTitle: "La dirección principal fue modificada", wantLanguage: "es",
+			wantDetail: "otra operación cambió la dirección principal",
+		},
+		{
+			name:       "error de protocolo",
+			err:        &Problem{Status: http.StatusUnsupportedMediaType, Code: "unsupported_media_type", Detail: "Content-Type debe ser application/merge-patch+json"},
+			wantStatus: http.StatusUnsupportedMediaType, wantCode: "unsupported_media_type", wantTitle: "Tipo de contenido no soportado", wantLanguage: "es",
+			wantDetail: "Content-Type debe ser application/merge-patch+json",
+		},
+		{
+			name:       "error que no es de dominio es un 500 opaco",
+			err:        errors.New("pq: connection refused a 10.0.0.5"),
+			wantStatus: http.StatusInternalServerError, wantCode: "internal_error", wantTitle: "Error interno", wantLanguage: "es",
+		},
+		{
+			name:           "título en inglés",
+			err:            domain.NotFound("profile_not_found", "usuario no encontrado"),
+			acceptLanguage: "en-US,en;q=0.9",
+			wantStatus:     http.StatusNotFound, wantCode: "profile_not_found", wantTitle: "Profile not found", wantLanguage: "en",
+			wantDetail: "usuario no encontrado",
+		},
+		{
+			name:           "primer idioma soportado de la lista",
+			err:            errors.New("falla"),
+			acceptLanguage: "fr-FR, en;q=0.8, es;q=0.5",
+			wantStatus:     http.StatusInternalServerError, wantCode: "internal_error", wantTitle: "Internal error", wantLanguage: "en",
+		},
+		{
+			name:           "idioma no soportado usa español",
+			err:            errVersion,
+			acceptLanguage: "de",
+			wantStatus:     http.StatusConflict, wantCode: "conflict", wantTitle: "Conflicto con el estado del recurso", wantLanguage: "es",
+			wantDetail: "el registro fue modificado por otra operación",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(Problems())
+			router.GET("/recurso", func(c *gin.Context) {
+				if tt.ifMatch {
+					MarkIfMatch(c)
+				}
+				Fail(c, tt.err)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/recurso", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != ProblemContentType {
+				t.Fatalf("Content-Type %q", ct)
+			}
+			if lang := rec.Header().Get("Content-Language"); lang != tt.wantLanguage {
+				t.Fatalf("Content-Language %q, esperaba %q", lang, tt.wantLanguage)
+			}
+			var body problemBody
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("respuesta inválida: %v", err)
+			}
+			want := problemBody{
+				Type:     problemTypePrefix + tt.wantCode,
+				Title:    tt.wantTitle,
+				Status:   tt.wantStatus,
+				Detail:   tt.wantDetail,
+				Instance: "/recurso",
+				Code:     tt.wantCode,
+			}
+			if len(body.Errors) != tt.wantFields {
+				t.Fatalf("%d errores de campo, esperaba %d", len(body.Errors), tt.wantFields)
+			}
+			body.Errors = nil
+			if !reflect.DeepEqual(body, want) {
+				t.Fatalf("problema\n%+v\nesperaba\n%+v", body, want)
+			}
+		})
+	}
+}
+
+func TestProblemsKeepsWrittenResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Problems())
+	router.GET("/recurso", func(c *gin.Context) {
+		c.JSON(http.StatusAccepted, gin.H{"ok": true})
+		_ = c.Error(errors.New("registrado después de responder"))
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/recurso", nil))
+	if rec.Code != http.StatusAccepted || rec.Header().Get("Content-Type") == ProblemContentType {
+		t.Fatalf("no debe reemplazarse una respuesta ya escrita: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestProblemLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":                        "es",
+		"en":                      "en",
+		"EN-gb":                   "en",
+		"es-AR,es;q=0.9,en;q=0.8": "es",
+		"pt-BR, en;q=0.5":         "en",
+		"*":                       "es",
+	}
+	for header, want := range tests {
+		if got := problemLanguage(header); got != want {
+			t.Errorf("problemLanguage(%q) = %q, esperaba %q", header, got, want)
+		}
+	}
+}
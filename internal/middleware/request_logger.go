@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"log/slog"
+	"profilego/pkg/logger"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader es el header usado para propagar el identificador de correlación.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestLogger inyecta en el contexto del request un logger con request_id, método y ruta,
+// y registra cada request al terminar con su status y latencia.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "sin_ruta"
+		}
+
+		ctx := logger.WithRequestID(c.Request.Context(), requestID)
+		ctx = logger.WithContext(ctx, slog.Default().With(
+			"request_id", requestID,
+			"method", c.Request.Method,
+			"route", route,
+		))
+		c.Request = c.Request.WithContext(ctx)
+
+		start := time.Now()
+		c.Next()
+
+		// El logger puede haber sido enriquecido (p. ej. con user_id) por otros middlewares
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		logger.FromContext(c.Request.Context()).Log(c.Request.Context(), level, "request",
+			"status", status,
+			"path", c.Request.URL.Path,
+			"latency_ms", float64(time.Since(start).Microseconds())/1000,
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
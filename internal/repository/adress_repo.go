@@ -3,10 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	//"errors"
 
 	"profilego/internal/domain"
+	"profilego/internal/geo"
+	"profilego/pkg/tracing"
 
 	"github.com/google/uuid"
 )
@@ -21,42 +25,126 @@ func NewAddressRepository(db *sql.DB) *AddressRepository {
 	return &AddressRepository{DB: db}
 }
 
-func (r *AddressRepository) CreateAddress(ctx context.Context, address *domain.Address) error {
-	query := `INSERT INTO address (addressId, CP, street, number, floor, mainAddress, creationDate, updatedDate, activeAddress, idProfile)
-	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
-
-	_, err := r.DB.ExecContext(ctx, query,
-		address.AddressID,
-		address.CP,
-		address.Street,
-		address.Number,
-		address.Floor,
-		address.MainAddress,
-		address.CreationDate,
-		address.UpdatedDate,
-		address.ActiveAddress,
-		address.IdProfile,
-	)
+// CreateAddress inserta una dirección. Si es la principal, en la misma transacción deja de
+// serlo la que lo era.
+func (r *AddressRepository) CreateAddress(ctx context.Context, address *domain.Address) (err error) {
+	query := `INSERT INTO address (addressId, CP, street, number, floor, apartment, locality, province, country,
+	          betweenStreets, addressReferences, mainAddress, latitude, longitude, geocodeQuality,
+	          creationDate, updatedDate, activeAddress, idProfile)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+	          RETURNING version`
+	ctx, span := startSpan(ctx, "AddressRepository.CreateAddress", query)
+	defer tracing.End(span, &err)
+
+	err = inAddressTx(ctx, r.DB, func(tx *sql.Tx) error {
+		if address.MainAddress && address.ActiveAddress {
+			if err := clearMainAddress(ctx, tx, address.IdProfile, address.AddressID); err != nil {
+				return err
+			}
+		}
+		return tx.QueryRowContext(ctx, query,
+			address.AddressID,
+			address.CP,
+			address.Street,
+			address.Number,
+			address.Floor,
+			address.Apartment,
+			address.Locality,
+			address.Province,
+			address.Country,
+			address.BetweenStreets,
+			address.References,
+			address.MainAddress,
+			address.Latitude,
+			address.Longitude,
+			geocodeQuality(address.GeocodeQuality),
+			address.CreationDate,
+			address.UpdatedDate,
+			address.ActiveAddress,
+			address.IdProfile,
+		).Scan(&address.Version)
+	})
+	return mainAddressConflict(err)
+}
+
+// addressColumns son las columnas de address en el orden de addressFields.
+const addressColumns = `addressId, CP, street, number, floor, apartment, locality, province, country,
+	          betweenStreets, addressReferences, mainAddress, latitude, longitude, geocodeQuality,
+	          creationDate, updatedDate, activeAddress, idProfile, version`
+
+// addressFields devuelve los destinos de Scan para una fila con addressColumns.
+func addressFields(a *domain.Address) []interface{} {
+	return []interface{}{
+		&a.AddressID, &a.CP, &a.Street, &a.Number, &a.Floor, &a.Apartment, &a.Locality, &a.Province, &a.Country,
+		&a.BetweenStreets, &a.References, &a.MainAddress, &a.Latitude, &a.Longitude, &a.GeocodeQuality,
+		&a.CreationDate, &a.UpdatedDate, &a.ActiveAddress, &a.IdProfile, &a.Version,
+	}
+}
+
+// geocodeQuality devuelve la calidad a guardar; vacía equivale a pendiente.
+func geocodeQuality(q domain.GeocodeQuality) domain.GeocodeQuality {
+	if q == "" {
+		return domain.GeocodePending
+	}
+	return q
+}
+
+// inAddressTx es inTx para las escrituras de direcciones: informa al trigger del historial
+// (address_history_record) qué usuario hace el cambio, si el contexto lo indica.
+func inAddressTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		if actor := domain.ActorFromContext(ctx); actor != "" {
+			if _, err := tx.ExecContext(ctx, `SELECT set_config('profilego.actor', $1, true)`, actor); err != nil {
+				return err
+			}
+		}
+		return fn(tx)
+	})
+}
+
+// clearMainAddress desmarca la dirección principal del perfil, salvo except.
+func clearMainAddress(ctx context.Context, tx *sql.Tx, idProfile, except uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `UPDATE address SET mainAddress = FALSE, version = version + 1
+			WHERE idProfile = $1 AND mainAddress AND addressId <> $2`, idProfile, except)
+	return err
+}
+
+// keepMainAddress es el valor de mainAddress de una dirección que deja de ser la principal: lo
+// sigue siendo si no hay otra dirección activa en el perfil que la reemplace.
+const keepMainAddress = `mainAddress AND activeAddress AND NOT EXISTS (
+			SELECT 1 FROM address s WHERE s.idProfile = address.idProfile AND s.activeAddress
+			AND s.addressId <> address.addressId)`
+
+// promoteMainAddress marca como principal la dirección activa más antigua del perfil (salvo
+// except) si el perfil quedó sin dirección principal.
+func promoteMainAddress(ctx context.Context, tx *sql.Tx, idProfile, except uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `UPDATE address SET mainAddress = TRUE, version = version + 1
+			WHERE addressId = (
+				SELECT addressId FROM address WHERE idProfile = $1 AND activeAddress AND addressId <> $2
+				ORDER BY creationDate, addressId LIMIT 1
+			) AND NOT EXISTS (SELECT 1 FROM address WHERE idProfile = $1 AND mainAddress)`, idProfile, except)
+	return err
+}
+
+// mainAddressConflict traduce la violación de address_main_idx (dos transacciones que marcan
+// a la vez distintas direcciones como principales) en ErrMainAddressConflict.
+func mainAddressConflict(err error) error {
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %v", ErrMainAddressConflict, err)
+	}
 	return err
 }
 
-func (r *AddressRepository) GetAddress(ctx context.Context, idProfile uuid.UUID) (*domain.Address, error) {
+// GetAddress devuelve la dirección principal del perfil o, si no tiene, la activa más antigua.
+func (r *AddressRepository) GetAddress(ctx context.Context, idProfile uuid.UUID) (_ *domain.Address, err error) {
 	var address domain.Address
-	query := `SELECT addressId, CP, street, number, floor, mainAddress, creationDate, updatedDate, activeAddress, idProfile 
-	          FROM address WHERE idProfile = $1 AND activeAddress = TRUE`
-
-	err := r.DB.QueryRowContext(ctx, query, idProfile).Scan(
-		&address.AddressID,
-		&address.CP,
-		&address.Street,
-		&address.Number,
-		&address.Floor,
-		&address.MainAddress,
-		&address.CreationDate,
-		&address.UpdatedDate,
-		&address.ActiveAddress,
-		&address.IdProfile,
-	)
+	query := `SELECT ` + addressColumns + `
+	          FROM address WHERE idProfile = $1 AND activeAddress = TRUE
+	          ORDER BY mainAddress DESC, creationDate, addressId LIMIT 1`
+	ctx, span := startSpan(ctx, "AddressRepository.GetAddress", query)
+	defer tracing.End(span, &err)
+
+	err = r.DB.QueryRowContext(ctx, query, idProfile).Scan(addressFields(&address)...)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -64,36 +152,205 @@ func (r *AddressRepository) GetAddress(ctx context.Context, idProfile uuid.UUID)
 	return &address, err
 }
 
-func (r *AddressRepository) UpdateAddress(ctx context.Context, address *domain.Address) error {
-	query := `UPDATE address SET CP = $1, street = $2, number = $3, floor = $4, mainAddress = $5, updatedDate = $6
-			WHERE idProfile = $7 AND activeAddress = TRUE`
+// GetAddressByID obtiene una dirección por su addressId, esté activa o no.
+func (r *AddressRepository) GetAddressByID(ctx context.Context, addressID uuid.UUID) (_ *domain.Address, err error) {
+	var address domain.Address
+	query := `SELECT ` + addressColumns + `
+	          FROM address WHERE addressId = $1`
+	ctx, span := startSpan(ctx, "AddressRepository.GetAddressByID", query)
+	defer tracing.End(span, &err)
+
+	err = r.DB.QueryRowContext(ctx, query, addressID).Scan(addressFields(&address)...)
 
-	_, err := r.DB.ExecContext(ctx, query,
-		address.CP,
-		address.Street,
-		address.Number,
-		address.Floor,
-		address.MainAddress,
-		address.UpdatedDate,
-		address.IdProfile,
-	)
-	return err
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
 }
 
-func (r *AddressRepository) DeleteAddress(ctx context.Context, addressId string, activeAddress bool, idprofile uuid.UUID) error {
-	query := `UPDATE address SET activeaddress = $2
-			WHERE addressid = $1 AND idprofile =$3`
-	_, err := r.DB.ExecContext(ctx, query, addressId, activeAddress, idprofile)
-	return err
+// UpdateAddressByID actualiza la dirección activa indicada por addressId dentro del perfil
+// idProfile; una dada de baja responde ErrAddressNotFound. Con Version distinto de 0 sólo
+// actualiza si la dirección sigue en esa versión. Si queda como principal, en la misma
+// transacción deja de serlo la que lo era; si era la principal y deja de serlo, pasa a serlo la
+// activa más antigua del perfil (o sigue siéndolo si no hay otra).
+func (r *AddressRepository) UpdateAddressByID(ctx context.Context, address *domain.Address) (err error) {
+	query := `UPDATE address SET CP = $1, street = $2, number = $3, floor = $4, apartment = $5, locality = $6,
+			province = $7, country = $8, betweenStreets = $9, addressReferences = $10,
+			mainAddress = $11 OR (` + keepMainAddress + `),
+			latitude = $12, longitude = $13, geocodeQuality = $14, updatedDate = $15,
+			version = version + 1
+			WHERE addressId = $16 AND idProfile = $17 AND activeAddress AND ($18 = 0 OR version = $18)`
+	ctx, span := startSpan(ctx, "AddressRepository.UpdateAddressByID", query)
+	defer tracing.End(span, &err)
+
+	err = inAddressTx(ctx, r.DB, func(tx *sql.Tx) error {
+		// Una dirección dada de baja no puede volver a ser la principal con un update
+		var active bool
+		err := tx.QueryRowContext(ctx, `SELECT activeAddress FROM address WHERE addressId = $1 AND idProfile = $2 FOR UPDATE`,
+			address.AddressID, address.IdProfile).Scan(&active)
+		if err == sql.ErrNoRows || err == nil && !active {
+			return ErrAddressNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if address.MainAddress {
+			if err := clearMainAddress(ctx, tx, address.IdProfile, address.AddressID); err != nil {
+				return err
+			}
+		}
+		result, err := tx.ExecContext(ctx, query,
+			address.CP,
+			address.Street,
+			address.Number,
+			address.Floor,
+			address.Apartment,
+			address.Locality,
+			address.Province,
+			address.Country,
+			address.BetweenStreets,
+			address.References,
+			address.MainAddress,
+			address.Latitude,
+			address.Longitude,
+			geocodeQuality(address.GeocodeQuality),
+			address.UpdatedDate,
+			address.AddressID,
+			address.IdProfile,
+			address.Version,
+		)
+		if err != nil {
+			return err
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return r.notUpdated(ctx, address.AddressID, address.IdProfile)
+		}
+		if address.MainAddress {
+			return nil
+		}
+		return promoteMainAddress(ctx, tx, address.IdProfile, address.AddressID)
+	})
+	return mainAddressConflict(err)
 }
 
-// GetAddressesByProfile obtiene todas las direcciones de un perfil.
-func (r *AddressRepository) GetAddressesByProfile(ctx context.Context, IdProfile uuid.UUID) ([]domain.Address, error) {
-	query := `SELECT addressId, CP, street, number, floor, mainAddress,
-			creationDate, updatedDate, activeAddress, idprofile
-			FROM address WHERE idProfile= $1`
+// PatchAddress actualiza sólo las columnas presentes en el patch (y updatedDate).
+// Con version distinto de 0 sólo actualiza si la dirección sigue en esa versión. mainAddress
+// funciona igual que en UpdateAddressByID.
+func (r *AddressRepository) PatchAddress(ctx context.Context, addressID, idProfile uuid.UUID, version int, patch domain.AddressPatch, updatedDate time.Time) (err error) {
+	var set setClause
+	addField(&set, "CP", patch.CP)
+	addField(&set, "street", patch.Street)
+	addField(&set, "number", patch.Number)
+	addField(&set, "floor", patch.Floor)
+	addField(&set, "apartment", patch.Apartment)
+	addField(&set, "locality", patch.Locality)
+	addField(&set, "province", patch.Province)
+	addField(&set, "country", patch.Country)
+	addField(&set, "betweenStreets", patch.BetweenStreets)
+	addField(&set, "addressReferences", patch.References)
+	demoted := patch.MainAddress.Set && !patch.MainAddress.Value
+	if demoted {
+		set.addRaw("mainAddress = " + keepMainAddress)
+	} else {
+		addField(&set, "mainAddress", patch.MainAddress)
+	}
+	addField(&set, "latitude", patch.Latitude)
+	addField(&set, "longitude", patch.Longitude)
+	addField(&set, "geocodeQuality", patch.GeocodeQuality)
+	set.add("updatedDate", updatedDate)
+	set.addRaw("version = version + 1")
 
-	rows, err := r.DB.QueryContext(ctx, query, IdProfile)
+	n := len(set.args)
+	query := fmt.Sprintf("UPDATE address SET %s WHERE addressId = $%d AND idProfile = $%d AND ($%d = 0 OR version = $%d)",
+		set, n+1, n+2, n+3, n+3)
+	ctx, span := startSpan(ctx, "AddressRepository.PatchAddress", query)
+	defer tracing.End(span, &err)
+
+	err = inAddressTx(ctx, r.DB, func(tx *sql.Tx) error {
+		if patch.MainAddress.Set && patch.MainAddress.Value {
+			if err := clearMainAddress(ctx, tx, idProfile, addressID); err != nil {
+				return err
+			}
+		}
+		result, err := tx.ExecContext(ctx, query, append(set.args, addressID, idProfile, version)...)
+		if err != nil {
+			return err
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return r.notUpdated(ctx, addressID, idProfile)
+		}
+		if !demoted {
+			return nil
+		}
+		return promoteMainAddress(ctx, tx, idProfile, addressID)
+	})
+	return mainAddressConflict(err)
+}
+
+// SetMainAddress marca la dirección como principal y, en la misma transacción, desmarca la que
+// lo era. La dirección debe estar activa; version funciona igual que en PatchAddress.
+func (r *AddressRepository) SetMainAddress(ctx context.Context, addressID, idProfile uuid.UUID, version int) (err error) {
+	query := `UPDATE address SET mainAddress = TRUE, version = version + 1
+			WHERE addressId = $1 AND idProfile = $2 AND activeAddress AND ($3 = 0 OR version = $3)`
+	ctx, span := startSpan(ctx, "AddressRepository.SetMainAddress", query)
+	defer tracing.End(span, &err)
+
+	err = inAddressTx(ctx, r.DB, func(tx *sql.Tx) error {
+		if err := clearMainAddress(ctx, tx, idProfile, addressID); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, query, addressID, idProfile, version)
+		if err != nil {
+			return err
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return r.notUpdated(ctx, addressID, idProfile)
+		}
+		return nil
+	})
+	return mainAddressConflict(err)
+}
+
+// SetGeocode guarda el resultado de geocodificar la dirección si sigue en esa versión (si no,
+// ErrVersionConflict). No incrementa la versión: las coordenadas se derivan de la dirección.
+func (r *AddressRepository) SetGeocode(ctx context.Context, addressID uuid.UUID, version int, geocode domain.Geocode) (err error) {
+	query := `UPDATE address SET latitude = $1, longitude = $2, geocodeQuality = $3
+			WHERE addressId = $4 AND version = $5`
+	ctx, span := startSpan(ctx, "AddressRepository.SetGeocode", query)
+	defer tracing.End(span, &err)
+
+	var address domain.Address
+	address.ApplyGeocode(geocode)
+	result, err := r.DB.ExecContext(ctx, query, address.Latitude, address.Longitude, address.GeocodeQuality, addressID, version)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		var exists bool
+		if err := r.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM address WHERE addressId = $1)`, addressID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrAddressNotFound
+		}
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// PendingGeocodes devuelve hasta limit direcciones activas pendientes de geocodificar, las
+// modificadas hace más tiempo primero.
+func (r *AddressRepository) PendingGeocodes(ctx context.Context, limit int) (_ []domain.Address, err error) {
+	query := `SELECT ` + addressColumns + `
+			FROM address WHERE activeAddress AND geocodeQuality = 'pending'
+			ORDER BY updatedDate, addressId LIMIT $1`
+	ctx, span := startSpan(ctx, "AddressRepository.PendingGeocodes", query)
+	defer tracing.End(span, &err)
+
+	rows, err := r.DB.QueryContext(ctx, query, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -102,15 +359,226 @@ func (r *AddressRepository) GetAddressesByProfile(ctx context.Context, IdProfile
 	var addresses []domain.Address
 	for rows.Next() {
 		var address domain.Address
-		err := rows.Scan(
-			&address.AddressID, &address.CP, &address.Street, &address.Number, &address.Floor,
-			&address.MainAddress, &address.CreationDate, &address.UpdatedDate,
-			&address.ActiveAddress, &address.IdProfile,
-		)
+		if err := rows.Scan(addressFields(&address)...); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, rows.Err()
+}
+
+// ResetGeocodes borra las coordenadas de todas las direcciones activas, las deja pendientes de
+// geocodificar y devuelve cuántas son.
+func (r *AddressRepository) ResetGeocodes(ctx context.Context) (_ int, err error) {
+	query := `UPDATE address SET latitude = NULL, longitude = NULL, geocodeQuality = 'pending' WHERE activeAddress`
+	ctx, span := startSpan(ctx, "AddressRepository.ResetGeocodes", query)
+	defer tracing.End(span, &err)
+
+	result, err := r.DB.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// distanceKm es la distancia de haversine en km de la dirección al punto ($1, $2); la misma
+// fórmula y radio que geo.DistanceKm.
+const distanceKm = `2 * 6371.0088 * asin(least(1, sqrt(
+	power(sin(radians(latitude - $1) / 2), 2) +
+	cos(radians($1)) * cos(radians(latitude)) * power(sin(radians(longitude - $2) / 2), 2))))`
+
+// NearbyAddresses devuelve hasta filter.Limit direcciones activas con coordenadas a lo sumo a
+// filter.RadiusKm del punto, de la más cercana a la más lejana. El rectángulo de geo.BoundingBox
+// descarta con address_coordinates_idx las que no pueden estar dentro del radio.
+func (r *AddressRepository) NearbyAddresses(ctx context.Context, filter domain.NearbyFilter) (_ []domain.AddressDistance, err error) {
+	query := `SELECT ` + addressColumns + `, distance FROM (
+				SELECT *, ` + distanceKm + ` AS distance
+				FROM address
+				WHERE activeAddress AND latitude IS NOT NULL
+					AND latitude BETWEEN $3 AND $4 AND longitude BETWEEN $5 AND $6
+			) nearby
+			WHERE distance <= $7
+			ORDER BY distance, addressId LIMIT $8`
+	ctx, span := startSpan(ctx, "AddressRepository.NearbyAddresses", query)
+	defer tracing.End(span, &err)
+
+	box := geo.BoundingBox(filter.Latitude, filter.Longitude, filter.RadiusKm)
+	rows, err := r.DB.QueryContext(ctx, query, filter.Latitude, filter.Longitude,
+		box.MinLat, box.MaxLat, box.MinLon, box.MaxLon, filter.RadiusKm, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []domain.AddressDistance
+	for rows.Next() {
+		var address domain.AddressDistance
+		if err := rows.Scan(append(addressFields(&address.Address), &address.DistanceKm)...); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, rows.Err()
+}
+
+// NearestAddress devuelve la dirección activa con coordenadas del perfil más cercana al punto,
+// o nil si el perfil no tiene ninguna.
+func (r *AddressRepository) NearestAddress(ctx context.Context, idProfile uuid.UUID, lat, lon float64) (_ *domain.AddressDistance, err error) {
+	query := `SELECT ` + addressColumns + `, ` + distanceKm + ` AS distance
+			FROM address
+			WHERE idProfile = $3 AND activeAddress AND latitude IS NOT NULL
+			ORDER BY distance, addressId LIMIT 1`
+	ctx, span := startSpan(ctx, "AddressRepository.NearestAddress", query)
+	defer tracing.End(span, &err)
+
+	var address domain.AddressDistance
+	err = r.DB.QueryRowContext(ctx, query, lat, lon, idProfile).
+		Scan(append(addressFields(&address.Address), &address.DistanceKm)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// historyColumns son las columnas de address_history en el orden de revisionFields.
+const historyColumns = `version, operation, COALESCE(changedBy, ''), changedAt, addressId, CP, street, number,
+	          floor, apartment, locality, province, country, betweenStreets, addressReferences, mainAddress,
+	          activeAddress, creationDate, updatedDate, idProfile`
+
+// revisionFields devuelve los destinos de Scan para una fila con historyColumns.
+func revisionFields(rev *domain.AddressRevision) []interface{} {
+	a := &rev.Address
+	return []interface{}{
+		&rev.Version, &rev.Operation, &rev.ChangedBy, &rev.ChangedAt, &a.AddressID, &a.CP, &a.Street, &a.Number,
+		&a.Floor, &a.Apartment, &a.Locality, &a.Province, &a.Country, &a.BetweenStreets, &a.References, &a.MainAddress,
+		&a.ActiveAddress, &a.CreationDate, &a.UpdatedDate, &a.IdProfile,
+	}
+}
+
+// AddressHistory devuelve todas las revisiones de la dirección, de la más antigua a la más nueva.
+// El trigger address_history_record guarda una por cada versión.
+func (r *AddressRepository) AddressHistory(ctx context.Context, addressID uuid.UUID) (_ []domain.AddressRevision, err error) {
+	query := `SELECT ` + historyColumns + `
+			FROM address_history WHERE addressId = $1
+			ORDER BY version`
+	ctx, span := startSpan(ctx, "AddressRepository.AddressHistory", query)
+	defer tracing.End(span, &err)
+
+	rows, err := r.DB.QueryContext(ctx, query, addressID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []domain.AddressRevision
+	for rows.Next() {
+		var rev domain.AddressRevision
+		if err := rows.Scan(revisionFields(&rev)...); err != nil {
+			return nil, err
+		}
+		rev.Address.Version = rev.Version
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// AddressAsOf devuelve la revisión de la dirección vigente en el instante at (la última
+// guardada hasta ese momento), o nil si la dirección todavía no existía.
+func (r *AddressRepository) AddressAsOf(ctx context.Context, addressID uuid.UUID, at time.Time) (_ *domain.AddressRevision, err error) {
+	query := `SELECT ` + historyColumns + `
+			FROM address_history WHERE addressId = $1 AND changedAt <= $2
+			ORDER BY version DESC LIMIT 1`
+	ctx, span := startSpan(ctx, "AddressRepository.AddressAsOf", query)
+	defer tracing.End(span, &err)
+
+	var rev domain.AddressRevision
+	err = r.DB.QueryRowContext(ctx, query, addressID, at).Scan(revisionFields(&rev)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rev.Address.Version = rev.Version
+	return &rev, nil
+}
+
+// notUpdated explica por qué un UPDATE condicionado no afectó filas: la dirección no existe
+// en ese perfil (ErrAddressNotFound) o cambió de versión (ErrVersionConflict).
+func (r *AddressRepository) notUpdated(ctx context.Context, addressID, idProfile uuid.UUID) error {
+	var exists bool
+	err := r.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM address WHERE addressId = $1 AND idProfile = $2)`,
+		addressID, idProfile).Scan(&exists)
+	switch {
+	case err != nil:
+		return err
+	case exists:
+		return ErrVersionConflict
+	default:
+		return ErrAddressNotFound
+	}
+}
+
+// DeleteAddress activa o da de baja una dirección del perfil (ErrAddressNotFound si no existe
+// en él). Una dirección dada de baja deja de ser la principal; si el perfil queda sin principal
+// (o ya no tenía una al reactivarla), en la misma transacción pasa a serlo la activa más antigua.
+// Con version distinto de 0 sólo la modifica si sigue en esa versión (si no, ErrVersionConflict).
+func (r *AddressRepository) DeleteAddress(ctx context.Context, addressId string, activeAddress bool, idprofile uuid.UUID, version int) (err error) {
+	query := `UPDATE address SET activeaddress = $2, mainAddress = mainAddress AND $2, version = version + 1
+			WHERE addressid = $1 AND idprofile = $3 AND ($4 = 0 OR version = $4)`
+	ctx, span := startSpan(ctx, "AddressRepository.DeleteAddress", query)
+	defer tracing.End(span, &err)
+
+	err = inAddressTx(ctx, r.DB, func(tx *sql.Tx) error {
+		var wasMain bool
+		err := tx.QueryRowContext(ctx, `SELECT mainAddress FROM address WHERE addressId = $1 AND idProfile = $2 FOR UPDATE`,
+			addressId, idprofile).Scan(&wasMain)
+		if err == sql.ErrNoRows {
+			return ErrAddressNotFound
+		}
 		if err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, query, addressId, activeAddress, idprofile, version)
+		if err != nil {
+			return err
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return ErrVersionConflict
+		}
+		if !wasMain && !activeAddress {
+			return nil
+		}
+		return promoteMainAddress(ctx, tx, idprofile, uuid.Nil)
+	})
+	return mainAddressConflict(err)
+}
+
+// GetAddressesByProfile obtiene todas las direcciones de un perfil, en orden de creación.
+func (r *AddressRepository) GetAddressesByProfile(ctx context.Context, IdProfile uuid.UUID) (_ []domain.Address, err error) {
+	query := `SELECT ` + addressColumns + `
+			FROM address WHERE idProfile= $1
+			ORDER BY creationDate, addressId`
+	ctx, span := startSpan(ctx, "AddressRepository.GetAddressesByProfile", query)
+	defer tracing.End(span, &err)
+
+	rows, err := r.DB.QueryContext(ctx, query, IdProfile)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []domain.Address
+	for rows.Next() {
+		var address domain.Address
+		if err := rows.Scan(addressFields(&address)...); err != nil {
 			return nil, err
 		}
 		addresses = append(addresses, address)
 	}
-	return addresses, nil
+	return addresses, rows.Err()
 }
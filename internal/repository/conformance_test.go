@@ -0,0 +1,1040 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"profilego/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// storeFactory devuelve un par de stores listos para usar por un subtest.
+type storeFactory func(t *testing.T) (ProfileStore, AddressStore)
+
+// runStoreConformance ejecuta la misma batería de pruebas sobre cualquier backend.
+func runStoreConformance(t *testing.T, newStores storeFactory) {
+	t.Run("ProfileStore", func(t *testing.T) {
+		profiles, _ := newStores(t)
+		testProfileStore(t, profiles)
+	})
+	t.Run("AddressStore", func(t *testing.T) {
+		profiles, addresses := newStores(t)
+		testAddressStore(t, profiles, addresses)
+	})
+}
+
+func newTestProfile(t *testing.T, ctx context.Context, store ProfileStore) *domain.Profile {
+	t.Helper()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	profile := &domain.Profile{
+		ProfileID:    uuid.New(),
+		UserID:       "user-" + uuid.NewString(),
+		ProfileName:  "Juan Perez",
+		ProfileMail:  "juan@example.com",
+		Phone:        "1133445566",
+		CreationDate: now,
+		UpdatedDate:  now,
+	}
+	if err := store.CreateProfile(ctx, profile); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	return profile
+}
+
+func testProfileStore(t *testing.T, store ProfileStore) {
+	ctx := context.Background()
+
+	t.Run("GetByUserID inexistente devuelve nil", func(t *testing.T) {
+		p, err := store.GetByUserID(ctx, "no-existe-"+uuid.NewString())
+		if err != nil || p != nil {
+			t.Fatalf("esperaba (nil, nil), obtuve (%v, %v)", p, err)
+		}
+		p, err = store.GetProfile(ctx, "no-existe-"+uuid.NewString())
+		if err != nil || p != nil {
+			t.Fatalf("esperaba (nil, nil), obtuve (%v, %v)", p, err)
+		}
+		p, err = store.GetByProfileID(ctx, uuid.New())
+		if err != nil || p != nil {
+			t.Fatalf("esperaba (nil, nil), obtuve (%v, %v)", p, err)
+		}
+	})
+
+	t.Run("CreateProfile y lectura", func(t *testing.T) {
+		created := newTestProfile(t, ctx, store)
+
+		got, err := store.GetByUserID(ctx, created.UserID)
+		if err != nil || got == nil {
+			t.Fatalf("GetByUserID: (%v, %v)", got, err)
+		}
+		if got.ProfileID != created.ProfileID || got.ProfileName != created.ProfileName ||
+			got.ProfileMail != created.ProfileMail || got.Phone != created.Phone {
+			t.Fatalf("perfil distinto: %+v", got)
+		}
+		if got.ProfilePoints != 0 || got.ProfileLevel != 0 {
+			t.Fatalf("puntos/nivel iniciales deben ser 0: %+v", got)
+		}
+
+		byID, err := store.GetByProfileID(ctx, created.ProfileID)
+		if err != nil || byID == nil || byID.UserID != created.UserID {
+			t.Fatalf("GetByProfileID: (%+v, %v)", byID, err)
+		}
+
+		full, err := store.GetProfile(ctx, created.UserID)
+		if err != nil || full == nil {
+			t.Fatalf("GetProfile: (%v, %v)", full, err)
+		}
+		if full.ProfileImage != nil || full.CUIL != "" || full.IIBB != nil {
+			t.Fatalf("datos fiscales e imagen deben estar vacíos: %+v", full)
+		}
+		if !full.CreationDate.Equal(created.CreationDate) {
+			t.Fatalf("creationDate: esperaba %v, obtuve %v", created.CreationDate, full.CreationDate)
+		}
+	})
+
+	t.Run("CreateProfile duplicado falla", func(t *testing.T) {
+		created := newTestProfile(t, ctx, store)
+		dup := *created
+		dup.ProfileID = uuid.New()
+		if err := store.CreateProfile(ctx, &dup); !errors.Is(err, ErrProfileExists) {
+			t.Fatalf("esperaba ErrProfileExists al crear un segundo perfil para el mismo userId, obtuve %v", err)
+		}
+	})
+
+	t.Run("UpdateProfile", func(t *testing.T) {
+		created := newTestProfile(t, ctx, store)
+		update := &domain.Profile{
+			ProfileID:   created.ProfileID,
+			ProfileName: "Juan Actualizado",
+			ProfileMail: "nuevo@example.com",
+			Phone:       "1199887766",
+			UpdatedDate: time.Now(),
+		}
+		if err := store.UpdateProfile(ctx, created.UserID, update); err != nil {
+			t.Fatalf("UpdateProfile: %v", err)
+		}
+		got, _ := store.GetByUserID(ctx, created.UserID)
+		if got.ProfileName != update.ProfileName || got.ProfileMail != update.ProfileMail || got.Phone != update.Phone {
+			t.Fatalf("perfil no actualizado: %+v", got)
+		}
+
+		missing := *update
+		missing.ProfileID = uuid.New()
+		if err := store.UpdateProfile(ctx, created.UserID, &missing); err == nil {
+			t.Fatal("esperaba error al actualizar un profileId inexistente")
+		}
+
+		other := newTestProfile(t, ctx, store)
+		foreign := *update
+		foreign.ProfileID = other.ProfileID
+		if err := store.UpdateProfile(ctx, created.UserID, &foreign); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("esperaba ErrUserNotFound al actualizar el perfil de otro usuario, obtuve %v", err)
+		}
+		got, _ = store.GetByUserID(ctx, other.UserID)
+		if got.ProfileName != other.ProfileName || got.ProfileMail != other.ProfileMail || got.Version != other.Version {
+			t.Fatalf("el perfil de otro usuario no debe cambiar: %+v", got)
+		}
+	})
+
+	t.Run("UpdateFiscalData e imagen", func(t *testing.T) {
+		created := newTestProfile(t, ctx, store)
+		fiscal := &domain.Profile{
+			ProfileID:           created.ProfileID,
+			CUIL:                "20123456786",
+			FiscalAdress:        "Av. Siempre Viva 742",
+			FiscalCondition:     domain.FiscalConditionMonotributista,
+			IIBB:                &domain.IIBB{Regime: domain.IIBBRegimeLocal, Number: "1234567", Jurisdiction: "901"},
+			UpdatedDate:         time.Now(),
+			MonotributoCategory: "C",
+		}
+		if err := store.UpdateFiscalData(ctx, created.UserID, fiscal); err != nil {
+			t.Fatalf("UpdateFiscalData: %v", err)
+		}
+		if err := store.UpdateProfileImage(ctx, created.UserID, created.ProfileID.String(), "avatar.png"); err != nil {
+			t.Fatalf("UpdateProfileImage: %v", err)
+		}
+
+		got, _ := store.GetProfile(ctx, created.UserID)
+		if got.CUIL != fiscal.CUIL || got.FiscalAdress != fiscal.FiscalAdress ||
+			got.FiscalCondition != fiscal.FiscalCondition || got.MonotributoCategory != fiscal.MonotributoCategory ||
+			got.IIBB == nil || *got.IIBB != *fiscal.IIBB {
+			t.Fatalf("datos fiscales no actualizados: %+v", got)
+		}
+		if got.ProfileImage == nil || *got.ProfileImage != "avatar.png" {
+			t.Fatalf("imagen no actualizada: %v", got.ProfileImage)
+		}
+		if !got.UpdatedDate.After(fiscal.UpdatedDate) {
+			t.Fatalf("UpdateProfileImage debe actualizar updatedDate: %v", got.UpdatedDate)
+		}
+
+		other := newTestProfile(t, ctx, store)
+		if err := store.UpdateProfileImage(ctx, created.UserID, other.ProfileID.String(), "ajena.png"); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("esperaba ErrUserNotFound con el profileId de otro usuario, obtuve %v", err)
+		}
+		if err := store.UpdateProfileImage(ctx, uuid.NewString(), created.ProfileID.String(), "ajena.png"); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("esperaba ErrUserNotFound con un usuario inexistente, obtuve %v", err)
+		}
+		got, _ = store.GetProfile(ctx, other.UserID)
+		if got.ProfileImage != nil {
+			t.Fatalf("la imagen de otro perfil no debe cambiar: %v", *got.ProfileImage)
+		}
+
+		fiscal.FiscalCondition = domain.FiscalConditionConsumidorFinal
+		fiscal.MonotributoCategory = ""
+		fiscal.IIBB = nil
+		if err := store.UpdateFiscalData(ctx, created.UserID, fiscal); err != nil {
+			t.Fatalf("UpdateFiscalData sin IIBB: %v", err)
+		}
+		got, _ = store.GetProfile(ctx, created.UserID)
+		if got.IIBB != nil || got.MonotributoCategory != "" || got.FiscalCondition != domain.FiscalConditionConsumidorFinal {
+			t.Fatalf("IIBB y categoría deben quedar vacíos: %+v", got)
+		}
+
+		// Sin If-Match también se informa el perfil inexistente
+		missing := *fiscal
+		missing.ProfileID = uuid.New()
+		if err := store.UpdateFiscalData(ctx, created.UserID, &missing); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("esperaba ErrUserNotFound en UpdateFiscalData de un perfil inexistente, obtuve %v", err)
+		}
+	})
+
+	t.Run("PatchProfile sólo cambia los campos enviados", func(t *testing.T) {
+		created := newTestProfile(t, ctx, store)
+		if err := store.UpdateProfileImage(ctx, created.UserID, created.ProfileID.String(), "avatar.png"); err != nil {
+			t.Fatalf("UpdateProfileImage: %v", err)
+		}
+
+		patch := domain.ProfilePatch{Phone: domain.SetField("1199887766")}
+		if err := store.PatchProfile(ctx, created.ProfileID, 0, patch, time.Now()); err != nil {
+			t.Fatalf("PatchProfile: %v", err)
+		}
+		got, _ := store.GetProfile(ctx, created.UserID)
+		if got.Phone != "1199887766" || got.ProfileName != created.ProfileName || got.ProfileMail != created.ProfileMail {
+			t.Fatalf("patch aplicado de más o de menos: %+v", got)
+		}
+		if got.ProfileImage == nil || *got.ProfileImage != "avatar.png" {
+			t.Fatalf("la imagen no debía cambiar: %v", got.ProfileImage)
+		}
+
+		clear := domain.ProfilePatch{ProfileImage: domain.Field[string]{Set: true, Null: true}}
+		if err := store.PatchProfile(ctx, created.ProfileID, 0, clear, time.Now()); err != nil {
+			t.Fatalf("PatchProfile null: %v", err)
+		}
+		if got, _ := store.GetProfile(ctx, created.UserID); got.ProfileImage != nil {
+			t.Fatalf("null debía borrar la imagen: %v", *got.ProfileImage)
+		}
+
+		if err := store.PatchProfile(ctx, uuid.New(), 0, patch, time.Now()); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("esperaba ErrUserNotFound, obtuve %v", err)
+		}
+	})
+
+	t.Run("Version se incrementa y condiciona las actualizaciones", func(t *testing.T) {
+		created := newTestProfile(t, ctx, store)
+		if created.Version != 1 {
+			t.Fatalf("la versión inicial debe ser 1, obtuve %d", created.Version)
+		}
+
+		patch := domain.ProfilePatch{ProfileName: domain.SetField("Otro Nombre")}
+		if err := store.PatchProfile(ctx, created.ProfileID, 1, patch, time.Now()); err != nil {
+			t.Fatalf("PatchProfile con la versión vigente: %v", err)
+		}
+		got, _ := store.GetProfile(ctx, created.UserID)
+		if got.Version != 2 {
+			t.Fatalf("esperaba versión 2, obtuve %d", got.Version)
+		}
+		if byID, _ := store.GetByProfileID(ctx, created.ProfileID); byID.Version != 2 {
+			t.Fatalf("GetByProfileID debe devolver la versión: %d", byID.Version)
+		}
+
+		if err := store.PatchProfile(ctx, created.ProfileID, 1, patch, time.Now()); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("esperaba ErrVersionConflict con una versión vieja, obtuve %v", err)
+		}
+		if err := store.PatchProfile(ctx, uuid.New(), 1, patch, time.Now()); !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("esperaba ErrUserNotFound, obtuve %v", err)
+		}
+
+		fiscal := &domain.Profile{ProfileID: created.ProfileID, CUIL: "20123456786", UpdatedDate: time.Now(), Version: 1}
+		if err := store.UpdateFiscalData(ctx, created.UserID, fiscal); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("esperaba ErrVersionConflict en UpdateFiscalData, obtuve %v", err)
+		}
+
+		if err := store.UpdateProfilePoints(ctx, created.UserID, &domain.Profile{ProfilePoints: 10}); err != nil {
+			t.Fatalf("UpdateProfilePoints: %v", err)
+		}
+		if got, _ := store.GetProfile(ctx, created.UserID); got.Version != 3 {
+			t.Fatalf("toda modificación debe incrementar la versión, obtuve %d", got.Version)
+		}
+	})
+
+	t.Run("UpdateProfilePoints suma puntos", func(t *testing.T) {
+		created := newTestProfile(t, ctx, store)
+		for _, pts := range []int{300, 450} {
+			if err := store.UpdateProfilePoints(ctx, created.UserID, &domain.Profile{ProfilePoints: pts}); err != nil {
+				t.Fatalf("UpdateProfilePoints: %v", err)
+			}
+		}
+		got, _ := store.GetByUserID(ctx, created.UserID)
+		if got.ProfilePoints != 750 {
+			t.Fatalf("esperaba 750 puntos, obtuve %d", got.ProfilePoints)
+		}
+
+		err := store.UpdateProfilePoints(ctx, "no-existe-"+uuid.NewString(), &domain.Profile{ProfilePoints: 1})
+		if !errors.Is(err, ErrUserNotFound) || err.Error() != "usuario no encontrado" {
+			t.Fatalf("esperaba ErrUserNotFound, obtuve %v", err)
+		}
+	})
+
+	t.Run("UpdateProfileLevel resetea puntos por encima de 1000", func(t *testing.T) {
+		created := newTestProfile(t, ctx, store)
+		update := &domain.Profile{ProfileLevel: 2, ProfilePoints: 1200}
+		if err := store.UpdateProfileLevel(ctx, created.UserID, update); err != nil {
+			t.Fatalf("UpdateProfileLevel: %v", err)
+		}
+		if update.ProfilePoints != 0 {
+			t.Fatalf("el perfil recibido debe quedar con 0 puntos, tiene %d", update.ProfilePoints)
+		}
+		got, _ := store.GetByUserID(ctx, created.UserID)
+		if got.ProfileLevel != 2 || got.ProfilePoints != 0 {
+			t.Fatalf("esperaba nivel 2 y 0 puntos: %+v", got)
+		}
+
+		if err := store.UpdateProfileLevel(ctx, created.UserID, &domain.Profile{ProfileLevel: 3, ProfilePoints: 500}); err != nil {
+			t.Fatalf("UpdateProfileLevel: %v", err)
+		}
+		got, _ = store.GetByUserID(ctx, created.UserID)
+		if got.ProfileLevel != 3 || got.ProfilePoints != 500 {
+			t.Fatalf("esperaba nivel 3 y 500 puntos: %+v", got)
+		}
+
+		err := store.UpdateProfileLevel(ctx, "no-existe-"+uuid.NewString(), &domain.Profile{ProfileLevel: 1})
+		if !errors.Is(err, ErrUserNotFound) {
+			t.Fatalf("esperaba ErrUserNotFound, obtuve %v", err)
+		}
+	})
+
+	t.Run("DeleteProfile", func(t *testing.T) {
+		created := newTestProfile(t, ctx, store)
+		if err := store.DeleteProfile(ctx, created.ProfileID, 0); err != nil {
+			t.Fatalf("DeleteProfile: %v", err)
+		}
+		if got, err := store.GetByUserID(ctx, created.UserID); err != nil || got != nil {
+			t.Fatalf("esperaba perfil eliminado, obtuve (%v, %v)", got, err)
+		}
+		if err := store.DeleteProfile(ctx, uuid.New(), 0); err != nil {
+			t.Fatalf("eliminar un perfil inexistente no debe fallar: %v", err)
+		}
+	})
+
+	t.Run("DeleteProfile con versión vieja", func(t *testing.T) {
+		created := newTestProfile(t, ctx, store)
+		if err := store.UpdateProfilePoints(ctx, created.UserID, &domain.Profile{ProfilePoints: 1}); err != nil {
+			t.Fatalf("UpdateProfilePoints: %v", err)
+		}
+		if err := store.DeleteProfile(ctx, created.ProfileID, created.Version); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("esperaba ErrVersionConflict, obtuve %v", err)
+		}
+		if got, _ := store.GetByUserID(ctx, created.UserID); got == nil {
+			t.Fatal("el perfil no debe eliminarse con una versión vieja")
+		}
+		if err := store.DeleteProfile(ctx, created.ProfileID, created.Version+1); err != nil {
+			t.Fatalf("DeleteProfile con la versión vigente: %v", err)
+		}
+	})
+}
+
+func testAddressStore(t *testing.T, profiles ProfileStore, store AddressStore) {
+	ctx := context.Background()
+
+	newAddress := func(profileID uuid.UUID, street string) *domain.Address {
+		floor := "3B"
+		address := domain.NewAddress("C1425ABC", street, &floor, true, true, 1234, profileID)
+		address.Province = "AR-C"
+		address.Locality = "Palermo"
+		address.CreationDate = address.CreationDate.UTC().Truncate(time.Microsecond)
+		address.UpdatedDate = address.CreationDate
+		if err := store.CreateAddress(ctx, address); err != nil {
+			t.Fatalf("CreateAddress: %v", err)
+		}
+		return address
+	}
+
+	t.Run("GetAddress sin direcciones devuelve nil", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		got, err := store.GetAddress(ctx, profile.ProfileID)
+		if err != nil || got != nil {
+			t.Fatalf("esperaba (nil, nil), obtuve (%v, %v)", got, err)
+		}
+		list, err := store.GetAddressesByProfile(ctx, profile.ProfileID)
+		if err != nil || len(list) != 0 {
+			t.Fatalf("esperaba lista vacía, obtuve (%v, %v)", list, err)
+		}
+	})
+
+	t.Run("CreateAddress y GetAddress", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		created := newAddress(profile.ProfileID, "Av. Corrientes")
+
+		got, err := store.GetAddress(ctx, profile.ProfileID)
+		if err != nil || got == nil {
+			t.Fatalf("GetAddress: (%v, %v)", got, err)
+		}
+		if got.AddressID != created.AddressID || got.Street != created.Street || got.CP != created.CP ||
+			got.Number != created.Number || got.Floor == nil || *got.Floor != *created.Floor ||
+			got.Province != "AR-C" || got.Locality != "Palermo" || got.Country != "AR" ||
+			got.Apartment != nil || got.BetweenStreets != nil || got.References != nil {
+			t.Fatalf("dirección distinta: %+v", got)
+		}
+	})
+
+	t.Run("UpdateAddressByID y PatchAddress con los datos estructurados", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		created := newAddress(profile.ProfileID, "Av. Colón")
+
+		apartment, between, references := "B", "Entre Ríos y Rivadavia", "Portón verde"
+		update := *created
+		update.CP = "X5000ABC"
+		update.Province = "AR-X"
+		update.Locality = "Córdoba"
+		update.Apartment = &apartment
+		update.BetweenStreets = &between
+		update.References = &references
+		update.UpdatedDate = time.Now()
+		if err := store.UpdateAddressByID(ctx, &update); err != nil {
+			t.Fatalf("UpdateAddressByID: %v", err)
+		}
+		got, _ := store.GetAddressByID(ctx, created.AddressID)
+		if got.CP != "X5000ABC" || got.Province != "AR-X" || got.Locality != "Córdoba" ||
+			got.Apartment == nil || *got.Apartment != apartment ||
+			got.BetweenStreets == nil || *got.BetweenStreets != between ||
+			got.References == nil || *got.References != references {
+			t.Fatalf("datos estructurados no actualizados: %+v", got)
+		}
+
+		patch := domain.AddressPatch{
+			Locality:   domain.SetField("Villa Allende"),
+			Apartment:  domain.Field[string]{Set: true, Null: true},
+			References: domain.Field[string]{Set: true, Null: true},
+		}
+		if err := store.PatchAddress(ctx, created.AddressID, profile.ProfileID, 0, patch, time.Now()); err != nil {
+			t.Fatalf("PatchAddress: %v", err)
+		}
+		got, _ = store.GetAddressByID(ctx, created.AddressID)
+		if got.Locality != "Villa Allende" || got.Apartment != nil || got.References != nil ||
+			got.BetweenStreets == nil || *got.BetweenStreets != between || got.Province != "AR-X" {
+			t.Fatalf("patch de datos estructurados no aplicado: %+v", got)
+		}
+	})
+
+	t.Run("una sola dirección principal por perfil", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		other := newTestProfile(t, ctx, profiles)
+		first := newAddress(profile.ProfileID, "Calle 1")
+		second := newAddress(profile.ProfileID, "Calle 2")
+		otherMain := newAddress(other.ProfileID, "Calle 3")
+
+		mains := func(profileID uuid.UUID) []uuid.UUID {
+			t.Helper()
+			list, err := store.GetAddressesByProfile(ctx, profileID)
+			if err != nil {
+				t.Fatalf("GetAddressesByProfile: %v", err)
+			}
+			var ids []uuid.UUID
+			for _, a := range list {
+				if a.MainAddress {
+					ids = append(ids, a.AddressID)
+				}
+			}
+			return ids
+		}
+		if got := mains(profile.ProfileID); len(got) != 1 || got[0] != second.AddressID {
+			t.Fatalf("crear una dirección principal debe desmarcar la anterior: %v", got)
+		}
+		if got, _ := store.GetAddress(ctx, profile.ProfileID); got.AddressID != second.AddressID {
+			t.Fatalf("GetAddress debe devolver la principal, obtuve %+v", got)
+		}
+
+		current, _ := store.GetAddressByID(ctx, first.AddressID)
+		if err := store.SetMainAddress(ctx, first.AddressID, profile.ProfileID, current.Version+1); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("esperaba ErrVersionConflict, obtuve %v", err)
+		}
+		if err := store.SetMainAddress(ctx, first.AddressID, profile.ProfileID, current.Version); err != nil {
+			t.Fatalf("SetMainAddress: %v", err)
+		}
+		if got := mains(profile.ProfileID); len(got) != 1 || got[0] != first.AddressID {
+			t.Fatalf("SetMainAddress debe dejar una sola principal: %v", got)
+		}
+		if got := mains(other.ProfileID); len(got) != 1 || got[0] != otherMain.AddressID {
+			t.Fatalf("no debe modificar direcciones de otro perfil: %v", got)
+		}
+		if err := store.SetMainAddress(ctx, uuid.New(), profile.ProfileID, 0); !errors.Is(err, ErrAddressNotFound) {
+			t.Fatalf("esperaba ErrAddressNotFound, obtuve %v", err)
+		}
+
+		update, _ := store.GetAddressByID(ctx, second.AddressID)
+		update.MainAddress = true
+		if err := store.UpdateAddressByID(ctx, update); err != nil {
+			t.Fatalf("UpdateAddressByID: %v", err)
+		}
+		if got := mains(profile.ProfileID); len(got) != 1 || got[0] != second.AddressID {
+			t.Fatalf("UpdateAddressByID debe dejar una sola principal: %v", got)
+		}
+
+		patch := domain.AddressPatch{MainAddress: domain.SetField(true)}
+		if err := store.PatchAddress(ctx, first.AddressID, profile.ProfileID, 0, patch, time.Now()); err != nil {
+			t.Fatalf("PatchAddress: %v", err)
+		}
+		if got := mains(profile.ProfileID); len(got) != 1 || got[0] != first.AddressID {
+			t.Fatalf("PatchAddress debe dejar una sola principal: %v", got)
+		}
+	})
+
+	t.Run("dar de baja la principal promueve otra", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		first := newAddress(profile.ProfileID, "Calle 1")
+		second := newAddress(profile.ProfileID, "Calle 2")
+		third := newAddress(profile.ProfileID, "Calle 3")
+		if err := store.DeleteAddress(ctx, second.AddressID.String(), false, profile.ProfileID, 0); err != nil {
+			t.Fatalf("DeleteAddress: %v", err)
+		}
+
+		if err := store.DeleteAddress(ctx, third.AddressID.String(), false, profile.ProfileID, 0); err != nil {
+			t.Fatalf("DeleteAddress: %v", err)
+		}
+		got, _ := store.GetAddressByID(ctx, first.AddressID)
+		if !got.MainAddress {
+			t.Fatalf("la dirección activa restante debe pasar a ser la principal: %+v", got)
+		}
+		if got, _ := store.GetAddressByID(ctx, third.AddressID); got.MainAddress || got.ActiveAddress {
+			t.Fatalf("la dirección dada de baja no puede seguir siendo principal: %+v", got)
+		}
+
+		if err := store.DeleteAddress(ctx, first.AddressID.String(), false, profile.ProfileID, 0); err != nil {
+			t.Fatalf("DeleteAddress: %v", err)
+		}
+		if got, err := store.GetAddress(ctx, profile.ProfileID); err != nil || got != nil {
+			t.Fatalf("sin direcciones activas no hay principal: (%v, %v)", got, err)
+		}
+
+		// Al reactivar una dirección en un perfil sin principal, pasa a serlo
+		if err := store.DeleteAddress(ctx, third.AddressID.String(), true, profile.ProfileID, 0); err != nil {
+			t.Fatalf("DeleteAddress: %v", err)
+		}
+		if got, _ := store.GetAddressByID(ctx, third.AddressID); !got.ActiveAddress || !got.MainAddress {
+			t.Fatalf("la dirección reactivada debe pasar a ser la principal: %+v", got)
+		}
+		if err := store.DeleteAddress(ctx, first.AddressID.String(), true, profile.ProfileID, 0); err != nil {
+			t.Fatalf("DeleteAddress: %v", err)
+		}
+		if got, _ := store.GetAddressByID(ctx, first.AddressID); !got.ActiveAddress || got.MainAddress {
+			t.Fatalf("si el perfil ya tiene principal, la reactivada no lo es: %+v", got)
+		}
+	})
+
+	t.Run("desmarcar la principal promueve otra", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		first := newAddress(profile.ProfileID, "Calle 1")
+		second := newAddress(profile.ProfileID, "Calle 2")
+		third := newAddress(profile.ProfileID, "Calle 3")
+
+		patch := domain.AddressPatch{MainAddress: domain.SetField(false)}
+		if err := store.PatchAddress(ctx, third.AddressID, profile.ProfileID, 0, patch, time.Now()); err != nil {
+			t.Fatalf("PatchAddress: %v", err)
+		}
+		if got, _ := store.GetAddress(ctx, profile.ProfileID); got == nil || got.AddressID != first.AddressID || !got.MainAddress {
+			t.Fatalf("PatchAddress debe promover la activa más antigua: %+v", got)
+		}
+
+		// Un PUT de v1 que no envía mainAddress la desmarca
+		update, _ := store.GetAddressByID(ctx, first.AddressID)
+		update.MainAddress = false
+		if err := store.UpdateAddressByID(ctx, update); err != nil {
+			t.Fatalf("UpdateAddressByID: %v", err)
+		}
+		if got, _ := store.GetAddress(ctx, profile.ProfileID); got == nil || got.AddressID != second.AddressID || !got.MainAddress {
+			t.Fatalf("UpdateAddressByID debe promover otra dirección activa: %+v", got)
+		}
+		if got, _ := store.GetAddressByID(ctx, first.AddressID); got.MainAddress {
+			t.Fatalf("la dirección desmarcada no puede seguir siendo principal: %+v", got)
+		}
+
+		alone := newTestProfile(t, ctx, profiles)
+		only := newAddress(alone.ProfileID, "Calle 4")
+		if err := store.PatchAddress(ctx, only.AddressID, alone.ProfileID, 0, patch, time.Now()); err != nil {
+			t.Fatalf("PatchAddress: %v", err)
+		}
+		if got, _ := store.GetAddressByID(ctx, only.AddressID); !got.MainAddress || got.Version != 2 {
+			t.Fatalf("la única dirección activa debe seguir siendo la principal: %+v", got)
+		}
+	})
+
+	t.Run("UpdateAddressByID no modifica una dirección dada de baja", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		inactive := newAddress(profile.ProfileID, "Calle 1")
+		main := newAddress(profile.ProfileID, "Calle 2")
+		if err := store.DeleteAddress(ctx, inactive.AddressID.String(), false, profile.ProfileID, 0); err != nil {
+			t.Fatalf("DeleteAddress: %v", err)
+		}
+
+		update, _ := store.GetAddressByID(ctx, inactive.AddressID)
+		update.Street, update.MainAddress, update.Version = "Calle 9", true, 0
+		if err := store.UpdateAddressByID(ctx, update); !errors.Is(err, ErrAddressNotFound) {
+			t.Fatalf("esperaba ErrAddressNotFound, obtuve %v", err)
+		}
+		if got, _ := store.GetAddress(ctx, profile.ProfileID); got == nil || got.AddressID != main.AddressID || !got.MainAddress {
+			t.Fatalf("la principal activa no debe cambiar: %+v", got)
+		}
+		if got, _ := store.GetAddressByID(ctx, inactive.AddressID); got.MainAddress || got.ActiveAddress || got.Street != "Calle 1" {
+			t.Fatalf("la dirección dada de baja no debe modificarse: %+v", got)
+		}
+	})
+
+	t.Run("DeleteAddress desactiva la dirección", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		created := newAddress(profile.ProfileID, "Av. Corrientes")
+
+		if err := store.DeleteAddress(ctx, created.AddressID.String(), false, profile.ProfileID, 0); err != nil {
+			t.Fatalf("DeleteAddress: %v", err)
+		}
+		if got, err := store.GetAddress(ctx, profile.ProfileID); err != nil || got != nil {
+			t.Fatalf("no debería haber dirección activa, obtuve (%v, %v)", got, err)
+		}
+
+		list, err := store.GetAddressesByProfile(ctx, profile.ProfileID)
+		if err != nil || len(list) != 1 || list[0].ActiveAddress {
+			t.Fatalf("la dirección debe seguir listada como inactiva: (%v, %v)", list, err)
+		}
+
+		if err := store.DeleteAddress(ctx, "no-es-uuid", false, profile.ProfileID, 0); err == nil {
+			t.Fatal("esperaba error con un addressId inválido")
+		}
+
+		other := newTestProfile(t, ctx, profiles)
+		foreign := newAddress(other.ProfileID, "Calle 1")
+		for _, id := range []uuid.UUID{uuid.New(), foreign.AddressID} {
+			if err := store.DeleteAddress(ctx, id.String(), false, profile.ProfileID, 0); !errors.Is(err, ErrAddressNotFound) {
+				t.Fatalf("esperaba ErrAddressNotFound para %s, obtuve %v", id, err)
+			}
+		}
+		if got, _ := store.GetAddressByID(ctx, foreign.AddressID); !got.ActiveAddress {
+			t.Fatalf("no debe modificar direcciones de otro perfil: %+v", got)
+		}
+	})
+
+	t.Run("DeleteAddress con versión vieja", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		created := newAddress(profile.ProfileID, "Av. Corrientes")
+		patch := domain.AddressPatch{Number: domain.SetField(2000)}
+		if err := store.PatchAddress(ctx, created.AddressID, profile.ProfileID, 0, patch, time.Now()); err != nil {
+			t.Fatalf("PatchAddress: %v", err)
+		}
+
+		if err := store.DeleteAddress(ctx, created.AddressID.String(), false, profile.ProfileID, 1); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("esperaba ErrVersionConflict, obtuve %v", err)
+		}
+		got, _ := store.GetAddressByID(ctx, created.AddressID)
+		if !got.ActiveAddress || !got.MainAddress || got.Version != 2 {
+			t.Fatalf("la dirección no debe cambiar con una versión vieja: %+v", got)
+		}
+		if err := store.DeleteAddress(ctx, created.AddressID.String(), false, profile.ProfileID, 2); err != nil {
+			t.Fatalf("DeleteAddress con la versión vigente: %v", err)
+		}
+	})
+
+	t.Run("GetAddressesByProfile filtra por perfil", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		other := newTestProfile(t, ctx, profiles)
+		newAddress(profile.ProfileID, "Calle 1")
+		newAddress(profile.ProfileID, "Calle 2")
+		newAddress(other.ProfileID, "Calle 3")
+
+		list, err := store.GetAddressesByProfile(ctx, profile.ProfileID)
+		if err != nil {
+			t.Fatalf("GetAddressesByProfile: %v", err)
+		}
+		if len(list) != 2 {
+			t.Fatalf("esperaba 2 direcciones, obtuve %d", len(list))
+		}
+		for _, a := range list {
+			if a.IdProfile != profile.ProfileID {
+				t.Fatalf("dirección de otro perfil: %+v", a)
+			}
+		}
+	})
+
+	t.Run("GetAddressByID y UpdateAddressByID", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		other := newTestProfile(t, ctx, profiles)
+		created := newAddress(profile.ProfileID, "Av. Corrientes")
+
+		if got, err := store.GetAddressByID(ctx, uuid.New()); err != nil || got != nil {
+			t.Fatalf("esperaba (nil, nil), obtuve (%v, %v)", got, err)
+		}
+		got, err := store.GetAddressByID(ctx, created.AddressID)
+		if err != nil || got == nil || got.Street != created.Street || got.IdProfile != profile.ProfileID {
+			t.Fatalf("GetAddressByID: (%+v, %v)", got, err)
+		}
+
+		update := *got
+		update.Street = "Av. Santa Fe"
+		update.UpdatedDate = time.Now()
+		if err := store.UpdateAddressByID(ctx, &update); err != nil {
+			t.Fatalf("UpdateAddressByID: %v", err)
+		}
+		if got, _ := store.GetAddressByID(ctx, created.AddressID); got.Street != "Av. Santa Fe" {
+			t.Fatalf("dirección no actualizada: %+v", got)
+		}
+
+		update.IdProfile = other.ProfileID
+		if err := store.UpdateAddressByID(ctx, &update); !errors.Is(err, ErrAddressNotFound) {
+			t.Fatalf("esperaba ErrAddressNotFound al actualizar desde otro perfil, obtuve %v", err)
+		}
+	})
+
+	t.Run("PatchAddress sólo cambia los campos enviados", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		other := newTestProfile(t, ctx, profiles)
+		created := newAddress(profile.ProfileID, "Av. Corrientes")
+
+		patch := domain.AddressPatch{
+			Number: domain.SetField(1500),
+			Floor:  domain.Field[string]{Set: true, Null: true},
+		}
+		if err := store.PatchAddress(ctx, created.AddressID, profile.ProfileID, 0, patch, time.Now()); err != nil {
+			t.Fatalf("PatchAddress: %v", err)
+		}
+		got, _ := store.GetAddressByID(ctx, created.AddressID)
+		if got.Number != 1500 || got.Floor != nil {
+			t.Fatalf("patch no aplicado: %+v", got)
+		}
+		if got.Street != created.Street || got.CP != created.CP || got.MainAddress != created.MainAddress {
+			t.Fatalf("campos no enviados modificados: %+v", got)
+		}
+
+		if err := store.PatchAddress(ctx, created.AddressID, other.ProfileID, 0, patch, time.Now()); !errors.Is(err, ErrAddressNotFound) {
+			t.Fatalf("esperaba ErrAddressNotFound al modificar desde otro perfil, obtuve %v", err)
+		}
+	})
+
+	t.Run("Version de direcciones", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		created := newAddress(profile.ProfileID, "Av. Corrientes")
+		if created.Version != 1 {
+			t.Fatalf("la versión inicial debe ser 1, obtuve %d", created.Version)
+		}
+
+		patch := domain.AddressPatch{Number: domain.SetField(2000)}
+		if err := store.PatchAddress(ctx, created.AddressID, profile.ProfileID, 1, patch, time.Now()); err != nil {
+			t.Fatalf("PatchAddress con la versión vigente: %v", err)
+		}
+		if err := store.PatchAddress(ctx, created.AddressID, profile.ProfileID, 1, patch, time.Now()); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("esperaba ErrVersionConflict, obtuve %v", err)
+		}
+
+		got, _ := store.GetAddressByID(ctx, created.AddressID)
+		if got.Version != 2 {
+			t.Fatalf("esperaba versión 2, obtuve %d", got.Version)
+		}
+		stale := *got
+		stale.Version = 1
+		if err := store.UpdateAddressByID(ctx, &stale); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("esperaba ErrVersionConflict en UpdateAddressByID, obtuve %v", err)
+		}
+
+		if err := store.DeleteAddress(ctx, created.AddressID.String(), false, profile.ProfileID, 0); err != nil {
+			t.Fatalf("DeleteAddress: %v", err)
+		}
+		list, _ := store.GetAddressesByProfile(ctx, profile.ProfileID)
+		if len(list) != 1 || list[0].Version != 3 {
+			t.Fatalf("la baja debe incrementar la versión: %+v", list)
+		}
+	})
+
+	t.Run("SetGeocode, PendingGeocodes y ResetGeocodes", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		created := newAddress(profile.ProfileID, "Av. Santa Fe")
+
+		pending := func() bool {
+			list, err := store.PendingGeocodes(ctx, 10000)
+			if err != nil {
+				t.Fatalf("PendingGeocodes: %v", err)
+			}
+			for _, a := range list {
+				if a.AddressID == created.AddressID {
+					return true
+				}
+			}
+			return false
+		}
+
+		got, _ := store.GetAddressByID(ctx, created.AddressID)
+		if got.GeocodeQuality != domain.GeocodePending || got.Latitude != nil || !pending() {
+			t.Fatalf("una dirección nueva debe quedar pendiente: %+v", got)
+		}
+
+		geocode := domain.Geocode{Latitude: -34.5889, Longitude: -58.4108, Quality: domain.GeocodeExact}
+		if err := store.SetGeocode(ctx, created.AddressID, created.Version+1, geocode); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("esperaba ErrVersionConflict, obtuve %v", err)
+		}
+		if err := store.SetGeocode(ctx, uuid.New(), 0, geocode); !errors.Is(err, ErrAddressNotFound) {
+			t.Fatalf("esperaba ErrAddressNotFound, obtuve %v", err)
+		}
+		if err := store.SetGeocode(ctx, created.AddressID, created.Version, geocode); err != nil {
+			t.Fatalf("SetGeocode: %v", err)
+		}
+
+		got, _ = store.GetAddressByID(ctx, created.AddressID)
+		if got.GeocodeQuality != domain.GeocodeExact || got.Latitude == nil || *got.Latitude != geocode.Latitude ||
+			got.Longitude == nil || *got.Longitude != geocode.Longitude {
+			t.Fatalf("coordenadas no guardadas: %+v", got)
+		}
+		if got.Version != created.Version {
+			t.Fatalf("SetGeocode no debe cambiar la versión: %d", got.Version)
+		}
+		if pending() {
+			t.Fatal("una dirección geocodificada no debe quedar pendiente")
+		}
+
+		n, err := store.ResetGeocodes(ctx)
+		if err != nil || n < 1 {
+			t.Fatalf("ResetGeocodes: (%d, %v)", n, err)
+		}
+		got, _ = store.GetAddressByID(ctx, created.AddressID)
+		if got.GeocodeQuality != domain.GeocodePending || got.Latitude != nil || !pending() {
+			t.Fatalf("ResetGeocodes debe dejar la dirección pendiente: %+v", got)
+		}
+	})
+
+	t.Run("NearbyAddresses y NearestAddress", func(t *testing.T) {
+		// Un punto al azar en el Atlántico sur, para no encontrar direcciones de otras pruebas.
+		lat, lon := -50+rand.Float64(), -30+rand.Float64()
+		profile := newTestProfile(t, ctx, profiles)
+		other := newTestProfile(t, ctx, profiles)
+
+		located := func(profileID uuid.UUID, street string, dLat, dLon float64) *domain.Address {
+			address := newAddress(profileID, street)
+			geocode := domain.Geocode{Latitude: lat + dLat, Longitude: lon + dLon, Quality: domain.GeocodeExact}
+			if err := store.SetGeocode(ctx, address.AddressID, address.Version, geocode); err != nil {
+				t.Fatalf("SetGeocode: %v", err)
+			}
+			return address
+		}
+		center := located(profile.ProfileID, "Centro", 0, 0)
+		near := located(other.ProfileID, "Cerca", 0.01, 0) // ~1,1 km
+		far := located(profile.ProfileID, "Lejos", 0, 0.1) // ~7,2 km
+		located(other.ProfileID, "Fuera", 0.5, 0)          // ~55 km
+		newAddress(profile.ProfileID, "Sin coordenadas")
+		inactive := located(profile.ProfileID, "De baja", 0, 0)
+		if err := store.DeleteAddress(ctx, inactive.AddressID.String(), false, profile.ProfileID, 0); err != nil {
+			t.Fatalf("DeleteAddress: %v", err)
+		}
+
+		got, err := store.NearbyAddresses(ctx, domain.NearbyFilter{Latitude: lat, Longitude: lon, RadiusKm: 10, Limit: 10})
+		if err != nil {
+			t.Fatalf("NearbyAddresses: %v", err)
+		}
+		if len(got) != 3 || got[0].AddressID != center.AddressID || got[1].AddressID != near.AddressID || got[2].AddressID != far.AddressID {
+			t.Fatalf("esperaba centro, cerca y lejos en ese orden: %+v", got)
+		}
+		if got[0].DistanceKm > 0.001 || got[1].DistanceKm < 1.0 || got[1].DistanceKm > 1.2 || got[2].DistanceKm < 7.0 || got[2].DistanceKm > 7.3 {
+			t.Fatalf("distancias incorrectas: %v, %v, %v", got[0].DistanceKm, got[1].DistanceKm, got[2].DistanceKm)
+		}
+
+		got, _ = store.NearbyAddresses(ctx, domain.NearbyFilter{Latitude: lat, Longitude: lon, RadiusKm: 5, Limit: 1})
+		if len(got) != 1 || got[0].AddressID != center.AddressID {
+			t.Fatalf("el radio y el límite deben aplicarse: %+v", got)
+		}
+
+		nearest, err := store.NearestAddress(ctx, profile.ProfileID, lat+0.01, lon+0.09)
+		if err != nil || nearest == nil || nearest.AddressID != far.AddressID {
+			t.Fatalf("esperaba la dirección lejana del perfil, obtuve (%+v, %v)", nearest, err)
+		}
+		if nearest.DistanceKm < 1.1 || nearest.DistanceKm > 1.6 {
+			t.Fatalf("distancia incorrecta: %v", nearest.DistanceKm)
+		}
+		nearest, err = store.NearestAddress(ctx, newTestProfile(t, ctx, profiles).ProfileID, lat, lon)
+		if err != nil || nearest != nil {
+			t.Fatalf("un perfil sin direcciones debe devolver (nil, nil), obtuve (%+v, %v)", nearest, err)
+		}
+	})
+
+	t.Run("AddressHistory y AddressAsOf", func(t *testing.T) {
+		profile := newTestProfile(t, ctx, profiles)
+		first := newAddress(profile.ProfileID, "Av. Corrientes")
+		second := newAddress(profile.ProfileID, "Av. Santa Fe") // first deja de ser la principal
+
+		actorCtx := domain.WithActor(ctx, "auditor-1")
+		patch := domain.AddressPatch{Street: domain.SetField("Av. Córdoba")}
+		if err := store.PatchAddress(actorCtx, first.AddressID, profile.ProfileID, 0, patch, time.Now()); err != nil {
+			t.Fatalf("PatchAddress: %v", err)
+		}
+		geocode := domain.Geocode{Latitude: -34.6, Longitude: -58.4, Quality: domain.GeocodeLocality}
+		if err := store.SetGeocode(ctx, first.AddressID, 3, geocode); err != nil {
+			t.Fatalf("SetGeocode: %v", err)
+		}
+		if err := store.SetMainAddress(actorCtx, first.AddressID, profile.ProfileID, 0); err != nil {
+			t.Fatalf("SetMainAddress: %v", err)
+		}
+		if err := store.DeleteAddress(actorCtx, first.AddressID.String(), false, profile.ProfileID, 0); err != nil {
+			t.Fatalf("DeleteAddress: %v", err)
+		}
+		if err := store.DeleteAddress(actorCtx, first.AddressID.String(), true, profile.ProfileID, 0); err != nil {
+			t.Fatalf("DeleteAddress (reactivar): %v", err)
+		}
+
+		history, err := store.AddressHistory(ctx, first.AddressID)
+		if err != nil {
+			t.Fatalf("AddressHistory: %v", err)
+		}
+		want := []struct {
+			operation domain.AddressOperation
+			changedBy string
+			street    string
+			main      bool
+			active    bool
+		}{
+			{domain.AddressCreated, "", "Av. Corrientes", true, true},
+			{domain.AddressUpdated, "", "Av. Corrientes", false, true},
+			{domain.AddressUpdated, "auditor-1", "Av. Córdoba", false, true},
+			{domain.AddressUpdated, "auditor-1", "Av. Córdoba", true, true},
+			{domain.AddressDeactivated, "auditor-1", "Av. Córdoba", false, false},
+			{domain.AddressReactivated, "auditor-1", "Av. Córdoba", false, true},
+		}
+		if len(history) != len(want) {
+			t.Fatalf("esperaba %d revisiones (SetGeocode no genera una), obtuve %+v", len(want), history)
+		}
+		for i, w := range want {
+			rev := history[i]
+			if rev.Version != i+1 || rev.Address.Version != i+1 || rev.Operation != w.operation || rev.ChangedBy != w.changedBy ||
+				rev.Address.Street != w.street || rev.Address.MainAddress != w.main || rev.Address.ActiveAddress != w.active ||
+				rev.Address.AddressID != first.AddressID || rev.Address.IdProfile != profile.ProfileID || rev.Address.Latitude != nil {
+				t.Fatalf("revisión %d: esperaba %+v, obtuve %+v", i+1, w, rev)
+			}
+			if i > 0 && rev.ChangedAt.Before(history[i-1].ChangedAt) {
+				t.Fatalf("las revisiones deben estar en orden: %v antes de %v", rev.ChangedAt, history[i-1].ChangedAt)
+			}
+		}
+
+		// second: creación, promoción al dar de baja first y pérdida de la principal con SetMainAddress.
+		secondHistory, _ := store.AddressHistory(ctx, second.AddressID)
+		current, _ := store.GetAddressByID(ctx, second.AddressID)
+		if len(secondHistory) != current.Version || secondHistory[len(secondHistory)-1].Address.MainAddress != current.MainAddress {
+			t.Fatalf("el historial debe incluir los cambios de principal: %+v (vigente %+v)", secondHistory, current)
+		}
+
+		got, err := store.AddressAsOf(ctx, first.AddressID, history[0].ChangedAt.Add(-time.Second))
+		if err != nil || got != nil {
+			t.Fatalf("antes de crearla esperaba (nil, nil), obtuve (%+v, %v)", got, err)
+		}
+		for _, rev := range history {
+			expected := rev
+			for _, later := range history {
+				if !later.ChangedAt.After(rev.ChangedAt) {
+					expected = later
+				}
+			}
+			got, err := store.AddressAsOf(ctx, first.AddressID, rev.ChangedAt)
+			if err != nil || got == nil || got.Version != expected.Version || got.Address.Street != expected.Address.Street {
+				t.Fatalf("AddressAsOf(%v): esperaba la versión %d, obtuve (%+v, %v)", rev.ChangedAt, expected.Version, got, err)
+			}
+		}
+		got, _ = store.AddressAsOf(ctx, first.AddressID, time.Now().Add(time.Hour))
+		if got == nil || got.Version != len(history) {
+			t.Fatalf("esperaba la última versión, obtuve %+v", got)
+		}
+		if history, _ := store.AddressHistory(ctx, uuid.New()); len(history) != 0 {
+			t.Fatalf("una dirección inexistente no tiene historial: %+v", history)
+		}
+	})
+}
+
+// testLocalityStore verifica la búsqueda en el catálogo de localidades. Reemplaza el catálogo
+// completo, por lo que corre sobre un store propio.
+func testLocalityStore(t *testing.T, store LocalityStore) {
+	ctx := context.Background()
+	catalog := []domain.Locality{
+		{Province: "AR-X", Name: "Córdoba", CP: "5000"},
+		{Province: "AR-X", Name: "Villa Allende", CP: "5105"},
+		{Province: "AR-X", Name: "Villa Carlos Paz", CP: "5152"},
+		{Province: "AR-C", Name: "Palermo", CP: "1425"},
+		{Province: "AR-C", Name: "Palermo", CP: "1414"},
+		{Province: "AR-C", Name: "PALERMO", CP: "1425"}, // repetida: se carga una vez
+		{Province: "AR-B", Name: "San Carlos de Bolívar", CP: "6550"},
+	}
+	if err := store.ReplaceLocalities(ctx, catalog); err != nil {
+		t.Fatalf("ReplaceLocalities: %v", err)
+	}
+
+	names := func(localities []domain.Locality) []string {
+		var result []string
+		for _, l := range localities {
+			result = append(result, l.Name+" "+l.CP)
+		}
+		return result
+	}
+	search := func(filter domain.LocalityFilter) ([]string, int) {
+		t.Helper()
+		if filter.Limit == 0 {
+			filter.Limit = 10
+		}
+		got, total, err := store.SearchLocalities(ctx, filter)
+		if err != nil {
+			t.Fatalf("SearchLocalities(%+v): %v", filter, err)
+		}
+		return names(got), total
+	}
+
+	t.Run("búsqueda por prefijo de palabra", func(t *testing.T) {
+		got, total := search(domain.LocalityFilter{Query: "carlos"})
+		if total != 2 || fmt.Sprint(got) != "[San Carlos de Bolívar 6550 Villa Carlos Paz 5152]" {
+			t.Fatalf("resultado inesperado: %v (total %d)", got, total)
+		}
+		got, _ = search(domain.LocalityFilter{Query: "villa"})
+		if fmt.Sprint(got) != "[Villa Allende 5105 Villa Carlos Paz 5152]" {
+			t.Fatalf("resultado inesperado: %v", got)
+		}
+	})
+
+	t.Run("primero las que empiezan con la búsqueda", func(t *testing.T) {
+		got, _ := search(domain.LocalityFilter{Query: "c"})
+		if fmt.Sprint(got) != "[Córdoba 5000 San Carlos de Bolívar 6550 Villa Carlos Paz 5152]" {
+			t.Fatalf("resultado inesperado: %v", got)
+		}
+	})
+
+	t.Run("filtros por código postal y provincia", func(t *testing.T) {
+		got, total := search(domain.LocalityFilter{CP: "14"})
+		if total != 2 || fmt.Sprint(got) != "[Palermo 1414 Palermo 1425]" {
+			t.Fatalf("resultado inesperado: %v (total %d)", got, total)
+		}
+		got, _ = search(domain.LocalityFilter{Province: "AR-X", CP: "51"})
+		if fmt.Sprint(got) != "[Villa Allende 5105 Villa Carlos Paz 5152]" {
+			t.Fatalf("resultado inesperado: %v", got)
+		}
+	})
+
+	t.Run("paginación", func(t *testing.T) {
+		got, total := search(domain.LocalityFilter{Province: "AR-X", Limit: 2, Offset: 1})
+		if total != 3 || fmt.Sprint(got) != "[Villa Allende 5105 Villa Carlos Paz 5152]" {
+			t.Fatalf("resultado inesperado: %v (total %d)", got, total)
+		}
+		got, total = search(domain.LocalityFilter{Province: "AR-X", Offset: 5})
+		if total != 3 || len(got) != 0 {
+			t.Fatalf("esperaba página vacía con total 3: %v (total %d)", got, total)
+		}
+	})
+
+	t.Run("FindLocality compara el nombre normalizado", func(t *testing.T) {
+		got, err := store.FindLocality(ctx, "AR-X", "5000", "cordoba")
+		if err != nil || got == nil || got.Name != "Córdoba" {
+			t.Fatalf("FindLocality: (%+v, %v)", got, err)
+		}
+		if got, err := store.FindLocality(ctx, "AR-X", "5105", "Córdoba"); err != nil || got != nil {
+			t.Fatalf("esperaba (nil, nil) con otro código postal, obtuve (%+v, %v)", got, err)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"profilego/internal/domain"
+	"profilego/internal/geo"
+	"profilego/pkg/tracing"
+)
+
+// LocalityRepository es el catálogo de localidades en PostgreSQL.
+type LocalityRepository struct {
+	DB *sql.DB
+}
+
+// NewLocalityRepository crea una nueva instancia del repositorio.
+func NewLocalityRepository(db *sql.DB) *LocalityRepository {
+	return &LocalityRepository{DB: db}
+}
+
+// ReplaceLocalities reemplaza todo el catálogo en una transacción. Las filas repetidas
+// (misma provincia, código postal y nombre normalizado) se cargan una sola vez.
+func (r *LocalityRepository) ReplaceLocalities(ctx context.Context, localities []domain.Locality) (err error) {
+	query := `INSERT INTO locality (province, name, searchKey, cp) VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING`
+	ctx, span := startSpan(ctx, "LocalityRepository.ReplaceLocalities", query)
+	defer tracing.End(span, &err)
+
+	return inTx(ctx, r.DB, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM locality`); err != nil {
+			return err
+		}
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, l := range localities {
+			if _, err := stmt.ExecContext(ctx, l.Province, l.Name, geo.SearchKey(l.Name), l.CP); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SearchLocalities busca en el catálogo. Primero van las localidades cuyo nombre empieza con
+// la búsqueda y después las que tienen otra palabra que empieza con ella; devuelve la página
+// pedida y el total de resultados.
+func (r *LocalityRepository) SearchLocalities(ctx context.Context, filter domain.LocalityFilter) (_ []domain.Locality, _ int, err error) {
+	var where []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if filter.Province != "" {
+		where = append(where, "province = "+arg(filter.Province))
+	}
+	if filter.CP != "" {
+		where = append(where, "cp LIKE "+arg(filter.CP)+" || '%'")
+	}
+	prefix := "TRUE"
+	if filter.Query != "" {
+		q := arg(filter.Query)
+		prefix = "searchKey LIKE " + q + " || '%'"
+		where = append(where, "("+prefix+" OR searchKey LIKE '% ' || "+q+" || '%')")
+	}
+	cond := "TRUE"
+	if len(where) > 0 {
+		cond = strings.Join(where, " AND ")
+	}
+
+	query := fmt.Sprintf(`SELECT province, name, cp FROM locality WHERE %s
+			ORDER BY %s DESC, searchKey COLLATE "C", cp, province LIMIT %s OFFSET %s`,
+		cond, prefix, arg(filter.Limit), arg(filter.Offset))
+	ctx, span := startSpan(ctx, "LocalityRepository.SearchLocalities", query)
+	defer tracing.End(span, &err)
+
+	var total int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM locality WHERE "+cond, args[:len(args)-2]...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	localities := []domain.Locality{}
+	for rows.Next() {
+		var l domain.Locality
+		if err := rows.Scan(&l.Province, &l.Name, &l.CP); err != nil {
+			return nil, 0, err
+		}
+		localities = append(localities, l)
+	}
+	return localities, total, rows.Err()
+}
+
+// FindLocality busca la localidad de la provincia con ese código postal y nombre (comparado
+// con geo.SearchKey); nil si no está en el catálogo.
+func (r *LocalityRepository) FindLocality(ctx context.Context, province, cp, name string) (_ *domain.Locality, err error) {
+	query := `SELECT province, name, cp FROM locality WHERE province = $1 AND cp = $2 AND searchKey = $3`
+	ctx, span := startSpan(ctx, "LocalityRepository.FindLocality", query)
+	defer tracing.End(span, &err)
+
+	var l domain.Locality
+	err = r.DB.QueryRowContext(ctx, query, province, cp, geo.SearchKey(name)).Scan(&l.Province, &l.Name, &l.CP)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
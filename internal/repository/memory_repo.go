@@ -0,0 +1,733 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"profilego/internal/domain"
+	"profilego/internal/geo"
+
+	"github.com/google/uuid"
+)
+
+// MemoryProfileRepository es una implementación en memoria de ProfileStore,
+// pensada para tests y desarrollo local. Replica la semántica de ProfileRepository.
+type MemoryProfileRepository struct {
+	mu       sync.RWMutex
+	profiles map[uuid.UUID]*domain.Profile
+}
+
+// NewMemoryProfileRepository crea un repositorio de perfiles vacío.
+func NewMemoryProfileRepository() *MemoryProfileRepository {
+	return &MemoryProfileRepository{profiles: make(map[uuid.UUID]*domain.Profile)}
+}
+
+// byUserID busca el perfil de un usuario. Debe llamarse con el lock tomado.
+func (r *MemoryProfileRepository) byUserID(userId string) *domain.Profile {
+	for _, p := range r.profiles {
+		if p.UserID == userId {
+			return p
+		}
+	}
+	return nil
+}
+
+func (r *MemoryProfileRepository) CreateProfile(ctx context.Context, profile *domain.Profile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.profiles[profile.ProfileID]; exists {
+		return fmt.Errorf("%w: profileId %s", ErrProfileExists, profile.ProfileID)
+	}
+	if r.byUserID(profile.UserID) != nil {
+		return ErrProfileExists
+	}
+
+	// Igual que el INSERT de PostgreSQL, sólo se persisten los datos básicos
+	profile.Version = 1
+	r.profiles[profile.ProfileID] = &domain.Profile{
+		ProfileID:    profile.ProfileID,
+		UserID:       profile.UserID,
+		ProfileName:  profile.ProfileName,
+		ProfileMail:  profile.ProfileMail,
+		Phone:        profile.Phone,
+		CreationDate: profile.CreationDate,
+		UpdatedDate:  profile.UpdatedDate,
+		Version:      profile.Version,
+	}
+	return nil
+}
+
+func (r *MemoryProfileRepository) GetProfile(ctx context.Context, userId string) (*domain.Profile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p := r.byUserID(userId)
+	if p == nil {
+		return nil, nil
+	}
+	profile := *p
+	profile.ProfileImage = copyString(p.ProfileImage)
+	profile.IIBB = copyIIBB(p.IIBB)
+	return &profile, nil
+}
+
+func (r *MemoryProfileRepository) GetByUserID(ctx context.Context, userId string) (*domain.Profile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return summary(r.byUserID(userId)), nil
+}
+
+func (r *MemoryProfileRepository) GetByProfileID(ctx context.Context, profileID uuid.UUID) (*domain.Profile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return summary(r.profiles[profileID]), nil
+}
+
+// summary copia los campos que devuelven GetByUserID y GetByProfileID en PostgreSQL.
+func summary(p *domain.Profile) *domain.Profile {
+	if p == nil {
+		return nil
+	}
+	return &domain.Profile{
+		ProfileID:     p.ProfileID,
+		UserID:        p.UserID,
+		ProfileName:   p.ProfileName,
+		ProfileMail:   p.ProfileMail,
+		Phone:         p.Phone,
+		ProfilePoints: p.ProfilePoints,
+		ProfileLevel:  p.ProfileLevel,
+		Version:       p.Version,
+	}
+}
+
+// checkVersion valida la versión esperada de una actualización condicionada (0 = sin condición).
+func checkVersion(current, expected int) error {
+	if expected != 0 && current != expected {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *MemoryProfileRepository) UpdateProfile(ctx context.Context, userId string, profile *domain.Profile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.profiles[profile.ProfileID]
+	if !ok || p.UserID != userId {
+		return fmt.Errorf("%w: profileId %s", ErrUserNotFound, profile.ProfileID)
+	}
+	p.ProfileName = profile.ProfileName
+	p.ProfileMail = profile.ProfileMail
+	p.Phone = profile.Phone
+	p.UpdatedDate = profile.UpdatedDate
+	p.Version++
+	return nil
+}
+
+func (r *MemoryProfileRepository) PatchProfile(ctx context.Context, profileID uuid.UUID, version int, patch domain.ProfilePatch, updatedDate time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.profiles[profileID]
+	if !ok {
+		return ErrUserNotFound
+	}
+	if err := checkVersion(p.Version, version); err != nil {
+		return err
+	}
+	domain.ApplyField(&p.ProfileName, patch.ProfileName)
+	domain.ApplyField(&p.ProfileMail, patch.ProfileMail)
+	domain.ApplyField(&p.Phone, patch.Phone)
+	domain.ApplyNullable(&p.ProfileImage, patch.ProfileImage)
+	p.UpdatedDate = updatedDate
+	p.Version++
+	return nil
+}
+
+func (r *MemoryProfileRepository) UpdateFiscalData(ctx context.Context, userid string, profile *domain.Profile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.profiles[profile.ProfileID]
+	if !ok {
+		return ErrUserNotFound
+	}
+	if err := checkVersion(p.Version, profile.Version); err != nil {
+		return err
+	}
+	p.CUIL = profile.CUIL
+	p.FiscalAdress = profile.FiscalAdress
+	p.FiscalCondition = profile.FiscalCondition
+	p.MonotributoCategory = profile.MonotributoCategory
+	p.IIBB = copyIIBB(profile.IIBB)
+	p.UpdatedDate = profile.UpdatedDate
+	p.Version++
+	return nil
+}
+
+func (r *MemoryProfileRepository) UpdateProfileImage(ctx context.Context, userId, profileID, profileImage string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := r.byUserID(userId)
+	if p == nil || p.ProfileID.String() != profileID {
+		return ErrUserNotFound
+	}
+	p.ProfileImage = &profileImage
+	p.UpdatedDate = time.Now()
+	p.Version++
+	return nil
+}
+
+func (r *MemoryProfileRepository) UpdateProfilePoints(ctx context.Context, userId string, profile *domain.Profile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := r.byUserID(userId)
+	if p == nil {
+		return ErrUserNotFound
+	}
+	p.ProfilePoints += profile.ProfilePoints
+	p.Version++
+	return nil
+}
+
+func (r *MemoryProfileRepository) UpdateProfileLevel(ctx context.Context, userId string, profile *domain.Profile) error {
+	if profile.ProfilePoints > 1000 { // mismo reseteo de puntos que ProfileRepository
+		profile.ProfilePoints = 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := r.byUserID(userId)
+	if p == nil {
+		return ErrUserNotFound
+	}
+	p.ProfileLevel = profile.ProfileLevel
+	p.ProfilePoints = profile.ProfilePoints
+	p.Version++
+	return nil
+}
+
+func (r *MemoryProfileRepository) DeleteProfile(ctx context.Context, profileID uuid.UUID, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, ok := r.profiles[profileID]; ok {
+		if err := checkVersion(p.Version, version); err != nil {
+			return err
+		}
+	}
+	delete(r.profiles, profileID)
+	return nil
+}
+
+// MemoryAddressRepository es una implementación en memoria de AddressStore.
+// Las direcciones se guardan en orden de creación. Cada vez que una dirección cambia de versión
+// se agrega una revisión a su historial, como hace el trigger de PostgreSQL.
+type MemoryAddressRepository struct {
+	mu        sync.RWMutex
+	addresses []*domain.Address
+	history   map[uuid.UUID][]domain.AddressRevision
+}
+
+// NewMemoryAddressRepository crea un repositorio de direcciones vacío.
+func NewMemoryAddressRepository() *MemoryAddressRepository {
+	return &MemoryAddressRepository{history: make(map[uuid.UUID][]domain.AddressRevision)}
+}
+
+// record agrega la versión actual de la dirección a su historial. Debe llamarse con el lock
+// tomado, después de incrementar la versión.
+func (r *MemoryAddressRepository) record(ctx context.Context, a *domain.Address, operation domain.AddressOperation) {
+	snapshot := copyAddress(a)
+	snapshot.Latitude, snapshot.Longitude, snapshot.GeocodeQuality = nil, nil, ""
+	r.history[a.AddressID] = append(r.history[a.AddressID], domain.AddressRevision{
+		Version:   a.Version,
+		Operation: operation,
+		ChangedBy: domain.ActorFromContext(ctx),
+		ChangedAt: time.Now(),
+		Address:   snapshot,
+	})
+}
+
+func (r *MemoryAddressRepository) CreateAddress(ctx context.Context, address *domain.Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, a := range r.addresses {
+		if a.AddressID == address.AddressID {
+			return fmt.Errorf("ya existe una dirección con addressId: %s", address.AddressID)
+		}
+	}
+	if address.MainAddress && address.ActiveAddress {
+		r.clearMain(ctx, address.IdProfile, address.AddressID)
+	}
+	address.Version = 1
+	address.GeocodeQuality = geocodeQuality(address.GeocodeQuality)
+	stored := copyAddress(address)
+	r.addresses = append(r.addresses, &stored)
+	r.record(ctx, &stored, domain.AddressCreated)
+	return nil
+}
+
+// clearMain desmarca la dirección principal del perfil, salvo except. Debe llamarse con el lock tomado.
+func (r *MemoryAddressRepository) clearMain(ctx context.Context, idProfile, except uuid.UUID) {
+	for _, a := range r.addresses {
+		if a.IdProfile == idProfile && a.MainAddress && a.AddressID != except {
+			a.MainAddress = false
+			a.Version++
+			r.record(ctx, a, domain.AddressUpdated)
+		}
+	}
+}
+
+func (r *MemoryAddressRepository) GetAddress(ctx context.Context, idProfile uuid.UUID) (*domain.Address, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *domain.Address
+	for _, a := range r.addresses {
+		if a.IdProfile == idProfile && a.ActiveAddress && (found == nil || a.MainAddress && !found.MainAddress) {
+			found = a
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+	address := copyAddress(found)
+	return &address, nil
+}
+
+func (r *MemoryAddressRepository) GetAddressByID(ctx context.Context, addressID uuid.UUID) (*domain.Address, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, a := range r.addresses {
+		if a.AddressID == addressID {
+			address := copyAddress(a)
+			return &address, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryAddressRepository) UpdateAddressByID(ctx context.Context, address *domain.Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, a := range r.addresses {
+		if a.AddressID == address.AddressID && a.IdProfile == address.IdProfile {
+			if !a.ActiveAddress {
+				return ErrAddressNotFound
+			}
+			if err := checkVersion(a.Version, address.Version); err != nil {
+				return err
+			}
+			if address.MainAddress {
+				r.clearMain(ctx, a.IdProfile, a.AddressID)
+			}
+			keepMain := r.keepsMain(a)
+			a.CP = address.CP
+			a.Street = address.Street
+			a.Number = address.Number
+			a.Floor = copyString(address.Floor)
+			a.Apartment = copyString(address.Apartment)
+			a.Locality = address.Locality
+			a.Province = address.Province
+			a.Country = address.Country
+			a.BetweenStreets = copyString(address.BetweenStreets)
+			a.References = copyString(address.References)
+			a.MainAddress = address.MainAddress || keepMain
+			a.Latitude = copyFloat(address.Latitude)
+			a.Longitude = copyFloat(address.Longitude)
+			a.GeocodeQuality = geocodeQuality(address.GeocodeQuality)
+			a.UpdatedDate = address.UpdatedDate
+			a.Version++
+			r.record(ctx, a, domain.AddressUpdated)
+			if !a.MainAddress {
+				r.promoteMain(ctx, a.IdProfile, a.AddressID)
+			}
+			return nil
+		}
+	}
+	return ErrAddressNotFound
+}
+
+func (r *MemoryAddressRepository) PatchAddress(ctx context.Context, addressID, idProfile uuid.UUID, version int, patch domain.AddressPatch, updatedDate time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, a := range r.addresses {
+		if a.AddressID == addressID && a.IdProfile == idProfile {
+			if err := checkVersion(a.Version, version); err != nil {
+				return err
+			}
+			if patch.MainAddress.Set && patch.MainAddress.Value {
+				r.clearMain(ctx, a.IdProfile, a.AddressID)
+			}
+			demoted := patch.MainAddress.Set && !patch.MainAddress.Value
+			keepMain := demoted && r.keepsMain(a)
+			patch.ApplyTo(a)
+			a.MainAddress = a.MainAddress || keepMain
+			a.UpdatedDate = updatedDate
+			a.Version++
+			r.record(ctx, a, domain.AddressUpdated)
+			if demoted {
+				r.promoteMain(ctx, a.IdProfile, a.AddressID)
+			}
+			return nil
+		}
+	}
+	return ErrAddressNotFound
+}
+
+func (r *MemoryAddressRepository) SetMainAddress(ctx context.Context, addressID, idProfile uuid.UUID, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, a := range r.addresses {
+		if a.AddressID == addressID && a.IdProfile == idProfile {
+			if err := checkVersion(a.Version, version); err != nil {
+				return err
+			}
+			if !a.ActiveAddress {
+				return ErrVersionConflict // igual que PostgreSQL: la condición del UPDATE no se cumple
+			}
+			r.clearMain(ctx, idProfile, addressID)
+			a.MainAddress = true
+			a.Version++
+			r.record(ctx, a, domain.AddressUpdated)
+			return nil
+		}
+	}
+	return ErrAddressNotFound
+}
+
+func (r *MemoryAddressRepository) DeleteAddress(ctx context.Context, addressId string, activeAddress bool, idprofile uuid.UUID, version int) error {
+	id, err := uuid.Parse(addressId)
+	if err != nil {
+		return fmt.Errorf("addressId inválido: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, a := range r.addresses {
+		if a.AddressID == id && a.IdProfile == idprofile {
+			if err := checkVersion(a.Version, version); err != nil {
+				return err
+			}
+			wasMain, wasActive := a.MainAddress, a.ActiveAddress
+			a.ActiveAddress = activeAddress
+			a.MainAddress = a.MainAddress && activeAddress
+			a.Version++
+			operation := domain.AddressUpdated
+			switch {
+			case wasActive && !activeAddress:
+				operation = domain.AddressDeactivated
+			case !wasActive && activeAddress:
+				operation = domain.AddressReactivated
+			}
+			r.record(ctx, a, operation)
+			if wasMain || activeAddress {
+				r.promoteMain(ctx, idprofile, uuid.Nil)
+			}
+			return nil
+		}
+	}
+	return ErrAddressNotFound
+}
+
+// keepsMain indica si a, siendo la principal, debe seguir siéndolo aunque se la desmarque
+// porque no hay otra dirección activa en el perfil. Debe llamarse con el lock tomado.
+func (r *MemoryAddressRepository) keepsMain(a *domain.Address) bool {
+	if !a.MainAddress || !a.ActiveAddress {
+		return false
+	}
+	for _, other := range r.addresses {
+		if other.IdProfile == a.IdProfile && other.ActiveAddress && other.AddressID != a.AddressID {
+			return false
+		}
+	}
+	return true
+}
+
+// promoteMain marca como principal la dirección activa más antigua del perfil (salvo except)
+// si el perfil quedó sin dirección principal. Debe llamarse con el lock tomado.
+func (r *MemoryAddressRepository) promoteMain(ctx context.Context, idProfile, except uuid.UUID) {
+	for _, a := range r.addresses {
+		if a.IdProfile == idProfile && a.MainAddress {
+			return
+		}
+	}
+	for _, a := range r.addresses {
+		if a.IdProfile == idProfile && a.ActiveAddress && a.AddressID != except {
+			a.MainAddress = true
+			a.Version++
+			r.record(ctx, a, domain.AddressUpdated)
+			return
+		}
+	}
+}
+
+func (r *MemoryAddressRepository) GetAddressesByProfile(ctx context.Context, IdProfile uuid.UUID) ([]domain.Address, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var addresses []domain.Address
+	for _, a := range r.addresses {
+		if a.IdProfile == IdProfile {
+			addresses = append(addresses, copyAddress(a))
+		}
+	}
+	return addresses, nil
+}
+
+func (r *MemoryAddressRepository) SetGeocode(ctx context.Context, addressID uuid.UUID, version int, geocode domain.Geocode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, a := range r.addresses {
+		if a.AddressID == addressID {
+			if a.Version != version {
+				return ErrVersionConflict
+			}
+			a.ApplyGeocode(geocode)
+			return nil
+		}
+	}
+	return ErrAddressNotFound
+}
+
+func (r *MemoryAddressRepository) PendingGeocodes(ctx context.Context, limit int) ([]domain.Address, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var pending []domain.Address
+	for _, a := range r.addresses {
+		if a.ActiveAddress && a.GeocodeQuality == domain.GeocodePending {
+			pending = append(pending, copyAddress(a))
+		}
+	}
+	slices.SortFunc(pending, func(a, b domain.Address) int {
+		return cmp.Or(a.UpdatedDate.Compare(b.UpdatedDate), strings.Compare(a.AddressID.String(), b.AddressID.String()))
+	})
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
+
+func (r *MemoryAddressRepository) ResetGeocodes(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, a := range r.addresses {
+		if a.ActiveAddress {
+			a.ResetGeocode()
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *MemoryAddressRepository) NearbyAddresses(ctx context.Context, filter domain.NearbyFilter) ([]domain.AddressDistance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	box := geo.BoundingBox(filter.Latitude, filter.Longitude, filter.RadiusKm)
+	var nearby []domain.AddressDistance
+	for _, a := range r.addresses {
+		if !a.ActiveAddress || a.Latitude == nil || !box.Contains(*a.Latitude, *a.Longitude) {
+			continue
+		}
+		distance := geo.DistanceKm(filter.Latitude, filter.Longitude, *a.Latitude, *a.Longitude)
+		if distance <= filter.RadiusKm {
+			nearby = append(nearby, domain.AddressDistance{Address: copyAddress(a), DistanceKm: distance})
+		}
+	}
+	sortByDistance(nearby)
+	if len(nearby) > filter.Limit {
+		nearby = nearby[:filter.Limit]
+	}
+	return nearby, nil
+}
+
+func (r *MemoryAddressRepository) NearestAddress(ctx context.Context, idProfile uuid.UUID, lat, lon float64) (*domain.AddressDistance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var candidates []domain.AddressDistance
+	for _, a := range r.addresses {
+		if a.IdProfile == idProfile && a.ActiveAddress && a.Latitude != nil {
+			candidates = append(candidates, domain.AddressDistance{
+				Address:    copyAddress(a),
+				DistanceKm: geo.DistanceKm(lat, lon, *a.Latitude, *a.Longitude),
+			})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sortByDistance(candidates)
+	return &candidates[0], nil
+}
+
+func (r *MemoryAddressRepository) AddressHistory(ctx context.Context, addressID uuid.UUID) ([]domain.AddressRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var revisions []domain.AddressRevision
+	for _, rev := range r.history[addressID] {
+		rev.Address = copyAddress(&rev.Address)
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
+func (r *MemoryAddressRepository) AddressAsOf(ctx context.Context, addressID uuid.UUID, at time.Time) (*domain.AddressRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *domain.AddressRevision
+	for i, rev := range r.history[addressID] {
+		if !rev.ChangedAt.After(at) {
+			found = &r.history[addressID][i]
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+	rev := *found
+	rev.Address = copyAddress(&rev.Address)
+	return &rev, nil
+}
+
+// sortByDistance ordena como AddressRepository: por distancia y luego por addressId.
+func sortByDistance(addresses []domain.AddressDistance) {
+	slices.SortFunc(addresses, func(a, b domain.AddressDistance) int {
+		return cmp.Or(cmp.Compare(a.DistanceKm, b.DistanceKm), strings.Compare(a.AddressID.String(), b.AddressID.String()))
+	})
+}
+
+// MemoryLocalityRepository es una implementación en memoria de LocalityStore.
+// Replica la búsqueda y el orden de LocalityRepository.
+type MemoryLocalityRepository struct {
+	mu         sync.RWMutex
+	localities []memoryLocality
+}
+
+type memoryLocality struct {
+	domain.Locality
+	key string
+}
+
+// NewMemoryLocalityRepository crea un catálogo de localidades vacío.
+func NewMemoryLocalityRepository() *MemoryLocalityRepository {
+	return &MemoryLocalityRepository{}
+}
+
+func (r *MemoryLocalityRepository) ReplaceLocalities(ctx context.Context, localities []domain.Locality) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.localities = r.localities[:0]
+	for _, l := range localities {
+		key := geo.SearchKey(l.Name)
+		duplicate := slices.ContainsFunc(r.localities, func(m memoryLocality) bool {
+			return m.Province == l.Province && m.CP == l.CP && m.key == key
+		})
+		if !duplicate {
+			r.localities = append(r.localities, memoryLocality{Locality: l, key: key})
+		}
+	}
+	return nil
+}
+
+func (r *MemoryLocalityRepository) SearchLocalities(ctx context.Context, filter domain.LocalityFilter) ([]domain.Locality, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matches []memoryLocality
+	for _, l := range r.localities {
+		if (filter.Province == "" || l.Province == filter.Province) &&
+			strings.HasPrefix(l.CP, filter.CP) && geo.MatchesKey(l.key, filter.Query) {
+			matches = append(matches, l)
+		}
+	}
+	slices.SortFunc(matches, func(a, b memoryLocality) int {
+		aPrefix, bPrefix := strings.HasPrefix(a.key, filter.Query), strings.HasPrefix(b.key, filter.Query)
+		if aPrefix != bPrefix {
+			if aPrefix {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(strings.Compare(a.key, b.key), strings.Compare(a.CP, b.CP), strings.Compare(a.Province, b.Province))
+	})
+
+	localities := []domain.Locality{}
+	for i := filter.Offset; i < len(matches) && len(localities) < filter.Limit; i++ {
+		localities = append(localities, matches[i].Locality)
+	}
+	return localities, len(matches), nil
+}
+
+func (r *MemoryLocalityRepository) FindLocality(ctx context.Context, province, cp, name string) (*domain.Locality, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key := geo.SearchKey(name)
+	for _, l := range r.localities {
+		if l.Province == province && l.CP == cp && l.key == key {
+			locality := l.Locality
+			return &locality, nil
+		}
+	}
+	return nil, nil
+}
+
+func copyAddress(a *domain.Address) domain.Address {
+	address := *a
+	address.Floor = copyString(a.Floor)
+	address.Apartment = copyString(a.Apartment)
+	address.BetweenStreets = copyString(a.BetweenStreets)
+	address.References = copyString(a.References)
+	address.Latitude = copyFloat(a.Latitude)
+	address.Longitude = copyFloat(a.Longitude)
+	return address
+}
+
+func copyIIBB(iibb *domain.IIBB) *domain.IIBB {
+	if iibb == nil {
+		return nil
+	}
+	v := *iibb
+	return &v
+}
+
+func copyFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	v := *f
+	return &v
+}
+
+func copyString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"profilego/internal/domain"
+)
+
+func TestMemoryStoreConformance(t *testing.T) {
+	runStoreConformance(t, func(t *testing.T) (ProfileStore, AddressStore) {
+		return NewMemoryProfileRepository(), NewMemoryAddressRepository()
+	})
+}
+
+func TestMemoryLocalityConformance(t *testing.T) {
+	testLocalityStore(t, NewMemoryLocalityRepository())
+}
+
+func TestMemoryProfileRepositoryConcurrentPoints(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryProfileRepository()
+	profile := newTestProfile(t, ctx, store)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := store.UpdateProfilePoints(ctx, profile.UserID, &domain.Profile{ProfilePoints: 10}); err != nil {
+				panic(fmt.Sprintf("UpdateProfilePoints: %v", err))
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, _ := store.GetByUserID(ctx, profile.UserID)
+	if got.ProfilePoints != 500 {
+		t.Fatalf("esperaba 500 puntos, obtuve %d", got.ProfilePoints)
+	}
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"profilego/internal/domain"
+)
+
+// setClause arma la lista de asignaciones de un UPDATE con sólo las columnas enviadas.
+type setClause struct {
+	assignments []string
+	args        []interface{}
+}
+
+func (s *setClause) add(column string, value interface{}) {
+	s.args = append(s.args, value)
+	s.assignments = append(s.assignments, fmt.Sprintf("%s = $%d", column, len(s.args)))
+}
+
+// addField agrega la columna si el campo vino en el patch; null se persiste como NULL.
+func addField[T any](s *setClause, column string, f domain.Field[T]) {
+	if !f.Set {
+		return
+	}
+	if f.Null {
+		s.add(column, nil)
+		return
+	}
+	s.add(column, f.Value)
+}
+
+// addRaw agrega una asignación sin parámetros, p. ej. "version = version + 1".
+func (s *setClause) addRaw(assignment string) {
+	s.assignments = append(s.assignments, assignment)
+}
+
+func (s *setClause) empty() bool {
+	return len(s.assignments) == 0
+}
+
+func (s *setClause) String() string {
+	return strings.Join(s.assignments, ", ")
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"profilego/pkg/db"
+)
+
+// TestPostgresStoreConformance corre la batería de conformidad contra PostgreSQL.
+// Requiere PROFILEGO_TEST_DSN apuntando a una base descartable; si no está, se omite.
+func TestPostgresStoreConformance(t *testing.T) {
+	dsn := os.Getenv("PROFILEGO_TEST_DSN")
+	if dsn == "" {
+		t.Skip("PROFILEGO_TEST_DSN no definido")
+	}
+
+	database, err := db.InitDB(dsn, 5*time.Second)
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	runStoreConformance(t, func(t *testing.T) (ProfileStore, AddressStore) {
+		return NewProfileRepository(database), NewAddressRepository(database)
+	})
+	t.Run("LocalityStore", func(t *testing.T) {
+		testLocalityStore(t, NewLocalityRepository(database))
+	})
+}
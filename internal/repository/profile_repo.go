@@ -5,11 +5,12 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
 
-	//"time"
+	"time"
 
 	"profilego/internal/domain"
+	"profilego/pkg/logger"
+	"profilego/pkg/tracing"
 
 	"github.com/google/uuid"
 )
@@ -22,52 +23,51 @@ type ProfileRepository struct {
 /*=============================RABBIT=====================================
 ==========================================================================*/
 // UpdateProfilePoints actualiza los puntos en la base de datos
-func (r *ProfileRepository) UpdateProfilePoints(ctx context.Context, userId string, profile *domain.Profile) error {
+func (r *ProfileRepository) UpdateProfilePoints(ctx context.Context, userId string, profile *domain.Profile) (err error) {
 
-	query := `UPDATE profile SET profilePoints = profilePoints + $1 WHERE userId = $2`
+	query := `UPDATE profile SET profilePoints = profilePoints + $1, version = version + 1 WHERE userId = $2`
+	ctx, span := startSpan(ctx, "ProfileRepository.UpdateProfilePoints", query)
+	defer tracing.End(span, &err)
 	result, err := r.DB.ExecContext(ctx, query, profile.ProfilePoints, userId)
 	if err != nil {
-		//log.Println("ERROR al ejecutar consulta:", err)
 		return err
 	}
 
 	// Verificar si se actualizó alguna fila
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		//log.Println("⚠ No se encontró un perfil con userId:", userId)
-		return errors.New("usuario no encontrado")
+		return ErrUserNotFound
 	}
 
-	//log.Println("✅ Puntos actualizados correctamente para userId:", userId)
 	return nil
 }
 
 // UpdateProfileLevel actualiza el nivel en la base de datos
-func (r *ProfileRepository) UpdateProfileLevel(ctx context.Context, userId string, profile *domain.Profile) error {
+func (r *ProfileRepository) UpdateProfileLevel(ctx context.Context, userId string, profile *domain.Profile) (err error) {
 
 	if profile.ProfilePoints > 1000 { // si vino a actualizar el nivel, y los puntos son mayores a 1000, reseteo los puntos
 		profile.ProfilePoints = 0
 	}
 
-	query := `UPDATE profile SET profileLevel = $1, profilepoints =$3  WHERE userId = $2`
+	query := `UPDATE profile SET profileLevel = $1, profilepoints =$3, version = version + 1  WHERE userId = $2`
+	ctx, span := startSpan(ctx, "ProfileRepository.UpdateProfileLevel", query)
+	defer tracing.End(span, &err)
 	result, err := r.DB.ExecContext(ctx, query,
 		profile.ProfileLevel,
 		userId,
 		profile.ProfilePoints,
 	)
 	if err != nil {
-		//log.Println("ERROR al ejecutar consulta:", err)
 		return err
 	}
 
 	// Verificar si se actualizó alguna fila
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		//log.Println("⚠ No se encontró un perfil con userId:", userId)
-		return errors.New("usuario no encontrado")
+		return ErrUserNotFound
 	}
 
-	log.Println("✅ Nivel actualizado correctamente para userId:", userId)
+	logger.FromContext(ctx).Debug("nivel actualizado", "user_id", userId, "profile_level", profile.ProfileLevel)
 	return nil
 }
 
@@ -88,7 +88,7 @@ func NewProfileRepository(db *sql.DB) *ProfileRepository {
 			creationDate, updatedDate
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
 
-	_, err := r.DB.ExecContext(ctx, query,
+	_, err = r.DB.ExecContext(ctx, query,
 		profile.ProfileID, profile.UserID, profile.ProfileImage, profile.ProfileName, profile.ProfileLevel,
 		profile.ProfilePoints, profile.ProfileMail, profile.Phone, profile.CUIL, profile.FiscalAdress,
 		profile.FiscalCondition, profile.IIBB, profile.CreationDate, profile.UpdatedDate,
@@ -96,25 +96,34 @@ func NewProfileRepository(db *sql.DB) *ProfileRepository {
 	return err
 }*/
 
-func (r *ProfileRepository) CreateProfile(ctx context.Context, profile *domain.Profile) error {
+func (r *ProfileRepository) CreateProfile(ctx context.Context, profile *domain.Profile) (err error) {
 	query := `
 		INSERT INTO profile (
-			profileId, userId, profileName,  
+			profileId, userId, profileName,
 			profileMail, phone,
 			creationDate, updatedDate
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING version`
+	ctx, span := startSpan(ctx, "ProfileRepository.CreateProfile", query)
+	defer tracing.End(span, &err)
 
-	_, err := r.DB.ExecContext(ctx, query,
+	err = r.DB.QueryRowContext(ctx, query,
 		profile.ProfileID, profile.UserID, profile.ProfileName, profile.ProfileMail, profile.Phone, profile.CreationDate, profile.UpdatedDate,
-	)
+	).Scan(&profile.Version)
+	if isUniqueViolation(err) {
+		return ErrProfileExists
+	}
 	return err
 }
 
 // GetProfile obtiene un perfil por su ID.
-func (r *ProfileRepository) GetProfile(ctx context.Context, userId string) (*domain.Profile, error) {
+func (r *ProfileRepository) GetProfile(ctx context.Context, userId string) (_ *domain.Profile, err error) {
 	query := `SELECT profileId, userId, profileImage, profileName, profileLevel, profilePoints,
-			profileMail, phone, CUIL, fiscalAdress, fiscalCondition, IIBB, 
-			creationDate, updatedDate FROM profile WHERE userId = $1`
+			profileMail, phone, CUIL, fiscalAdress, fiscalCondition, monotributoCategory,
+			iibbRegime, iibbNumber, iibbJurisdiction,
+			creationDate, updatedDate, version FROM profile WHERE userId = $1`
+	ctx, span := startSpan(ctx, "ProfileRepository.GetProfile", query)
+	defer tracing.End(span, &err)
 
 	row := r.DB.QueryRowContext(ctx, query, userId)
 
@@ -126,31 +135,43 @@ func (r *ProfileRepository) GetProfile(ctx context.Context, userId string) (*dom
 		cuil            sql.NullString
 		fiscalAdress    sql.NullString
 		fiscalCondition sql.NullString
-		iibb            sql.NullString
+		category        sql.NullString
+		iibbRegime      sql.NullString
+		iibbNumber      sql.NullString
+		iibbJuris       sql.NullString
 	)
 
-	err := row.Scan(
+	err = row.Scan(
 		&profile.ProfileID, &profile.UserID, &profileImage, &profile.ProfileName, &profile.ProfileLevel,
 		&profile.ProfilePoints, &profileMail, &phone, &cuil, &fiscalAdress,
-		&fiscalCondition, &iibb, &profile.CreationDate, &profile.UpdatedDate,
+		&fiscalCondition, &category, &iibbRegime, &iibbNumber, &iibbJuris, &profile.CreationDate, &profile.UpdatedDate, &profile.Version,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil // No se encontró el perfil
 		}
-		//log.Println("❌ Error al recuperar el perfil:", err)
 		return nil, err
 	}
 
 	// Convertir `sql.NullString` a `string` evitando `NULL`
 
+	if profileImage.Valid {
+		profile.ProfileImage = &profileImage.String
+	}
 	profile.ProfileMail = safeString(profileMail)
 	profile.Phone = safeString(phone)
 	profile.CUIL = safeString(cuil)
 	profile.FiscalAdress = safeString(fiscalAdress)
-	profile.FiscalCondition = safeString(fiscalCondition)
-	profile.IIBB = safeString(iibb)
+	profile.FiscalCondition = domain.FiscalCondition(safeString(fiscalCondition))
+	profile.MonotributoCategory = safeString(category)
+	if iibbRegime.Valid || iibbNumber.Valid {
+		profile.IIBB = &domain.IIBB{
+			Regime:       domain.IIBBRegime(safeString(iibbRegime)),
+			Number:       safeString(iibbNumber),
+			Jurisdiction: safeString(iibbJuris),
+		}
+	}
 
 	return &profile, err
 }
@@ -163,71 +184,183 @@ func safeString(ns sql.NullString) string {
 	return ""
 }
 
-// UpdateProfile actualiza un perfil existente.
-func (r *ProfileRepository) UpdateProfile(ctx context.Context, profileId string, profile *domain.Profile) error {
+// nullString es la inversa de safeString: el string vacío se guarda como NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// UpdateProfile actualiza un perfil existente. Sólo actualiza si profile.ProfileID es del
+// usuario userId; si no, devuelve ErrUserNotFound.
+func (r *ProfileRepository) UpdateProfile(ctx context.Context, userId string, profile *domain.Profile) (err error) {
 	query := `
-		UPDATE profile SET 
-			profileName = $1,profileMail = $2, phone = $3, updatedDate =$4
-		WHERE profileid = $5`
+		UPDATE profile SET
+			profileName = $1,profileMail = $2, phone = $3, updatedDate =$4, version = version + 1
+		WHERE profileid = $5 AND userId = $6`
+	ctx, span := startSpan(ctx, "ProfileRepository.UpdateProfile", query)
+	defer tracing.End(span, &err)
 
-	//log.Println("🔍 Ejecutando SQL Update con profileId:", profile.ProfileID)
-	//log.Println("🔍 Ejecutando SQL Update con profileId:", profile.UpdatedDate)
 	result, err := r.DB.ExecContext(ctx, query,
 		profile.ProfileName,
 		profile.ProfileMail,
 		profile.Phone,
 		profile.UpdatedDate,
 		profile.ProfileID,
+		userId,
 	)
 	if err != nil {
-		//log.Println("Error en la ejecución de la consulta SQL:", err) // Agrega este log
 		return err
 	}
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		//log.Println("⚠️ No se actualizó ninguna fila. ¿El profileId existe?")
-		return fmt.Errorf("no se encontró el perfil con profileId: %s", profile.ProfileID)
+		return fmt.Errorf("%w: profileId %s", ErrUserNotFound, profile.ProfileID)
 	}
 
-	//log.Println("✅ Perfil actualizado correctamente:", profile.ProfileID)
 	return nil
 }
 
-func (r *ProfileRepository) UpdateFiscalData(ctx context.Context, userid string, profile *domain.Profile) error { // este va a ser el updateFisicalData
+// PatchProfile actualiza sólo las columnas presentes en el patch (y updatedDate).
+// Con version distinto de 0 sólo actualiza si el perfil sigue en esa versión.
+func (r *ProfileRepository) PatchProfile(ctx context.Context, profileID uuid.UUID, version int, patch domain.ProfilePatch, updatedDate time.Time) (err error) {
+	var set setClause
+	addField(&set, "profileName", patch.ProfileName)
+	addField(&set, "profileMail", patch.ProfileMail)
+	addField(&set, "phone", patch.Phone)
+	addField(&set, "profileImage", patch.ProfileImage)
+	set.add("updatedDate", updatedDate)
+	set.addRaw("version = version + 1")
+
+	n := len(set.args)
+	query := fmt.Sprintf("UPDATE profile SET %s WHERE profileId = $%d AND ($%d = 0 OR version = $%d)", set, n+1, n+2, n+2)
+	ctx, span := startSpan(ctx, "ProfileRepository.PatchProfile", query)
+	defer tracing.End(span, &err)
+
+	result, err := r.DB.ExecContext(ctx, query, append(set.args, profileID, version)...)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return r.notUpdated(ctx, profileID)
+	}
+	return nil
+}
+
+// notUpdated explica por qué un UPDATE condicionado no afectó filas: el perfil no existe
+// (ErrUserNotFound) o cambió de versión (ErrVersionConflict).
+func (r *ProfileRepository) notUpdated(ctx context.Context, profileID uuid.UUID) error {
+	var exists bool
+	err := r.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM profile WHERE profileId = $1)`, profileID).Scan(&exists)
+	switch {
+	case err != nil:
+		return err
+	case exists:
+		return ErrVersionConflict
+	default:
+		return ErrUserNotFound
+	}
+}
+
+func (r *ProfileRepository) UpdateFiscalData(ctx context.Context, userid string, profile *domain.Profile) (err error) { // este va a ser el updateFisicalData
 	query := `
 		UPDATE profile SET
-			CUIL = $1, fiscalAdress = $2,fiscalCondition = $3, IIBB = $4, updatedDate = $5
-		WHERE profileid = $6`
-
-	_, err := r.DB.ExecContext(ctx, query,
-		profile.CUIL, profile.FiscalAdress, profile.FiscalCondition, profile.IIBB, profile.UpdatedDate, profile.ProfileID,
+			CUIL = $1, fiscalAdress = $2, fiscalCondition = $3, monotributoCategory = $4,
+			iibbRegime = $5, iibbNumber = $6, iibbJurisdiction = $7, updatedDate = $8, version = version + 1
+		WHERE profileid = $9 AND ($10 = 0 OR version = $10)`
+	ctx, span := startSpan(ctx, "ProfileRepository.UpdateFiscalData", query)
+	defer tracing.End(span, &err)
+
+	var iibb domain.IIBB
+	if profile.IIBB != nil {
+		iibb = *profile.IIBB
+	}
+	result, err := r.DB.ExecContext(ctx, query,
+		profile.CUIL, profile.FiscalAdress, nullString(string(profile.FiscalCondition)), nullString(profile.MonotributoCategory),
+		nullString(string(iibb.Regime)), nullString(iibb.Number), nullString(iibb.Jurisdiction),
+		profile.UpdatedDate, profile.ProfileID, profile.Version,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return r.notUpdated(ctx, profile.ProfileID)
+	}
+	return nil
 }
 
-func (r *ProfileRepository) UpdateProfileImage(ctx context.Context, userId, profileID, profileImage string) error {
-	query := `UPDATE profile SET profileImage = $1 WHERE userId = $2`
-	_, err := r.DB.ExecContext(ctx, query, profileImage, userId)
+// UpdateProfileImage reemplaza la imagen del perfil profileID del usuario. Si el perfil no
+// existe o es de otro usuario devuelve ErrUserNotFound.
+func (r *ProfileRepository) UpdateProfileImage(ctx context.Context, userId, profileID, profileImage string) (err error) {
+	query := `UPDATE profile SET profileImage = $1, updatedDate = $2, version = version + 1 WHERE userId = $3 AND profileId = $4`
+	ctx, span := startSpan(ctx, "ProfileRepository.UpdateProfileImage", query)
+	defer tracing.End(span, &err)
+
+	result, err := r.DB.ExecContext(ctx, query, profileImage, time.Now(), userId, profileID)
 	if err != nil {
-		//log.Println("❌ Error actualizando la imagen de perfil:", err)
 		return err
 	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrUserNotFound
+	}
 	return nil
 }
 
-// DeleteProfile elimina un perfil por su ID.
-func (r *ProfileRepository) DeleteProfile(ctx context.Context, profileID uuid.UUID) error {
-	query := `DELETE FROM profile WHERE profileId = $1`
-	_, err := r.DB.ExecContext(ctx, query, profileID)
-	return err
+// DeleteProfile elimina un perfil por su ID. Con version distinto de 0 sólo lo elimina si
+// sigue en esa versión (si no, ErrVersionConflict). Eliminar un perfil inexistente no es error.
+func (r *ProfileRepository) DeleteProfile(ctx context.Context, profileID uuid.UUID, version int) (err error) {
+	query := `DELETE FROM profile WHERE profileId = $1 AND ($2 = 0 OR version = $2)`
+	ctx, span := startSpan(ctx, "ProfileRepository.DeleteProfile", query)
+	defer tracing.End(span, &err)
+
+	result, err := r.DB.ExecContext(ctx, query, profileID, version)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 && version != 0 {
+		var exists bool
+		if err := r.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM profile WHERE profileId = $1)`, profileID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return ErrVersionConflict
+		}
+	}
+	return nil
+}
+
+// GetByProfileID busca un perfil por su profileId. Devuelve los mismos campos que GetByUserID.
+func (r *ProfileRepository) GetByProfileID(ctx context.Context, profileID uuid.UUID) (_ *domain.Profile, err error) {
+	var profile domain.Profile
+	query := "SELECT profileid, userid, profilename, profilemail, phone, ProfilePoints, ProfileLevel, version FROM profile WHERE profileId = $1"
+	ctx, span := startSpan(ctx, "ProfileRepository.GetByProfileID", query)
+	defer tracing.End(span, &err)
+
+	err = r.DB.QueryRowContext(ctx, query, profileID).Scan(
+		&profile.ProfileID,
+		&profile.UserID,
+		&profile.ProfileName,
+		&profile.ProfileMail,
+		&profile.Phone,
+		&profile.ProfilePoints,
+		&profile.ProfileLevel,
+		&profile.Version,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &profile, nil
 }
 
-func (r *ProfileRepository) GetByUserID(ctx context.Context, userId string) (*domain.Profile, error) {
+func (r *ProfileRepository) GetByUserID(ctx context.Context, userId string) (_ *domain.Profile, err error) {
 
 	var profile domain.Profile
-	query := "SELECT profileid, userid, profilename, profilemail, phone, ProfilePoints, ProfileLevel FROM profile WHERE userId = $1"
+	query := "SELECT profileid, userid, profilename, profilemail, phone, ProfilePoints, ProfileLevel, version FROM profile WHERE userId = $1"
+	ctx, span := startSpan(ctx, "ProfileRepository.GetByUserID", query)
+	defer tracing.End(span, &err)
 
-	err := r.DB.QueryRowContext(ctx, query, userId).Scan(
+	err = r.DB.QueryRowContext(ctx, query, userId).Scan(
 		&profile.ProfileID,
 		&profile.UserID,
 		&profile.ProfileName,
@@ -235,6 +368,7 @@ func (r *ProfileRepository) GetByUserID(ctx context.Context, userId string) (*do
 		&profile.Phone,
 		&profile.ProfilePoints, //kike agrego estos dos para hacer la sincronizacion de puntos
 		&profile.ProfileLevel,
+		&profile.Version,
 	)
 
 	if err != nil {
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"profilego/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUserNotFound se devuelve cuando una actualización por userId no afecta ninguna fila.
+	ErrUserNotFound = domain.NotFound("profile_not_found", "usuario no encontrado")
+	// ErrProfileExists se devuelve al crear un segundo perfil para el mismo userId.
+	ErrProfileExists = domain.AlreadyExists("profile_exists", "el usuario ya tiene un perfil creado")
+	// ErrAddressNotFound se devuelve cuando una actualización por addressId no afecta ninguna fila.
+	ErrAddressNotFound = domain.NotFound("address_not_found", "dirección no encontrada")
+	// ErrVersionConflict se devuelve cuando una actualización condicionada encuentra otra versión del registro.
+	ErrVersionConflict = domain.Conflict("version_conflict", "el registro fue modificado por otra operación")
+	// ErrMainAddressConflict se devuelve cuando otra transacción marcó a la vez otra dirección principal.
+	ErrMainAddressConflict = domain.Conflict("main_address_conflict", "otra operación cambió la dirección principal")
+)
+
+// ProfileStore define las operaciones de persistencia de perfiles.
+// La implementan ProfileRepository (PostgreSQL) y MemoryProfileRepository.
+// Toda modificación incrementa Version. Las operaciones que reciben una versión (o un
+// Version distinto de 0) sólo actualizan si coincide y si no devuelven ErrVersionConflict.
+type ProfileStore interface {
+	CreateProfile(ctx context.Context, profile *domain.Profile) error
+	GetProfile(ctx context.Context, userId string) (*domain.Profile, error)
+	GetByUserID(ctx context.Context, userId string) (*domain.Profile, error)
+	GetByProfileID(ctx context.Context, profileID uuid.UUID) (*domain.Profile, error)
+	UpdateProfile(ctx context.Context, userId string, profile *domain.Profile) error
+	PatchProfile(ctx context.Context, profileID uuid.UUID, version int, patch domain.ProfilePatch, updatedDate time.Time) error
+	UpdateFiscalData(ctx context.Context, userid string, profile *domain.Profile) error
+	UpdateProfileImage(ctx context.Context, userId, profileID, profileImage string) error
+	UpdateProfilePoints(ctx context.Context, userId string, profile *domain.Profile) error
+	UpdateProfileLevel(ctx context.Context, userId string, profile *domain.Profile) error
+	DeleteProfile(ctx context.Context, profileID uuid.UUID, version int) error
+}
+
+// AddressStore define las operaciones de persistencia de direcciones.
+// La implementan AddressRepository (PostgreSQL) y MemoryAddressRepository, con la misma
+// semántica de versiones que ProfileStore. Un perfil tiene a lo sumo una dirección principal
+// activa: las operaciones que marcan una como principal desmarcan la anterior en la misma
+// transacción, y las que dejan de marcar (o dan de baja) la principal marcan la activa más
+// antigua; si no hay otra activa, la dirección sigue siendo la principal. Cada versión de una dirección queda en su historial (AddressHistory), con el
+// usuario de domain.ActorFromContext como autor.
+type AddressStore interface {
+	CreateAddress(ctx context.Context, address *domain.Address) error
+	GetAddress(ctx context.Context, idProfile uuid.UUID) (*domain.Address, error)
+	GetAddressByID(ctx context.Context, addressID uuid.UUID) (*domain.Address, error)
+	UpdateAddressByID(ctx context.Context, address *domain.Address) error
+	PatchAddress(ctx context.Context, addressID, idProfile uuid.UUID, version int, patch domain.AddressPatch, updatedDate time.Time) error
+	SetMainAddress(ctx context.Context, addressID, idProfile uuid.UUID, version int) error
+	DeleteAddress(ctx context.Context, addressId string, activeAddress bool, idprofile uuid.UUID, version int) error
+	GetAddressesByProfile(ctx context.Context, IdProfile uuid.UUID) ([]domain.Address, error)
+	SetGeocode(ctx context.Context, addressID uuid.UUID, version int, geocode domain.Geocode) error
+	PendingGeocodes(ctx context.Context, limit int) ([]domain.Address, error)
+	ResetGeocodes(ctx context.Context) (int, error)
+	NearbyAddresses(ctx context.Context, filter domain.NearbyFilter) ([]domain.AddressDistance, error)
+	NearestAddress(ctx context.Context, idProfile uuid.UUID, lat, lon float64) (*domain.AddressDistance, error)
+	AddressHistory(ctx context.Context, addressID uuid.UUID) ([]domain.AddressRevision, error)
+	AddressAsOf(ctx context.Context, addressID uuid.UUID, at time.Time) (*domain.AddressRevision, error)
+}
+
+// LocalityStore define las operaciones del catálogo de localidades y códigos postales.
+// La implementan LocalityRepository (PostgreSQL) y MemoryLocalityRepository. Los nombres se
+// comparan normalizados con geo.SearchKey.
+type LocalityStore interface {
+	ReplaceLocalities(ctx context.Context, localities []domain.Locality) error
+	SearchLocalities(ctx context.Context, filter domain.LocalityFilter) ([]domain.Locality, int, error)
+	FindLocality(ctx context.Context, province, cp, name string) (*domain.Locality, error)
+}
+
+var (
+	_ ProfileStore  = (*ProfileRepository)(nil)
+	_ ProfileStore  = (*MemoryProfileRepository)(nil)
+	_ AddressStore  = (*AddressRepository)(nil)
+	_ AddressStore  = (*MemoryAddressRepository)(nil)
+	_ LocalityStore = (*LocalityRepository)(nil)
+	_ LocalityStore = (*MemoryLocalityRepository)(nil)
+)
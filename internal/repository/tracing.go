@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"profilego/pkg/tracing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan abre un span para una consulta a PostgreSQL.
+func startSpan(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracing.Tracer("repository").Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(tracing.DBAttributes(query)...),
+	)
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// inTx ejecuta fn en una transacción y la confirma si fn no devuelve error.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -2,10 +2,11 @@ package service
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"time"
 
 	"profilego/internal/domain"
+	"profilego/internal/geo"
 	"profilego/internal/repository"
 
 	"github.com/google/uuid"
@@ -13,12 +14,17 @@ import (
 
 // AddressService maneja la lógica de negocio para direcciones.
 type AddressService struct {
-	Repo        repository.AddressRepository
-	ProfileRepo repository.ProfileRepository
+	Repo        repository.AddressStore
+	ProfileRepo repository.ProfileStore
+	// Localities es el catálogo contra el que se valida el par CP/localidad; nil no lo valida.
+	Localities repository.LocalityStore
+	// Geocoding geocodifica en segundo plano las direcciones nuevas o modificadas; con nil
+	// quedan pendientes.
+	Geocoding *GeocodingService
 }
 
 // NewAddressService crea una nueva instancia de AddressService.
-func NewAddressService(repo repository.AddressRepository, profileRepo repository.ProfileRepository) *AddressService {
+func NewAddressService(repo repository.AddressStore, profileRepo repository.ProfileStore) *AddressService {
 	return &AddressService{
 		Repo:        repo,
 		ProfileRepo: profileRepo,
@@ -39,10 +45,24 @@ func NewAddressService(repo repository.AddressRepository, profileRepo repository
 	return s.Repo.CreateAddress(ctx, address)
 }*/
 
+// CreateAddress agrega una dirección activa al perfil del usuario. La primera dirección activa
+// es siempre la principal; si se crea otra como principal, la anterior deja de serlo.
 func (s *AddressService) CreateAddress(ctx context.Context, userId string, address *domain.Address) error {
-	profile, err := s.ProfileRepo.GetByUserID(ctx, userId)
-	if err != nil || profile == nil {
-		return errors.New("perfil no encontrado")
+	profile, err := s.profileOf(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.validate(ctx, address); err != nil {
+		return err
+	}
+
+	current, err := s.Repo.GetAddress(ctx, profile.ProfileID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		address.MainAddress = true
 	}
 
 	address.AddressID = uuid.New()
@@ -50,41 +70,461 @@ func (s *AddressService) CreateAddress(ctx context.Context, userId string, addre
 	address.ActiveAddress = true
 	address.CreationDate = time.Now()
 	address.UpdatedDate = time.Now()
+	address.ResetGeocode()
 
-	return s.Repo.CreateAddress(ctx, address)
+	if err := s.Repo.CreateAddress(ctx, address); err != nil {
+		return err
+	}
+	s.geocode(ctx, address)
+	return nil
 }
 
+// GetAddress devuelve la dirección principal del usuario (o, si no tiene, la activa más antigua).
 func (s *AddressService) GetAddress(ctx context.Context, userId string) (*domain.Address, error) {
-	profile, err := s.ProfileRepo.GetByUserID(ctx, userId)
-	if err != nil || profile == nil {
-		return nil, errors.New("perfil no encontrado")
+	profile, err := s.profileOf(ctx, userId)
+	if err != nil {
+		return nil, err
 	}
 
 	return s.Repo.GetAddress(ctx, profile.ProfileID)
 }
 
+// UpdateAddress reemplaza la dirección indicada por address.AddressID o, si no viene, la que
+// devuelve GetAddress. Las coordenadas se conservan salvo que cambie la ubicación; en ese caso
+// se vuelve a geocodificar.
 func (s *AddressService) UpdateAddress(ctx context.Context, userId string, address *domain.Address) error {
-	profile, err := s.ProfileRepo.GetByUserID(ctx, userId)
-	if err != nil || profile == nil {
-		return errors.New("perfil no encontrado")
+	profile, err := s.profileOf(ctx, userId)
+	if err != nil {
+		return err
 	}
 
+	var current *domain.Address
+	if address.AddressID == uuid.Nil {
+		current, err = s.Repo.GetAddress(ctx, profile.ProfileID)
+	} else {
+		current, err = s.Repo.GetAddressByID(ctx, address.AddressID)
+	}
+	if err != nil {
+		return err
+	}
+	if current == nil || current.IdProfile != profile.ProfileID || !current.ActiveAddress {
+		return ErrAddressNotFound
+	}
+	address.AddressID = current.AddressID
+	if err := s.validate(ctx, address); err != nil {
+		return err
+	}
 	address.IdProfile = profile.ProfileID
 	address.UpdatedDate = time.Now()
+	address.Version = 0
+	relocated := geo.AddressKey(*current) != geo.AddressKey(*address)
+	if relocated {
+		address.ResetGeocode()
+	} else {
+		address.Latitude, address.Longitude, address.GeocodeQuality = current.Latitude, current.Longitude, current.GeocodeQuality
+	}
 
-	return s.Repo.UpdateAddress(ctx, address)
+	if err := s.Repo.UpdateAddressByID(ctx, address); err != nil {
+		return err
+	}
+	if !relocated {
+		return nil
+	}
+	// UpdateAddressByID no devuelve la versión nueva: se geocodifica la que esté vigente
+	if current, err := s.Repo.GetAddressByID(ctx, address.AddressID); err == nil && current != nil {
+		s.geocode(ctx, current)
+	}
+	return nil
 }
 
 func (s *AddressService) DeleteAddress(ctx context.Context, addressId string, activeAddress bool, userId string) error {
+	profile, err := s.profileOf(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	return s.Repo.DeleteAddress(ctx, addressId, activeAddress, profile.ProfileID, 0)
+}
+
+// profileOf busca el perfil del usuario; ErrProfileNotFound si no tiene uno.
+func (s *AddressService) profileOf(ctx context.Context, userId string) (*domain.Profile, error) {
 	profile, err := s.ProfileRepo.GetByUserID(ctx, userId)
-	if err != nil || profile == nil {
-		return errors.New("perfil no encontrado")
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar el perfil del usuario: %w", err)
+	}
+	if profile == nil {
+		return nil, ErrProfileNotFound
 	}
+	return profile, nil
+}
 
-	return s.Repo.DeleteAddress(ctx, addressId, activeAddress, profile.ProfileID)
+// ProfileOwner devuelve el userId dueño del perfil, o "" si el perfil no existe.
+func (s *AddressService) ProfileOwner(ctx context.Context, profileID uuid.UUID) (string, error) {
+	profile, err := s.ProfileRepo.GetByProfileID(ctx, profileID)
+	if err != nil || profile == nil {
+		return "", err
+	}
+	return profile.UserID, nil
 }
 
 // GetAddressesByProfile obtiene todas las direcciones de un perfil.
 func (s *AddressService) GetAddressesByProfile(ctx context.Context, profileID uuid.UUID) ([]domain.Address, error) {
 	return s.Repo.GetAddressesByProfile(ctx, profileID)
 }
+
+// AddressFilter filtra ListAddresses; un campo nil no filtra.
+type AddressFilter struct {
+	Active *bool
+	Main   *bool
+}
+
+func (f AddressFilter) matches(a domain.Address) bool {
+	return (f.Active == nil || a.ActiveAddress == *f.Active) && (f.Main == nil || a.MainAddress == *f.Main)
+}
+
+// ListAddresses devuelve las direcciones del perfil del usuario que cumplen el filtro, en orden de creación.
+func (s *AddressService) ListAddresses(ctx context.Context, userId string, filter AddressFilter) ([]domain.Address, error) {
+	profile, err := s.profileOf(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.Repo.GetAddressesByProfile(ctx, profile.ProfileID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]domain.Address, 0, len(addresses))
+	for _, a := range addresses {
+		if filter.matches(a) {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+// GetAddressByID devuelve una dirección activa del perfil del usuario.
+// Las direcciones de otros perfiles o dadas de baja se informan como ErrAddressNotFound.
+func (s *AddressService) GetAddressByID(ctx context.Context, userId string, addressID uuid.UUID) (*domain.Address, error) {
+	profile, err := s.profileOf(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := s.Repo.GetAddressByID(ctx, addressID)
+	if err != nil {
+		return nil, err
+	}
+	if address == nil || address.IdProfile != profile.ProfileID || !address.ActiveAddress {
+		return nil, ErrAddressNotFound
+	}
+	return address, nil
+}
+
+// PatchAddress aplica un JSON Merge Patch a una dirección del usuario. El resultado se valida
+// igual que CreateAddress, sólo se persisten los campos que cambian y se devuelve la dirección resultante.
+// version funciona igual que en ProfileService.PatchProfile.
+func (s *AddressService) PatchAddress(ctx context.Context, userId string, addressID uuid.UUID, version int, patch domain.AddressPatch) (*domain.Address, error) {
+	address, err := s.GetAddressByID(ctx, userId, addressID)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && address.Version != version {
+		return nil, ErrVersionConflict
+	}
+	var nulls fieldErrors
+	nulls.addNull("CP", patch.CP.Null)
+	nulls.addNull("street", patch.Street.Null)
+	nulls.addNull("number", patch.Number.Null)
+	nulls.addNull("locality", patch.Locality.Null)
+	nulls.addNull("province", patch.Province.Null)
+	nulls.addNull("country", patch.Country.Null)
+	nulls.addNull("mainAddress", patch.MainAddress.Null)
+	if err := nulls.err(); err != nil {
+		return nil, err
+	}
+
+	merged := *address
+	patch.ApplyTo(&merged)
+	if err := s.validate(ctx, &merged); err != nil {
+		return nil, err
+	}
+	relocated := geo.AddressKey(*address) != geo.AddressKey(merged)
+	if relocated {
+		merged.ResetGeocode()
+	}
+	changed := domain.AddressChanges(address, &merged)
+	if changed == (domain.AddressPatch{}) {
+		return address, nil
+	}
+
+	merged.UpdatedDate = time.Now()
+	if err := s.Repo.PatchAddress(ctx, address.AddressID, address.IdProfile, address.Version, changed, merged.UpdatedDate); err != nil {
+		return nil, err
+	}
+	merged.Version++
+	if address.MainAddress && !merged.MainAddress {
+		// Si no había otra dirección activa sigue siendo la principal (ver AddressStore)
+		current, err := s.Repo.GetAddressByID(ctx, address.AddressID)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil {
+			merged.MainAddress = current.MainAddress
+		}
+	}
+	if relocated {
+		s.geocode(ctx, &merged)
+	}
+	return &merged, nil
+}
+
+// SetMainAddress marca una dirección activa del usuario como principal (la anterior deja de
+// serlo) y devuelve la dirección resultante. version funciona igual que en PatchAddress.
+func (s *AddressService) SetMainAddress(ctx context.Context, userId string, addressID uuid.UUID, version int) (*domain.Address, error) {
+	address, err := s.GetAddressByID(ctx, userId, addressID)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && address.Version != version {
+		return nil, ErrVersionConflict
+	}
+	if address.MainAddress {
+		return address, nil
+	}
+
+	if err := s.Repo.SetMainAddress(ctx, address.AddressID, address.IdProfile, address.Version); err != nil {
+		return nil, err
+	}
+	address.MainAddress = true
+	address.Version++
+	return address, nil
+}
+
+// DeactivateAddress da de baja una dirección del usuario (queda guardada como inactiva). Si era
+// la principal, pasa a serlo la dirección activa más antigua.
+// Con version distinto de 0 sólo la da de baja si sigue en esa versión.
+func (s *AddressService) DeactivateAddress(ctx context.Context, userId string, addressID uuid.UUID, version int) error {
+	address, err := s.GetAddressByID(ctx, userId, addressID)
+	if err != nil {
+		return err
+	}
+	return s.Repo.DeleteAddress(ctx, address.AddressID.String(), false, address.IdProfile, version)
+}
+
+// AddressHistory devuelve todas las versiones de una dirección del usuario, de la más antigua a
+// la más nueva, con quién y cuándo hizo cada cambio y qué campos cambiaron. Incluye las
+// direcciones dadas de baja.
+func (s *AddressService) AddressHistory(ctx context.Context, userId string, addressID uuid.UUID) ([]domain.AddressRevision, error) {
+	if err := s.checkAddressOwner(ctx, userId, addressID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.Repo.AddressHistory(ctx, addressID)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(revisions); i++ {
+		revisions[i].Changes = domain.AddressFieldChanges(&revisions[i-1].Address, &revisions[i].Address)
+	}
+	if revisions == nil {
+		revisions = []domain.AddressRevision{}
+	}
+	return revisions, nil
+}
+
+// AddressAsOf devuelve la versión de una dirección del usuario vigente en el instante at, p. ej.
+// para saber a dónde se envió un pedido. ErrAddressNotAtTime si la dirección no existía todavía.
+func (s *AddressService) AddressAsOf(ctx context.Context, userId string, addressID uuid.UUID, at time.Time) (*domain.AddressRevision, error) {
+	if err := s.checkAddressOwner(ctx, userId, addressID); err != nil {
+		return nil, err
+	}
+
+	revision, err := s.Repo.AddressAsOf(ctx, addressID, at)
+	if err != nil {
+		return nil, err
+	}
+	if revision == nil {
+		return nil, ErrAddressNotAtTime
+	}
+	return revision, nil
+}
+
+// checkAddressOwner verifica que la dirección, activa o no, sea del perfil del usuario. Si ya
+// no está en address (se eliminó el perfil) el perfil sale de su historial, y si el perfil ya no
+// existe la dirección es del usuario que la dio de alta.
+func (s *AddressService) checkAddressOwner(ctx context.Context, userId string, addressID uuid.UUID) error {
+	address, err := s.Repo.GetAddressByID(ctx, addressID)
+	if err != nil {
+		return err
+	}
+	var revisions []domain.AddressRevision
+	var idProfile uuid.UUID
+	if address != nil {
+		idProfile = address.IdProfile
+	} else {
+		revisions, err = s.Repo.AddressHistory(ctx, addressID)
+		if err != nil {
+			return err
+		}
+		if len(revisions) == 0 {
+			return ErrAddressNotFound
+		}
+		idProfile = revisions[len(revisions)-1].Address.IdProfile
+	}
+
+	owner, err := s.ProfileRepo.GetByProfileID(ctx, idProfile)
+	if err != nil {
+		return err
+	}
+	if owner != nil {
+		if owner.UserID != userId {
+			return ErrAddressNotFound
+		}
+		return nil
+	}
+
+	if revisions == nil {
+		if revisions, err = s.Repo.AddressHistory(ctx, addressID); err != nil {
+			return err
+		}
+	}
+	if len(revisions) == 0 || revisions[0].Operation != domain.AddressCreated || revisions[0].ChangedBy != userId {
+		return ErrAddressNotFound
+	}
+	return nil
+}
+
+// NearbySearch es una búsqueda de direcciones cerca de un punto; Latitude y Longitude son
+// obligatorias (nil = no se indicaron).
+type NearbySearch struct {
+	Latitude  *float64
+	Longitude *float64
+	RadiusKm  float64 // 0 = DefaultNearbyRadiusKm
+	Limit     int     // 0 = DefaultNearbyLimit
+}
+
+const (
+	// DefaultNearbyRadiusKm es el radio de NearbyAddresses si no se indica otro.
+	DefaultNearbyRadiusKm = 5
+	// MaxNearbyRadiusKm es el radio máximo de NearbyAddresses.
+	MaxNearbyRadiusKm = 100
+	// DefaultNearbyLimit es la cantidad de direcciones de NearbyAddresses si no se indica otra.
+	DefaultNearbyLimit = 20
+	// MaxNearbyLimit es la cantidad máxima de direcciones de NearbyAddresses.
+	MaxNearbyLimit = 100
+)
+
+// NearbyAddresses devuelve las direcciones activas geocodificadas de todos los perfiles a lo
+// sumo a RadiusKm del punto, de la más cercana a la más lejana, con su distancia.
+func (s *AddressService) NearbyAddresses(ctx context.Context, search NearbySearch) ([]domain.AddressDistance, error) {
+	var errs fieldErrors
+	validatePoint(&errs, search.Latitude, search.Longitude)
+	filter := domain.NearbyFilter{RadiusKm: search.RadiusKm, Limit: search.Limit}
+	if filter.RadiusKm == 0 {
+		filter.RadiusKm = DefaultNearbyRadiusKm
+	}
+	if filter.RadiusKm <= 0 || filter.RadiusKm > MaxNearbyRadiusKm {
+		errs.add("radiusKm", fmt.Sprintf("debe ser mayor a 0 y a lo sumo %d", MaxNearbyRadiusKm))
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultNearbyLimit
+	}
+	if filter.Limit < 1 || filter.Limit > MaxNearbyLimit {
+		errs.add("limit", fmt.Sprintf("debe estar entre 1 y %d", MaxNearbyLimit))
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+
+	filter.Latitude, filter.Longitude = *search.Latitude, *search.Longitude
+	addresses, err := s.Repo.NearbyAddresses(ctx, filter)
+	if addresses == nil && err == nil {
+		addresses = []domain.AddressDistance{}
+	}
+	return addresses, err
+}
+
+// NearestAddress devuelve la dirección activa geocodificada del usuario más cercana al punto,
+// p. ej. para elegir la dirección de entrega. ErrNoGeocodedAddress si no tiene ninguna.
+func (s *AddressService) NearestAddress(ctx context.Context, userId string, lat, lng *float64) (*domain.AddressDistance, error) {
+	var errs fieldErrors
+	validatePoint(&errs, lat, lng)
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+
+	profile, err := s.profileOf(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	nearest, err := s.Repo.NearestAddress(ctx, profile.ProfileID, *lat, *lng)
+	if err != nil {
+		return nil, err
+	}
+	if nearest == nil {
+		return nil, ErrNoGeocodedAddress
+	}
+	return nearest, nil
+}
+
+// validatePoint valida que las coordenadas estén presentes y en rango.
+func validatePoint(errs *fieldErrors, lat, lng *float64) {
+	switch {
+	case lat == nil:
+		errs.add("lat", "es obligatorio")
+	case *lat < -90 || *lat > 90:
+		errs.add("lat", "debe estar entre -90 y 90")
+	}
+	switch {
+	case lng == nil:
+		errs.add("lng", "es obligatorio")
+	case *lng < -180 || *lng > 180:
+		errs.add("lng", "debe estar entre -180 y 180")
+	}
+}
+
+// geocode encola la dirección para geocodificarla, si hay un GeocodingService.
+func (s *AddressService) geocode(ctx context.Context, address *domain.Address) {
+	if s.Geocoding != nil {
+		s.Geocoding.Enqueue(ctx, *address)
+	}
+}
+
+// validate valida la dirección (ver validateAddress) y que su código postal y localidad estén
+// en el catálogo; la localidad se guarda con el nombre del catálogo.
+func (s *AddressService) validate(ctx context.Context, address *domain.Address) error {
+	if err := validateAddress(address); err != nil {
+		return err
+	}
+	if s.Localities == nil || address.Country != domain.CountryArgentina {
+		return nil
+	}
+
+	cp := address.CP
+	if len(cp) == 8 {
+		cp = cp[1:5]
+	}
+	locality, err := s.Localities.FindLocality(ctx, address.Province, cp, address.Locality)
+	if err != nil {
+		return fmt.Errorf("error al buscar la localidad: %w", err)
+	}
+	if locality == nil {
+		return invalid("locality", fmt.Sprintf("no corresponde al código postal %s en la provincia indicada", cp))
+	}
+	address.Locality = locality.Name
+	return nil
+}
+
+// validateAddress valida calle y número y los datos geográficos de la dirección (ver
+// geo.ValidateAddress), que se guardan normalizados.
+func validateAddress(address *domain.Address) error {
+	var errs fieldErrors
+	if address.Street == "" {
+		errs.add("street", "es obligatorio")
+	}
+	if address.Number == 0 {
+		errs.add("number", "es obligatorio")
+	}
+	errs = append(errs, geo.ValidateAddress(address)...)
+	return errs.err()
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"profilego/internal/domain"
+	"profilego/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// cascadedAddresses simula el ON DELETE CASCADE de PostgreSQL: las direcciones de los perfiles
+// eliminados ya no están en address, sólo en su historial.
+type cascadedAddresses struct {
+	*repository.MemoryAddressRepository
+	profiles *repository.MemoryProfileRepository
+}
+
+func (r cascadedAddresses) GetAddressByID(ctx context.Context, addressID uuid.UUID) (*domain.Address, error) {
+	address, err := r.MemoryAddressRepository.GetAddressByID(ctx, addressID)
+	if err != nil || address == nil {
+		return address, err
+	}
+	if profile, _ := r.profiles.GetByProfileID(ctx, address.IdProfile); profile == nil {
+		return nil, nil
+	}
+	return address, nil
+}
+
+func TestAddressHistoryOwner(t *testing.T) {
+	ctx := context.Background()
+	profiles := repository.NewMemoryProfileRepository()
+	addresses := cascadedAddresses{repository.NewMemoryAddressRepository(), profiles}
+	s := NewAddressService(addresses, profiles)
+
+	newProfile := func(userID string) *domain.Profile {
+		p := domain.NewProfile(userID, "Ana", "ana@example.com", "1144445555", "", "", "", nil, 0, 0, nil)
+		if err := profiles.CreateProfile(ctx, p); err != nil {
+			t.Fatalf("CreateProfile: %v", err)
+		}
+		return p
+	}
+	newAddress := func(actor string, profile *domain.Profile) uuid.UUID {
+		a := domain.NewAddress("C1425ABC", "Av. Santa Fe", nil, false, true, 1234, profile.ProfileID)
+		a.Province, a.Locality = "AR-C", "Palermo"
+		if err := addresses.CreateAddress(domain.WithActor(ctx, actor), a); err != nil {
+			t.Fatalf("CreateAddress: %v", err)
+		}
+		return a.AddressID
+	}
+
+	live := newProfile("u1")
+	liveAddress := newAddress("u1", live)
+	deleted := newProfile("u2")
+	deletedAddress := newAddress("u2", deleted)
+	byAdmin := newAddress("admin", deleted)
+	if err := profiles.DeleteProfile(ctx, deleted.ProfileID, 0); err != nil {
+		t.Fatalf("DeleteProfile: %v", err)
+	}
+	newProfile("u2") // el usuario volvió a crear su perfil
+
+	tests := []struct {
+		name      string
+		userID    string
+		addressID uuid.UUID
+		wantErr   error
+	}{
+		{"dirección del perfil del usuario", "u1", liveAddress, nil},
+		{"dirección de otro usuario", "u2", liveAddress, ErrAddressNotFound},
+		{"perfil eliminado, el usuario que la dio de alta", "u2", deletedAddress, nil},
+		{"perfil eliminado, otro usuario", "u1", deletedAddress, ErrAddressNotFound},
+		{"perfil eliminado, dada de alta por otro usuario", "u2", byAdmin, ErrAddressNotFound},
+		{"dirección inexistente", "u1", uuid.New(), ErrAddressNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revisions, err := s.AddressHistory(ctx, tt.userID, tt.addressID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddressHistory: esperaba %v, obtuve %v", tt.wantErr, err)
+			}
+			_, errAsOf := s.AddressAsOf(ctx, tt.userID, tt.addressID, time.Now())
+			if !errors.Is(errAsOf, tt.wantErr) {
+				t.Fatalf("AddressAsOf: esperaba %v, obtuve %v", tt.wantErr, errAsOf)
+			}
+			if tt.wantErr == nil && (len(revisions) != 1 || revisions[0].Address.AddressID != tt.addressID) {
+				t.Fatalf("historial inesperado: %+v", revisions)
+			}
+		})
+	}
+}
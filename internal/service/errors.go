@@ -0,0 +1,53 @@
+package service
+
+import (
+	"profilego/internal/domain"
+	"profilego/internal/repository"
+)
+
+// Errores de negocio. Son *domain.Error, así que los handlers los traducen por categoría
+// y código sin comparar mensajes.
+var (
+	ErrProfileNotFound = repository.ErrUserNotFound
+	ErrProfileExists   = repository.ErrProfileExists
+	ErrAddressNotFound = repository.ErrAddressNotFound
+	// ErrAddressNotAtTime indica que la dirección todavía no existía en el instante pedido.
+	ErrAddressNotAtTime = domain.NotFound("address_not_found_at_time", "la dirección no existía en ese momento")
+	// ErrNoGeocodedAddress indica que el perfil no tiene direcciones activas con coordenadas.
+	ErrNoGeocodedAddress = domain.NotFound("geocoded_address_not_found", "el perfil no tiene direcciones geocodificadas")
+	// ErrVersionConflict indica que la versión esperada (If-Match) no es la vigente.
+	ErrVersionConflict = repository.ErrVersionConflict
+	// ErrProfileNotOwned se devuelve cuando el perfil encontrado no es del userId pedido.
+	ErrProfileNotOwned = domain.Forbidden("profile_not_owned", "perfil no pertenece al usuario")
+	// ErrPublisherUnavailable se devuelve si no se pudo publicar un evento en RabbitMQ.
+	ErrPublisherUnavailable = domain.Upstream("publisher_unavailable", "error al publicar mensaje en RabbitMQ", nil)
+)
+
+// fieldErrors acumula los campos inválidos de una validación para informarlos todos juntos.
+type fieldErrors []domain.FieldError
+
+func (f *fieldErrors) add(field, message string) {
+	*f = append(*f, domain.FieldError{Field: field, Message: message})
+}
+
+// addNull registra un campo obligatorio que vino en null en un patch.
+func (f *fieldErrors) addNull(field string, null bool) {
+	if null {
+		f.add(field, "no puede ser null")
+	}
+}
+
+// err devuelve el error de validación, o nil si no hubo campos inválidos.
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	return domain.Validation("validation_failed", "los datos enviados no son válidos", f...)
+}
+
+// invalid crea un error de validación de un solo campo.
+func invalid(field, message string) error {
+	var f fieldErrors
+	f.add(field, message)
+	return f.err()
+}
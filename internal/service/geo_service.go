@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"profilego/internal/domain"
+	"profilego/internal/geo"
+	"profilego/internal/repository"
+)
+
+const (
+	// DefaultLocalityLimit es la cantidad de localidades por página si no se indica otra.
+	DefaultLocalityLimit = 20
+	// MaxLocalityLimit es la cantidad máxima de localidades por página.
+	MaxLocalityLimit = 100
+)
+
+// GeoService maneja las búsquedas en el catálogo de localidades.
+type GeoService struct {
+	Localities repository.LocalityStore
+}
+
+// NewGeoService crea una nueva instancia de GeoService.
+func NewGeoService(localities repository.LocalityStore) *GeoService {
+	return &GeoService{Localities: localities}
+}
+
+// LocalitySearch es una búsqueda de autocompletado: CP es lo escrito del código postal (4
+// dígitos o CPA, completo o no) y Query lo escrito del nombre de la localidad.
+type LocalitySearch struct {
+	CP       string
+	Query    string
+	Province string
+	Limit    int // 0 = DefaultLocalityLimit
+	Offset   int
+}
+
+// SearchLocalities busca localidades por prefijo de código postal y de alguna palabra del
+// nombre, sin distinguir mayúsculas ni acentos. Devuelve la página pedida y el total.
+func (s *GeoService) SearchLocalities(ctx context.Context, search LocalitySearch) ([]domain.Locality, int, error) {
+	var errs fieldErrors
+	filter := domain.LocalityFilter{Query: geo.SearchKey(search.Query), Limit: search.Limit, Offset: search.Offset}
+
+	province, digits, ok := geo.PostalCodePrefix(search.CP)
+	if !ok {
+		errs.add("cp", "debe ser el comienzo de un código de 4 dígitos o de un CPA")
+	}
+	filter.CP = digits
+	if search.Province != "" {
+		p, found := geo.FindProvince(strings.ToUpper(search.Province))
+		switch {
+		case !found:
+			errs.add("province", "no es un código de provincia válido (ISO 3166-2:AR, p. ej. AR-C)")
+		case province != "" && province != p.Code:
+			errs.add("province", "no corresponde a la letra del CPA")
+		}
+		province = p.Code
+	}
+	filter.Province = province
+	if ok && filter.CP == "" && filter.Query == "" && filter.Province == "" {
+		errs.add("q", "indicar cp, q o province")
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = DefaultLocalityLimit
+	}
+	if filter.Limit < 1 || filter.Limit > MaxLocalityLimit {
+		errs.add("limit", "debe estar entre 1 y 100")
+	}
+	if filter.Offset < 0 {
+		errs.add("offset", "no puede ser negativo")
+	}
+	if err := errs.err(); err != nil {
+		return nil, 0, err
+	}
+	return s.Localities.SearchLocalities(ctx, filter)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"profilego/internal/domain"
+	"profilego/internal/geo"
+	"profilego/internal/repository"
+	"profilego/pkg/logger"
+)
+
+// regeocodeBatch es la cantidad de direcciones pendientes que RegeocodePending lee por vez.
+const regeocodeBatch = 100
+
+// GeocodingService geocodifica direcciones en segundo plano. AddressService encola cada
+// dirección nueva o modificada y un worker la geocodifica y guarda las coordenadas.
+// Si la cola está llena o el proveedor falla, la dirección queda pendiente y se completa con
+// RegeocodePending (`profilego geo regeocode`).
+type GeocodingService struct {
+	Repo     repository.AddressStore
+	Geocoder geo.Geocoder
+
+	mu      sync.Mutex
+	jobs    chan domain.Address
+	stopped bool
+	done    chan struct{}
+}
+
+// NewGeocodingService crea el servicio con una cola de hasta queueSize direcciones.
+func NewGeocodingService(repo repository.AddressStore, geocoder geo.Geocoder, queueSize int) *GeocodingService {
+	return &GeocodingService{
+		Repo:     repo,
+		Geocoder: geocoder,
+		jobs:     make(chan domain.Address, queueSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start inicia el worker que procesa la cola.
+func (s *GeocodingService) Start() {
+	go func() {
+		defer close(s.done)
+		for address := range s.jobs {
+			ctx := logger.WithContext(context.Background(), slog.Default().With("address_id", address.AddressID))
+			if err := s.Geocode(ctx, address); err != nil {
+				logger.FromContext(ctx).Warn("no se pudo geocodificar la dirección", "error", err)
+			}
+		}
+	}()
+}
+
+// Stop deja de aceptar direcciones y espera a que se procesen las encoladas.
+func (s *GeocodingService) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.jobs)
+	}
+	s.mu.Unlock()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Enqueue encola la dirección para geocodificarla sin bloquear.
+func (s *GeocodingService) Enqueue(ctx context.Context, address domain.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		logger.FromContext(ctx).Warn("geocodificación detenida, la dirección queda pendiente", "address_id", address.AddressID)
+		return
+	}
+	select {
+	case s.jobs <- address:
+	default:
+		logger.FromContext(ctx).Warn("cola de geocodificación llena, la dirección queda pendiente", "address_id", address.AddressID)
+	}
+}
+
+// Geocode geocodifica la dirección y guarda el resultado si no cambió desde entonces (si
+// cambió, la versión nueva ya está pendiente).
+func (s *GeocodingService) Geocode(ctx context.Context, address domain.Address) error {
+	geocode, err := s.Geocoder.Geocode(ctx, address)
+	if err != nil {
+		return err
+	}
+	err = s.Repo.SetGeocode(ctx, address.AddressID, address.Version, geocode)
+	if errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrAddressNotFound) {
+		return nil
+	}
+	return err
+}
+
+// RegeocodePending geocodifica todas las direcciones activas pendientes, o todas las activas si
+// all es true, y devuelve cuántas procesó. Se detiene ante la primera falla del proveedor.
+func (s *GeocodingService) RegeocodePending(ctx context.Context, all bool) (int, error) {
+	if all {
+		if _, err := s.Repo.ResetGeocodes(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	processed := 0
+	for {
+		pending, err := s.Repo.PendingGeocodes(ctx, regeocodeBatch)
+		if err != nil || len(pending) == 0 {
+			return processed, err
+		}
+		for _, address := range pending {
+			if err := s.Geocode(ctx, address); err != nil {
+				return processed, err
+			}
+			processed++
+		}
+	}
+}
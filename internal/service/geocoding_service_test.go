@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"profilego/internal/domain"
+	"profilego/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// fakeGeocoder ubica todas las direcciones en el mismo punto salvo las calles de fail, que
+// responden error. Si block no es nil, cada consulta espera a que se cierre.
+type fakeGeocoder struct {
+	mu    sync.Mutex
+	calls int
+	fail  map[string]bool
+	block chan struct{}
+}
+
+func (g *fakeGeocoder) Geocode(ctx context.Context, address domain.Address) (domain.Geocode, error) {
+	if g.block != nil {
+		<-g.block
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.calls++
+	if g.fail[address.Street] {
+		return domain.Geocode{}, errors.New("proveedor caído")
+	}
+	return domain.Geocode{Latitude: -34.6, Longitude: -58.4, Quality: domain.GeocodeExact}, nil
+}
+
+// newPendingAddresses guarda una dirección pendiente de geocodificar por calle.
+func newPendingAddresses(t *testing.T, repo *repository.MemoryAddressRepository, streets ...string) []domain.Address {
+	t.Helper()
+	profileID := uuid.New()
+	addresses := make([]domain.Address, len(streets))
+	for i, street := range streets {
+		a := domain.NewAddress("C1425ABC", street, nil, false, true, 1000+i, profileID)
+		a.Province, a.Locality = "AR-C", "Palermo"
+		a.ResetGeocode()
+		// PendingGeocodes ordena por updatedDate: se respeta el orden de streets
+		a.UpdatedDate = a.UpdatedDate.Add(time.Duration(i) * time.Millisecond)
+		if err := repo.CreateAddress(context.Background(), a); err != nil {
+			t.Fatalf("CreateAddress: %v", err)
+		}
+		addresses[i] = *a
+	}
+	return addresses
+}
+
+func geocodeQualityOf(t *testing.T, repo *repository.MemoryAddressRepository, addressID uuid.UUID) domain.GeocodeQuality {
+	t.Helper()
+	a, err := repo.GetAddressByID(context.Background(), addressID)
+	if err != nil || a == nil {
+		t.Fatalf("GetAddressByID: %v", err)
+	}
+	return a.GeocodeQuality
+}
+
+func TestGeocodingServiceQueue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("con la cola llena la dirección queda pendiente", func(t *testing.T) {
+		repo := repository.NewMemoryAddressRepository()
+		addresses := newPendingAddresses(t, repo, "Av. Santa Fe", "Av. Córdoba")
+		s := NewGeocodingService(repo, &fakeGeocoder{}, 1)
+
+		// Sin el worker andando la segunda no entra en la cola
+		s.Enqueue(ctx, addresses[0])
+		s.Enqueue(ctx, addresses[1])
+		s.Start()
+		if err := s.Stop(ctx); err != nil {
+			t.Fatalf("Stop: %v", err)
+		}
+		if got := geocodeQualityOf(t, repo, addresses[0].AddressID); got != domain.GeocodeExact {
+			t.Fatalf("la dirección encolada debía geocodificarse antes de Stop: %s", got)
+		}
+		if got := geocodeQualityOf(t, repo, addresses[1].AddressID); got != domain.GeocodePending {
+			t.Fatalf("la dirección que no entró debía quedar pendiente: %s", got)
+		}
+
+		// RegeocodePending completa la que quedó pendiente
+		processed, err := s.RegeocodePending(ctx, false)
+		if err != nil || processed != 1 {
+			t.Fatalf("RegeocodePending = %d, %v; esperaba 1", processed, err)
+		}
+		if got := geocodeQualityOf(t, repo, addresses[1].AddressID); got != domain.GeocodeExact {
+			t.Fatalf("RegeocodePending debía geocodificarla: %s", got)
+		}
+	})
+
+	t.Run("después de Stop no encola", func(t *testing.T) {
+		repo := repository.NewMemoryAddressRepository()
+		addresses := newPendingAddresses(t, repo, "Av. Santa Fe")
+		geocoder := &fakeGeocoder{}
+		s := NewGeocodingService(repo, geocoder, 1)
+		s.Start()
+		if err := s.Stop(ctx); err != nil {
+			t.Fatalf("Stop: %v", err)
+		}
+		s.Enqueue(ctx, addresses[0])
+		if err := s.Stop(ctx); err != nil {
+			t.Fatalf("un segundo Stop no debe fallar: %v", err)
+		}
+		if geocoder.calls != 0 || geocodeQualityOf(t, repo, addresses[0].AddressID) != domain.GeocodePending {
+			t.Fatal("la dirección debía quedar pendiente")
+		}
+	})
+
+	t.Run("Stop respeta el vencimiento del contexto", func(t *testing.T) {
+		repo := repository.NewMemoryAddressRepository()
+		addresses := newPendingAddresses(t, repo, "Av. Santa Fe")
+		geocoder := &fakeGeocoder{block: make(chan struct{})}
+		s := NewGeocodingService(repo, geocoder, 1)
+		s.Start()
+		s.Enqueue(ctx, addresses[0])
+
+		stopCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if err := s.Stop(stopCtx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("esperaba context.DeadlineExceeded con el proveedor bloqueado, obtuve %v", err)
+		}
+		close(geocoder.block)
+		if err := s.Stop(ctx); err != nil {
+			t.Fatalf("Stop: %v", err)
+		}
+		if got := geocodeQualityOf(t, repo, addresses[0].AddressID); got != domain.GeocodeExact {
+			t.Fatalf("la dirección encolada debía terminar de geocodificarse: %s", got)
+		}
+	})
+}
+
+func TestGeocodingServiceGeocode(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryAddressRepository()
+	addresses := newPendingAddresses(t, repo, "Av. Santa Fe")
+	s := NewGeocodingService(repo, &fakeGeocoder{}, 1)
+
+	// La dirección cambió después de encolarla: el resultado viejo se descarta
+	stale := addresses[0]
+	if err := repo.SetMainAddress(ctx, stale.AddressID, stale.IdProfile, 0); err != nil {
+		t.Fatalf("SetMainAddress: %v", err)
+	}
+	if err := s.Geocode(ctx, stale); err != nil {
+		t.Fatalf("una versión vieja no es un error: %v", err)
+	}
+	if got := geocodeQualityOf(t, repo, stale.AddressID); got != domain.GeocodePending {
+		t.Fatalf("no debía guardar el resultado de la versión vieja: %s", got)
+	}
+
+	missing := stale
+	missing.AddressID = uuid.New()
+	if err := s.Geocode(ctx, missing); err != nil {
+		t.Fatalf("una dirección borrada no es un error: %v", err)
+	}
+}
+
+func TestRegeocodePending(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("se detiene ante la primera falla del proveedor", func(t *testing.T) {
+		repo := repository.NewMemoryAddressRepository()
+		addresses := newPendingAddresses(t, repo, "Av. Santa Fe", "Av. Córdoba", "Av. Corrientes")
+		s := NewGeocodingService(repo, &fakeGeocoder{fail: map[string]bool{"Av. Córdoba": true}}, 1)
+
+		processed, err := s.RegeocodePending(ctx, false)
+		if err == nil || processed != 1 {
+			t.Fatalf("RegeocodePending = %d, %v; esperaba 1 y el error del proveedor", processed, err)
+		}
+		want := []domain.GeocodeQuality{domain.GeocodeExact, domain.GeocodePending, domain.GeocodePending}
+		for i, a := range addresses {
+			if got := geocodeQualityOf(t, repo, a.AddressID); got != want[i] {
+				t.Fatalf("%s quedó %s, esperaba %s", a.Street, got, want[i])
+			}
+		}
+	})
+
+	t.Run("all vuelve a geocodificar las ya resueltas", func(t *testing.T) {
+		repo := repository.NewMemoryAddressRepository()
+		newPendingAddresses(t, repo, "Av. Santa Fe", "Av. Córdoba")
+		geocoder := &fakeGeocoder{}
+		s := NewGeocodingService(repo, geocoder, 1)
+
+		if processed, err := s.RegeocodePending(ctx, false); err != nil || processed != 2 {
+			t.Fatalf("RegeocodePending = %d, %v; esperaba 2", processed, err)
+		}
+		if processed, err := s.RegeocodePending(ctx, false); err != nil || processed != 0 {
+			t.Fatalf("sin pendientes RegeocodePending = %d, %v", processed, err)
+		}
+		if processed, err := s.RegeocodePending(ctx, true); err != nil || processed != 2 {
+			t.Fatalf("RegeocodePending(all) = %d, %v; esperaba 2", processed, err)
+		}
+		if geocoder.calls != 4 {
+			t.Fatalf("consultas al proveedor %d, esperaba 4", geocoder.calls)
+		}
+	})
+}
@@ -5,14 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"strconv"
 
 	//"strings"
 	"time"
 
 	"profilego/internal/domain"
+	"profilego/internal/fiscal"
+	"profilego/internal/metrics"
 	"profilego/internal/repository"
+	"profilego/pkg/logger"
 
 	//"profilego/internal/transport/mq"
 
@@ -20,24 +22,26 @@ import (
 )
 
 type Publisher interface { //Interfaz para comunicarme con publisher y romper la dependencia directa (error de ciclo infinito de importaciones)
-	PublishProfilePoints(profileid string, profilepoints int) error
-	PublishMessage(queueName string, message []byte) error
+	PublishProfilePoints(ctx context.Context, profileid string, profilepoints int) error
+	PublishMessage(ctx context.Context, queueName string, message []byte) error
 }
 
 type ProfileService struct {
-	Repo      repository.ProfileRepository
+	Repo      repository.ProfileStore
 	Publisher Publisher // Usa la interfaz en lugar de `mq`
+	Queue     string    // Cola donde se publican los eventos de puntos
 }
 
 // NewProfileService crea una nueva instancia de ProfileService.
-func NewProfileService(repo repository.ProfileRepository) *ProfileService {
+func NewProfileService(repo repository.ProfileStore) *ProfileService {
 	return &ProfileService{Repo: repo}
 }
 
-func NewProfileRabbitService(repo repository.ProfileRepository, publisher Publisher) *ProfileService {
+func NewProfileRabbitService(repo repository.ProfileStore, publisher Publisher, queue string) *ProfileService {
 	return &ProfileService{
 		Repo:      repo,
 		Publisher: publisher,
+		Queue:     queue,
 	}
 }
 
@@ -46,27 +50,18 @@ func (s *ProfileService) CreateProfile(ctx context.Context, userId string, profi
 	// PRIMERO VALIDO QUE EXISTA EL USUARIO
 	existingProfile, err := s.Repo.GetByUserID(ctx, userId)
 	if err != nil {
-		//log.Println(" Error al buscar userId:", err)
-		return errors.New("error al buscar el perfil del usuario")
+		return fmt.Errorf("error al buscar el perfil del usuario: %w", err)
 	}
 
-	// 🔹 Si ya existe un perfil para este `userId`, rechazar la creación
+	// Si ya existe un perfil para este `userId`, rechazar la creación
 	if existingProfile != nil {
-		//log.Println("El usuario ya tiene un perfil - userId:", userId)
-		return errors.New("el usuario ya tiene un perfil creado")
+		return ErrProfileExists
 	}
 
 	//DESPUES LAS VALIDACIONES BASICAS DE LOS DATOS
 
-	if profile.ProfileName == "" || profile.ProfileMail == "" {
-		return errors.New("el nombre y el email del perfil son obligatorios")
-	}
-	if profile.Phone == "" || len(profile.Phone) < 10 {
-		return errors.New("el número de telefono es obligatorio y debe tener al menos 10 digitos")
-	}
-
-	if _, err := strconv.Atoi(profile.Phone); err != nil {
-		return errors.New("el numero de teléfono debe ser numérico")
+	if err := validateBasicData(profile); err != nil {
+		return err
 	}
 
 	profile.ProfileID = uuid.New()
@@ -82,13 +77,11 @@ func (s *ProfileService) GetProfile(ctx context.Context, userId string) (*domain
 
 	existingProfile, err := s.Repo.GetByUserID(ctx, userId)
 	if err != nil {
-		//log.Println(" Error al buscar userId:", err)
-		return nil, errors.New("error al buscar el perfil del usuario")
+		return nil, fmt.Errorf("error al buscar el perfil del usuario: %w", err)
 	}
 
 	if existingProfile == nil {
-		//log.Println("⚠ No se encontró un perfil con userId:", userId)
-		return nil, errors.New("usuario no encontrado")
+		return nil, ErrProfileNotFound
 	}
 
 	return s.Repo.GetProfile(ctx, userId)
@@ -104,35 +97,27 @@ func (s *ProfileService) UpdateProfile(ctx context.Context, userId string, profi
 	//VALIDO QUE EXISTA EL USERID ANTES DE ACTUALIZAR EL REGISTRO
 	existingProfile, err := s.Repo.GetByUserID(ctx, userId)
 	if err != nil {
-		//log.Println("❌ Error al buscar userId:", err)
-		return errors.New("error al buscar el perfil del usuario")
+		return fmt.Errorf("error al buscar el perfil del usuario: %w", err)
 	}
 
 	if existingProfile == nil {
-		//log.Println("⚠ No se encontró un perfil con userId:", userId)
-		return errors.New("usuario no encontrado")
+		return ErrProfileNotFound
 	}
 	// FIN VALIDA USER
 
-	if profile.ProfileName == "" || profile.ProfileMail == "" {
-		return errors.New("el nombre y el email del perfil son obligatorios")
-	}
-	if profile.Phone == "" || len(profile.Phone) < 10 {
-		return errors.New("el número de telefono es obligatorio y debe tener al menos 10 digitos")
-	}
-
-	if _, err := strconv.Atoi(profile.Phone); err != nil {
-		return errors.New("el numero de teléfono debe ser numérico")
+	if err := validateBasicData(profile); err != nil {
+		return err
 	}
-	//log.Println("⏳ Iniciando actualización del perfil:", profile.ProfileID)
 
+	// Se actualiza siempre el perfil del usuario, nunca el profileId que venga en el body
+	profile.ProfileID = existingProfile.ProfileID
 	profile.UpdatedDate = time.Now()
 
 	err = s.Repo.UpdateProfile(ctx, userId, profile)
 	if err != nil {
-		log.Println("❌ Error al actualizar el perfil en la base de datos:", err)
+		logger.FromContext(ctx).Error("error al actualizar el perfil en la base de datos", "error", err)
 	} else {
-		log.Println("✅ Perfil actualizado correctamente:", profile.ProfileID)
+		logger.FromContext(ctx).Info("perfil actualizado", "profile_id", profile.ProfileID)
 	}
 
 	return err
@@ -147,36 +132,26 @@ func (s *ProfileService) UpdateFiscalData(ctx context.Context, userId string, pr
 	//VALIDO QUE EXISTA EL USERID ANTES DE ACTUALIZAR EL REGISTRO
 	existingProfile, err := s.Repo.GetByUserID(ctx, userId)
 	if err != nil {
-		//log.Println("❌ Error al buscar userId:", err)
-		return errors.New("error al buscar el perfil del usuario")
+		return fmt.Errorf("error al buscar el perfil del usuario: %w", err)
 	}
 
 	if existingProfile == nil {
-		//log.Println("⚠ No se encontró un perfil con userId:", userId)
-		return errors.New("usuario no encontrado")
+		return ErrProfileNotFound
 	}
 	// Validación básica
-	if profile.CUIL == "" || profile.FiscalAdress == "" || profile.FiscalCondition == "" || profile.IIBB == "" {
-		return errors.New("los datos fiscales son obligatorios")
-	}
-	if len(profile.CUIL) < 11 {
-		return errors.New("el CUIL debe tener al menos 11 caracteres")
-	}
-
-	if _, err := strconv.Atoi(profile.CUIL); err != nil {
-		return errors.New("el CUIL debe ser un valor numérico")
-	}
-	if _, err := strconv.Atoi(profile.IIBB); err != nil {
-		return errors.New("IIBB debe ser un valor numérico")
+	if err := validateFiscalData(profile); err != nil {
+		return err
 	}
-	//log.Println("⏳ Iniciando actualización del perfil:", profile.ProfileID)
 
 	profile.UpdatedDate = time.Now()
 	err = s.Repo.UpdateFiscalData(ctx, userId, profile)
+	if errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrProfileNotFound) {
+		return err
+	}
 	if err != nil {
-		log.Println("❌ Error al actualizar el perfil en la base de datos:", err)
+		logger.FromContext(ctx).Error("error al actualizar los datos fiscales en la base de datos", "error", err)
 	} else {
-		log.Println("✅ Perfil actualizado correctamente:", profile.ProfileID)
+		logger.FromContext(ctx).Info("datos fiscales actualizados", "profile_id", profile.ProfileID)
 	}
 
 	return err
@@ -188,25 +163,135 @@ func (s *ProfileService) UpdateProfileImage(ctx context.Context, userId string,
 	//VALIDO QUE EXISTA EL USERID ANTES DE ACTUALIZAR EL REGISTRO
 	existingProfile, err := s.Repo.GetByUserID(ctx, userId)
 	if err != nil {
-		//log.Println("❌ Error al buscar userId:", err)
-		return errors.New("error al buscar el perfil del usuario")
+		return fmt.Errorf("error al buscar el perfil del usuario: %w", err)
 	}
 
 	if existingProfile == nil {
-		//log.Println("⚠ No se encontró un perfil con userId:", userId)
-		return errors.New("usuario no encontrado")
+		return ErrProfileNotFound
 	}
 
 	//Validacion básica
 	if profileImage == "" {
-		return errors.New("debe subir un archivo")
+		return invalid("profileImage", "debe subir un archivo")
+	}
+	if _, err := uuid.Parse(profileID); err != nil {
+		return invalid("profileId", "debe ser un UUID")
 	}
 	return s.Repo.UpdateProfileImage(ctx, userId, profileID, profileImage)
 }
 
 // DeleteProfile elimina un perfil por su ID.
 func (s *ProfileService) DeleteProfile(ctx context.Context, profileID uuid.UUID) error {
-	return s.Repo.DeleteProfile(ctx, profileID)
+	return s.Repo.DeleteProfile(ctx, profileID, 0)
+}
+
+// DeleteProfileByUser elimina el perfil del usuario junto con sus direcciones.
+// Con version distinto de 0 sólo lo elimina si sigue en esa versión.
+func (s *ProfileService) DeleteProfileByUser(ctx context.Context, userId string, version int) error {
+	existingProfile, err := s.Repo.GetByUserID(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("error al buscar el perfil del usuario: %w", err)
+	}
+	if existingProfile == nil {
+		return ErrProfileNotFound
+	}
+	return s.Repo.DeleteProfile(ctx, existingProfile.ProfileID, version)
+}
+
+// PatchProfile aplica un JSON Merge Patch a los datos básicos del perfil. El resultado se valida
+// igual que UpdateProfile y sólo se persisten los campos que efectivamente cambian.
+// Con version distinto de 0 (If-Match) falla con ErrVersionConflict si el perfil está en otra versión;
+// en cualquier caso la escritura se condiciona a la versión leída.
+func (s *ProfileService) PatchProfile(ctx context.Context, userId string, version int, patch domain.ProfilePatch) (*domain.Profile, error) {
+	profile, err := s.Repo.GetProfile(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar el perfil del usuario: %w", err)
+	}
+	if profile == nil {
+		return nil, ErrProfileNotFound
+	}
+	if version != 0 && profile.Version != version {
+		return nil, ErrVersionConflict
+	}
+	var nulls fieldErrors
+	nulls.addNull("profileName", patch.ProfileName.Null)
+	nulls.addNull("profileMail", patch.ProfileMail.Null)
+	nulls.addNull("phone", patch.Phone.Null)
+	if err := nulls.err(); err != nil {
+		return nil, err
+	}
+
+	merged := *profile
+	var changed domain.ProfilePatch
+	if patch.ProfileName.Set && patch.ProfileName.Value != profile.ProfileName {
+		merged.ProfileName, changed.ProfileName = patch.ProfileName.Value, patch.ProfileName
+	}
+	if patch.ProfileMail.Set && patch.ProfileMail.Value != profile.ProfileMail {
+		merged.ProfileMail, changed.ProfileMail = patch.ProfileMail.Value, patch.ProfileMail
+	}
+	if patch.Phone.Set && patch.Phone.Value != profile.Phone {
+		merged.Phone, changed.Phone = patch.Phone.Value, patch.Phone
+	}
+	switch {
+	case patch.ProfileImage.Null && profile.ProfileImage != nil:
+		merged.ProfileImage, changed.ProfileImage = nil, patch.ProfileImage
+	case patch.ProfileImage.Set && !patch.ProfileImage.Null:
+		if patch.ProfileImage.Value == "" {
+			return nil, invalid("profileImage", "no puede estar vacía; para borrarla enviar null")
+		}
+		if profile.ProfileImage == nil || *profile.ProfileImage != patch.ProfileImage.Value {
+			image := patch.ProfileImage.Value
+			merged.ProfileImage, changed.ProfileImage = &image, patch.ProfileImage
+		}
+	}
+
+	if err := validateBasicData(&merged); err != nil {
+		return nil, err
+	}
+	if changed == (domain.ProfilePatch{}) {
+		return profile, nil
+	}
+
+	merged.UpdatedDate = time.Now()
+	if err := s.Repo.PatchProfile(ctx, profile.ProfileID, profile.Version, changed, merged.UpdatedDate); err != nil {
+		return nil, err
+	}
+	merged.Version++
+	return &merged, nil
+}
+
+// validateBasicData valida nombre, email y teléfono del perfil.
+func validateBasicData(profile *domain.Profile) error {
+	var errs fieldErrors
+	if profile.ProfileName == "" {
+		errs.add("profileName", "es obligatorio")
+	}
+	if profile.ProfileMail == "" {
+		errs.add("profileMail", "es obligatorio")
+	}
+	if len(profile.Phone) < 10 {
+		errs.add("phone", "es obligatorio y debe tener al menos 10 dígitos")
+	} else if _, err := strconv.Atoi(profile.Phone); err != nil {
+		errs.add("phone", "debe ser numérico")
+	}
+	return errs.err()
+}
+
+// validateFiscalData valida CUIL, domicilio fiscal, condición fiscal e IIBB (ver
+// fiscal.ValidateCondition). El CUIL y el número de IIBB se guardan normalizados.
+func validateFiscalData(profile *domain.Profile) error {
+	var errs fieldErrors
+	if profile.FiscalAdress == "" {
+		errs.add("fiscalAdress", "es obligatorio")
+	}
+	cuit, err := fiscal.Parse(profile.CUIL)
+	if err != nil {
+		errs.add("CUIL", err.Error())
+	} else {
+		profile.CUIL = cuit.Number
+	}
+	errs = append(errs, fiscal.ValidateCondition(profile, cuit.Type)...)
+	return errs.err()
 }
 
 // RABBIT
@@ -214,19 +299,17 @@ func (s *ProfileService) UpdateProfilePoints(ctx context.Context, userId string,
 	//VALIDO QUE EXISTA EL USERID ANTES DE ACTUALIZAR EL REGISTRO
 	existingProfile, err := s.Repo.GetByUserID(ctx, userId)
 	if err != nil {
-		log.Println("❌ Error al buscar userId:", err)
-		return errors.New("error al buscar el perfil del usuario")
+		logger.FromContext(ctx).Error("error al buscar el perfil", "user_id", userId, "error", err)
+		return fmt.Errorf("error al buscar el perfil del usuario: %w", err)
 	}
 
 	if existingProfile == nil {
-		//log.Println("⚠ No se encontró un perfil con userId:", userId)
-		return errors.New("usuario no encontrado")
+		return ErrProfileNotFound
 	}
 
 	// Validar que el profileId pertenece al userId
 	if existingProfile.UserID != userId {
-		//log.Println("❌ El profileId no pertenece al userId:", userId)
-		return errors.New("perfil no pertenece al usuario")
+		return ErrProfileNotOwned
 	}
 
 	// Actualizar puntos en la base de datos...
@@ -234,8 +317,11 @@ func (s *ProfileService) UpdateProfilePoints(ctx context.Context, userId string,
 	if err != nil {
 		return err
 	}
+	if profile.ProfilePoints > 0 {
+		metrics.PointsAwardedTotal.Add(float64(profile.ProfilePoints))
+	}
 
-	// 📢 Serializar el mensaje a JSON
+	// Serializar el mensaje a JSON
 	message, err := json.Marshal(map[string]interface{}{
 		"userId":        userId,
 		"profileId":     profile.ProfileID,
@@ -246,18 +332,13 @@ func (s *ProfileService) UpdateProfilePoints(ctx context.Context, userId string,
 	}
 
 	if s.Publisher == nil {
-		//log.Println("❌ ERROR: s.Publisher es nil, la conexión con RabbitMQ no está inicializada")
-		return fmt.Errorf("error: publisher no inicializado")
+		return ErrPublisherUnavailable.Wrap(errors.New("publisher no inicializado"))
 	}
-	// 📢 Publicar evento en RabbitMQ
-	errRabbit := s.Publisher.PublishMessage("direct_profile", message)
-
-	log.Println("❌ ESTA LLEGANDO ACA2?")
-
+	// Publicar evento en RabbitMQ
+	errRabbit := s.Publisher.PublishMessage(ctx, s.Queue, message)
 	if errRabbit != nil {
-		//log.Println("❌ Error: s.Publisher es nil, la conexión no está inicializada")
-		//log.Println(message)
-		return fmt.Errorf("error: publisher no inicializado")
+		logger.FromContext(ctx).Error("error publicando evento de puntos", "queue", s.Queue, "error", errRabbit)
+		return ErrPublisherUnavailable.Wrap(errRabbit)
 	}
 
 	return err
@@ -268,50 +349,37 @@ func (s *ProfileService) UpdateProfileLevel(ctx context.Context, userId string,
 	// Verificar que el usuario existe en la base de datos
 	existingProfile, err := s.Repo.GetByUserID(ctx, userId)
 	if err != nil {
-		log.Println("❌ Error al buscar userId:", err)
-		return errors.New("error al buscar el perfil del usuario")
+		logger.FromContext(ctx).Error("error al buscar el perfil", "user_id", userId, "error", err)
+		return fmt.Errorf("error al buscar el perfil del usuario: %w", err)
 	}
 
 	if existingProfile == nil {
-		log.Println("⚠ No se encontró un perfil con userId:", userId)
-		return errors.New("usuario no encontrado")
+		logger.FromContext(ctx).Warn("no se encontró un perfil", "user_id", userId)
+		return ErrProfileNotFound
 	}
 
 	// Validar que el profileId pertenece al userId
 	if existingProfile.UserID != userId {
-		log.Println("❌ El profileId no pertenece al userId:", userId)
-		return errors.New("perfil no pertenece al usuario")
+		logger.FromContext(ctx).Warn("el perfil no pertenece al usuario", "user_id", userId)
+		return ErrProfileNotOwned
 	}
 
 	// Actualizar nivel en la base de datos...
-	log.Println("📩 Actualizando nivel - userId:", userId, "profileLevel:", profile.ProfileLevel)
+	logger.FromContext(ctx).Info("actualizando nivel", "user_id", userId, "profile_level", profile.ProfileLevel)
 	err = s.Repo.UpdateProfileLevel(ctx, userId, profile)
 	if err != nil {
 		return err
 	}
-
-	// 📢 Serializar el mensaje a JSON Kike comento esta parte para no publicar otro mensaje cuando subio el level y entra en bucle
-	/*message, err := json.Marshal(map[string]interface{}{
-		"userId":       userId,
-		"profileId":    profile.ProfileID,
-		"profileLevel": profile.ProfileLevel,
-	})
-	if err != nil {
-		return err
-	}*/
+	if profile.ProfileLevel > existingProfile.ProfileLevel {
+		metrics.LevelUpsTotal.Add(float64(profile.ProfileLevel - existingProfile.ProfileLevel))
+	}
 
 	if s.Publisher == nil {
-		log.Println("❌ ERROR: RabbitMQ no está inicializado")
-		return fmt.Errorf("error: publisher no inicializado")
+		logger.FromContext(ctx).Error("publisher de RabbitMQ no inicializado")
+		return ErrPublisherUnavailable.Wrap(errors.New("publisher no inicializado"))
 	}
 
-	// 📢 Publicar evento en RabbitMQ   Kike comento esta parte para no publicar otro mensaje cuando subio el level y entra en bucle
-	//errRabbit := s.Publisher.PublishMessage("direct_profile", message)
-
-	/*if errRabbit != nil {
-		//log.Println("❌ Error publicando en RabbitMQ")
-		return fmt.Errorf("error al publicar mensaje en RabbitMQ")
-	}*/
-
+	// El cambio de nivel no se publica: el consumidor de puntos lo volvería a procesar y
+	// entraría en bucle.
 	return nil
 }
@@ -1,11 +1,12 @@
 package http
 
 import (
-	"fmt"
 	//"log"
 	"net/http"
 	"profilego/internal/domain"
+	"profilego/internal/middleware"
 	"profilego/internal/service"
+	"profilego/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -21,19 +22,19 @@ func NewAdressHandler(addressService service.AddressService) *AdressHandler {
 
 // Obtener todas las direcciones de un perfil
 func (h *AdressHandler) GetAdressesByProfile(c *gin.Context) {
-	fmt.Println("Todos los parámetros recibidos:", c.Params) // 🔍 Debug
 	profileID := c.Param("userId")
-	fmt.Println("ProfileID recibido:", profileID) // 🔍 Debug
+	logger.FromContext(c.Request.Context()).Debug("listando direcciones", "profile_id", profileID)
 
 	profileId, err := uuid.Parse(profileID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de perfil inválido"})
+		middleware.Fail(c, domain.Validation("validation_failed", "ID de perfil inválido",
+			domain.FieldError{Field: "profileId", Message: "debe ser un UUID"}))
 		return
 	}
 
 	adresses, err := h.addressService.GetAddressesByProfile(c.Request.Context(), profileId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		middleware.Fail(c, err)
 		return
 	}
 
@@ -43,20 +44,20 @@ func (h *AdressHandler) GetAdressesByProfile(c *gin.Context) {
 func (h *AdressHandler) CreateAddress(c *gin.Context) {
 	userId := c.Param("userId")
 	if userId == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "userId es requerido"})
+		middleware.Fail(c, errMissingUserID)
 		return
 	}
 
 	var newAddress domain.Address
 	if err := c.ShouldBindJSON(&newAddress); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		middleware.Fail(c, invalidBody(err))
 		return
 	}
 
 	ctx := c.Request.Context()
 	err := h.addressService.CreateAddress(ctx, userId, &newAddress)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		middleware.Fail(c, err)
 		return
 	}
 
@@ -66,19 +67,19 @@ func (h *AdressHandler) CreateAddress(c *gin.Context) {
 func (h *AdressHandler) GetAddress(c *gin.Context) {
 	userId := c.Param("userId")
 	if userId == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "userId es requerido"})
+		middleware.Fail(c, errMissingUserID)
 		return
 	}
 
 	ctx := c.Request.Context()
 	address, err := h.addressService.GetAddress(ctx, userId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		middleware.Fail(c, err)
 		return
 	}
 
 	if address == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No se encontró una dirección activa"})
+		middleware.Fail(c, service.ErrAddressNotFound)
 		return
 	}
 
@@ -88,20 +89,20 @@ func (h *AdressHandler) GetAddress(c *gin.Context) {
 func (h *AdressHandler) UpdateAddress(c *gin.Context) {
 	userId := c.Param("userId")
 	if userId == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "userId es requerido"})
+		middleware.Fail(c, errMissingUserID)
 		return
 	}
 
 	var updateAddress domain.Address
 	if err := c.ShouldBindJSON(&updateAddress); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		middleware.Fail(c, invalidBody(err))
 		return
 	}
 
 	ctx := c.Request.Context()
 	err := h.addressService.UpdateAddress(ctx, userId, &updateAddress)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		middleware.Fail(c, err)
 		return
 	}
 
@@ -112,7 +113,7 @@ func (h *AdressHandler) DeleteAddress(c *gin.Context) {
 	userId := c.Param("userId")
 
 	if userId == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "userId es requerido"})
+		middleware.Fail(c, errMissingUserID)
 		return
 	}
 
@@ -123,33 +124,87 @@ func (h *AdressHandler) DeleteAddress(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&requestBody); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de request inválido"})
+		middleware.Fail(c, invalidBody(err))
 		return
 	}
 
 	// Validar que addressId no esté vacío
 	if requestBody.AddressID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "addressId es requerido"})
+		middleware.Fail(c, domain.Validation("validation_failed", "addressId es requerido",
+			domain.FieldError{Field: "addressId", Message: "es obligatorio"}))
+		return
+	}
+	if _, err := uuid.Parse(requestBody.AddressID); err != nil {
+		middleware.Fail(c, domain.Validation("validation_failed", "addressId inválido",
+			domain.FieldError{Field: "addressId", Message: "debe ser un UUID"}))
 		return
 	}
 
 	ctx := c.Request.Context()
 	err := h.addressService.DeleteAddress(ctx, requestBody.AddressID, requestBody.ActiveAddress, userId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		middleware.Fail(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Dirección eliminada correctamente"})
 }
 
+// NearbyAddresses devuelve las direcciones de todos los perfiles cerca de un punto
+// (?lat=&lng=&radiusKm=&limit=), de la más cercana a la más lejana, con su distancia en km.
+func (h *AdressHandler) NearbyAddresses(c *gin.Context) {
+	lat, ok := floatQuery(c, "lat")
+	if !ok {
+		return
+	}
+	lng, ok := floatQuery(c, "lng")
+	if !ok {
+		return
+	}
+	radius, ok := floatQuery(c, "radiusKm")
+	if !ok {
+		return
+	}
+	limit, ok := intQuery(c, "limit")
+	if !ok {
+		return
+	}
+	search := service.NearbySearch{Latitude: lat, Longitude: lng, Limit: limit}
+	if radius != nil {
+		search.RadiusKm = *radius
+	}
+
+	addresses, err := h.addressService.NearbyAddresses(c.Request.Context(), search)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, addresses)
+}
+
 func (h *AdressHandler) RegisterRoutes(router *gin.RouterGroup) {
-	adressGroup := router.Group("/address")
+	adressGroup := router.Group("/address", middleware.Deprecated("/api/v2/profiles/{userId}/addresses"))
 	{
-		adressGroup.POST("/:userId/createAddress", h.CreateAddress)
-		adressGroup.GET("/:userId/getAddress", h.GetAddress)
-		adressGroup.POST("/:userId/updateAddress", h.UpdateAddress)
-		adressGroup.PUT("/:userId/deleteAddress", h.DeleteAddress)
-		adressGroup.GET("/:userId", h.GetAdressesByProfile)
+		owned := adressGroup.Group("", middleware.RequireOwner())
+		owned.POST("/:userId/createAddress", h.CreateAddress)
+		owned.GET("/:userId/getAddress", h.GetAddress)
+		owned.POST("/:userId/updateAddress", h.UpdateAddress)
+		owned.PUT("/:userId/deleteAddress", h.DeleteAddress)
+
+		// Esta ruta recibe el profileId (no el userId) en :userId
+		adressGroup.GET("/:userId", middleware.RequireOwnerFunc(h.profileOwner), h.GetAdressesByProfile)
+	}
+
+	router.GET("/addresses/nearby", middleware.RequirePermission(domain.PermProfileReadAny), h.NearbyAddresses)
+}
+
+// profileOwner resuelve el userId dueño del perfil indicado en la ruta de GetAdressesByProfile.
+// Un profileId mal formado se deja pasar para que el handler responda 400.
+func (h *AdressHandler) profileOwner(c *gin.Context) (string, error) {
+	profileID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		user, _ := middleware.CurrentUser(c)
+		return user.ID, nil
 	}
+	return h.addressService.ProfileOwner(c.Request.Context(), profileID)
 }
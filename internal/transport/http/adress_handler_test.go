@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"profilego/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestDeleteAddressV1(t *testing.T) {
+	tests := []struct {
+		name       string
+		addressID  func(f *apiFixture) string
+		wantStatus int
+		wantCode   string
+	}{
+		{"da de baja la dirección", func(f *apiFixture) string { return f.address.AddressID.String() }, http.StatusOK, ""},
+		{"sin addressId", func(*apiFixture) string { return "" }, http.StatusBadRequest, "validation_failed"},
+		{"addressId que no es un UUID", func(*apiFixture) string { return "no-es-uuid" }, http.StatusBadRequest, "validation_failed"},
+		{"dirección inexistente", func(*apiFixture) string { return uuid.NewString() }, http.StatusNotFound, "address_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAPIFixture(t)
+			body := `{"addressId":"` + tt.addressID(f) + `","activeAddress":false}`
+			req := httptest.NewRequest(http.MethodPut, "/api/address/me/deleteAddress", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := f.serve(req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				assertProblemCode(t, rec, tt.wantCode)
+			}
+
+			stored, _ := f.addresses.GetAddressByID(context.Background(), f.address.AddressID)
+			if stored.ActiveAddress != (tt.wantStatus != http.StatusOK) {
+				t.Fatalf("activeAddress = %v tras un %d", stored.ActiveAddress, rec.Code)
+			}
+		})
+	}
+}
+
+func TestGetAddressesByProfileOwner(t *testing.T) {
+	f := newAPIFixture(t)
+	other := domain.NewProfile("u2", "Beto", "beto@example.com", "1166667777", "", "", "", nil, 0, 0, nil)
+	if err := f.profiles.CreateProfile(context.Background(), other); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		profileID  string
+		wantStatus int
+		wantCode   string
+	}{
+		{"perfil propio", f.profile.ProfileID.String(), http.StatusOK, ""},
+		{"perfil de otro usuario", other.ProfileID.String(), http.StatusForbidden, "resource_forbidden"},
+		{"profileId mal formado", "no-es-uuid", http.StatusBadRequest, "validation_failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := f.serve(httptest.NewRequest(http.MethodGet, "/api/address/"+tt.profileID, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				assertProblemCode(t, rec, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestUpdateAddressV1KeepsGeocode(t *testing.T) {
+	tests := []struct {
+		name       string
+		street     string
+		wantCoords bool
+	}{
+		{"sin mover la dirección conserva las coordenadas", "Av. Santa Fe", true},
+		{"otra calle vuelve a geocodificar", "Av. Córdoba", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAPIFixture(t)
+			ctx := context.Background()
+			geocode := domain.Geocode{Latitude: -34.588, Longitude: -58.411, Quality: domain.GeocodeExact}
+			if err := f.addresses.SetGeocode(ctx, f.address.AddressID, f.address.Version, geocode); err != nil {
+				t.Fatalf("SetGeocode: %v", err)
+			}
+
+			// El cliente no puede fijar las coordenadas: se ignoran las que vengan en el body
+			body := `{"addressId":"` + f.address.AddressID.String() + `","CP":"C1425ABC","street":"` + tt.street +
+				`","number":1234,"floor":"5A","locality":"Palermo","province":"AR-C","mainAddress":true,"latitude":1,"longitude":1}`
+			req := httptest.NewRequest(http.MethodPost, "/api/address/me/updateAddress", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := f.serve(req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body)
+			}
+
+			stored, _ := f.addresses.GetAddressByID(ctx, f.address.AddressID)
+			if stored.Floor == nil || *stored.Floor != "5A" || stored.Street != tt.street {
+				t.Fatalf("dirección no actualizada: %+v", stored)
+			}
+			if tt.wantCoords {
+				if stored.Latitude == nil || *stored.Latitude != geocode.Latitude || stored.GeocodeQuality != domain.GeocodeExact {
+					t.Fatalf("debía conservar las coordenadas: %v %v", stored.Latitude, stored.GeocodeQuality)
+				}
+				return
+			}
+			if stored.Latitude != nil || stored.GeocodeQuality != domain.GeocodePending {
+				t.Fatalf("debía quedar pendiente de geocodificar: %v %v", stored.Latitude, stored.GeocodeQuality)
+			}
+		})
+	}
+}
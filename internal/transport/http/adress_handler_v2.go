@@ -0,0 +1,256 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"profilego/internal/domain"
+	"profilego/internal/middleware"
+	"profilego/internal/service"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RegisterRoutesV2 registra la API REST de direcciones como subrecurso del perfil
+// (/profiles/{userId}/addresses/{addressId}).
+func (h *AdressHandler) RegisterRoutesV2(router *gin.RouterGroup) {
+	addresses := router.Group("/profiles/:userId/addresses", middleware.RequireOwner())
+	{
+		addresses.GET("", h.listAddressesV2)
+		addresses.POST("", h.createAddressV2)
+		addresses.GET("/nearest", h.nearestAddressV2)
+		addresses.GET("/:addressId", h.getAddressV2)
+		addresses.PATCH("/:addressId", h.patchAddressV2)
+		addresses.DELETE("/:addressId", h.deleteAddressV2)
+		addresses.PUT("/:addressId/main", h.setMainAddressV2)
+		addresses.GET("/:addressId/history", h.addressHistoryV2)
+		addresses.GET("/:addressId/history/as-of", h.addressAsOfV2)
+	}
+}
+
+// listAddressesV2 lista las direcciones activas. ?active=false lista sólo las dadas de baja,
+// ?includeInactive=true todas y ?main=true|false filtra por dirección principal.
+func (h *AdressHandler) listAddressesV2(c *gin.Context) {
+	active, ok := boolQuery(c, "active")
+	if !ok {
+		return
+	}
+	main, ok := boolQuery(c, "main")
+	if !ok {
+		return
+	}
+	filter := service.AddressFilter{Active: active, Main: main}
+	if filter.Active == nil && c.Query("includeInactive") != "true" {
+		onlyActive := true
+		filter.Active = &onlyActive
+	}
+
+	addresses, err := h.addressService.ListAddresses(c.Request.Context(), c.Param("userId"), filter)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, addresses)
+}
+
+func (h *AdressHandler) createAddressV2(c *gin.Context) {
+	var address domain.Address
+	if err := c.ShouldBindJSON(&address); err != nil {
+		middleware.Fail(c, invalidBody(err))
+		return
+	}
+
+	if err := h.addressService.CreateAddress(c.Request.Context(), c.Param("userId"), &address); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	// Se usa el userId resuelto (no el alias "me") para que la URL sea canónica
+	location := strings.Replace(c.FullPath(), ":userId", c.Param("userId"), 1) + "/" + address.AddressID.String()
+	c.Header("Location", location)
+	setETag(c, address.Version)
+	c.JSON(http.StatusCreated, address)
+}
+
+func (h *AdressHandler) getAddressV2(c *gin.Context) {
+	addressID, ok := addressIDParam(c)
+	if !ok {
+		return
+	}
+
+	address, err := h.addressService.GetAddressByID(c.Request.Context(), c.Param("userId"), addressID)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if notModified(c, address.Version) {
+		return
+	}
+	setETag(c, address.Version)
+	c.JSON(http.StatusOK, address)
+}
+
+// patchAddressV2 aplica un JSON Merge Patch a la dirección; "floor": null borra el piso.
+func (h *AdressHandler) patchAddressV2(c *gin.Context) {
+	addressID, ok := addressIDParam(c)
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var patch domain.AddressPatch
+	if !bindMergePatch(c, &patch) {
+		return
+	}
+
+	address, err := h.addressService.PatchAddress(c.Request.Context(), c.Param("userId"), addressID, version, patch)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	setETag(c, address.Version)
+	c.JSON(http.StatusOK, address)
+}
+
+// addressHistoryV2 devuelve todas las versiones de la dirección, también si está dada de baja.
+func (h *AdressHandler) addressHistoryV2(c *gin.Context) {
+	addressID, ok := addressIDParam(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.addressService.AddressHistory(c.Request.Context(), c.Param("userId"), addressID)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// addressAsOfV2 devuelve la versión de la dirección vigente en ?at= (RFC 3339).
+func (h *AdressHandler) addressAsOfV2(c *gin.Context) {
+	addressID, ok := addressIDParam(c)
+	if !ok {
+		return
+	}
+	at, err := time.Parse(time.RFC3339Nano, c.Query("at"))
+	if err != nil {
+		middleware.Fail(c, domain.Validation("validation_failed", "parámetro inválido",
+			domain.FieldError{Field: "at", Message: "debe ser una fecha y hora RFC 3339, p. ej. 2024-05-01T15:04:05Z"}))
+		return
+	}
+
+	revision, err := h.addressService.AddressAsOf(c.Request.Context(), c.Param("userId"), addressID, at)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revision)
+}
+
+// nearestAddressV2 devuelve la dirección del perfil más cercana al punto (?lat=&lng=), con su
+// distancia en km. Sólo considera las direcciones activas ya geocodificadas.
+func (h *AdressHandler) nearestAddressV2(c *gin.Context) {
+	lat, ok := floatQuery(c, "lat")
+	if !ok {
+		return
+	}
+	lng, ok := floatQuery(c, "lng")
+	if !ok {
+		return
+	}
+
+	nearest, err := h.addressService.NearestAddress(c.Request.Context(), c.Param("userId"), lat, lng)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nearest)
+}
+
+// deleteAddressV2 da de baja la dirección; deja de aparecer en el listado y en GET.
+func (h *AdressHandler) deleteAddressV2(c *gin.Context) {
+	addressID, ok := addressIDParam(c)
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.addressService.DeactivateAddress(c.Request.Context(), c.Param("userId"), addressID, version); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// setMainAddressV2 marca la dirección como principal; la anterior deja de serlo.
+func (h *AdressHandler) setMainAddressV2(c *gin.Context) {
+	addressID, ok := addressIDParam(c)
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	address, err := h.addressService.SetMainAddress(c.Request.Context(), c.Param("userId"), addressID, version)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	setETag(c, address.Version)
+	c.JSON(http.StatusOK, address)
+}
+
+// boolQuery parsea un filtro booleano opcional de la query string; nil si no vino.
+func boolQuery(c *gin.Context, name string) (*bool, bool) {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return nil, true
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		middleware.Fail(c, domain.Validation("validation_failed", "filtro inválido",
+			domain.FieldError{Field: name, Message: "debe ser true o false"}))
+		return nil, false
+	}
+	return &value, true
+}
+
+// floatQuery parsea un parámetro numérico opcional; si no viene devuelve nil.
+func floatQuery(c *gin.Context, name string) (*float64, bool) {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return nil, true
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		middleware.Fail(c, domain.Validation("validation_failed", "parámetro inválido",
+			domain.FieldError{Field: name, Message: "debe ser un número"}))
+		return nil, false
+	}
+	return &value, true
+}
+
+// addressIDParam parsea :addressId; un id mal formado no puede existir, así que se responde 404.
+func addressIDParam(c *gin.Context) (uuid.UUID, bool) {
+	addressID, err := uuid.Parse(c.Param("addressId"))
+	if err != nil {
+		middleware.Fail(c, service.ErrAddressNotFound)
+		return uuid.Nil, false
+	}
+	return addressID, true
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"profilego/internal/domain"
+	"profilego/internal/middleware"
+	"profilego/internal/repository"
+	"profilego/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// apiFixture es la API (v1 en /api, v2 en /api/v2) sobre repositorios en memoria, autenticada
+// como user (u1, sin permisos, salvo que el test lo cambie), con un perfil y una dirección cargados.
+// Si beforePatch no es nil se ejecuta antes de persistir un PATCH del perfil, para simular otra
+// escritura concurrente entre la lectura y el UPDATE.
+type apiFixture struct {
+	router      *gin.Engine
+	user        *domain.AuthUser
+	beforePatch func()
+	profiles    *repository.MemoryProfileRepository
+	addresses   *repository.MemoryAddressRepository
+	published   *fakePublisher
+	profile     *domain.Profile
+	address     *domain.Address
+}
+
+// fakePublisher guarda los mensajes publicados en lugar de enviarlos a RabbitMQ.
+type fakePublisher struct {
+	messages [][]byte
+}
+
+func (p *fakePublisher) PublishProfilePoints(ctx context.Context, profileid string, profilepoints int) error {
+	return nil
+}
+
+func (p *fakePublisher) PublishMessage(ctx context.Context, queueName string, message []byte) error {
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+// racingProfiles es el repositorio de perfiles del fixture con el hook beforePatch.
+type racingProfiles struct {
+	*repository.MemoryProfileRepository
+	f *apiFixture
+}
+
+func (r racingProfiles) PatchProfile(ctx context.Context, profileID uuid.UUID, version int, patch domain.ProfilePatch, updatedDate time.Time) error {
+	if r.f.beforePatch != nil {
+		r.f.beforePatch()
+	}
+	return r.MemoryProfileRepository.PatchProfile(ctx, profileID, version, patch, updatedDate)
+}
+
+// newAPIFixture arma el fixture; v2 son middlewares extra del grupo /api/v2 (p. ej. RequireIfMatch).
+func newAPIFixture(t *testing.T, v2 ...gin.HandlerFunc) *apiFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	f := &apiFixture{
+		user:      &domain.AuthUser{ID: "u1", Enabled: true},
+		profiles:  repository.NewMemoryProfileRepository(),
+		addresses: repository.NewMemoryAddressRepository(),
+		published: &fakePublisher{},
+	}
+	f.profile = domain.NewProfile("u1", "Ana", "ana@example.com", "1144445555", "", "", "", nil, 0, 0, nil)
+	if err := f.profiles.CreateProfile(ctx, f.profile); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	if err := f.profiles.UpdateProfileImage(ctx, "u1", f.profile.ProfileID.String(), "avatar.png"); err != nil {
+		t.Fatalf("UpdateProfileImage: %v", err)
+	}
+	f.profile, _ = f.profiles.GetProfile(ctx, "u1")
+	floor := "3B"
+	f.address = domain.NewAddress("C1425ABC", "Av. Santa Fe", &floor, true, true, 1234, f.profile.ProfileID)
+	f.address.Province, f.address.Locality = "AR-C", "Palermo"
+	if err := f.addresses.CreateAddress(ctx, f.address); err != nil {
+		t.Fatalf("CreateAddress: %v", err)
+	}
+
+	f.router = gin.New()
+	f.router.Use(middleware.Problems())
+	api := f.router.Group("/api", func(c *gin.Context) {
+		c.Set(middleware.AuthUserKey, f.user)
+	})
+	store := racingProfiles{f.profiles, f}
+	profiles := NewProfileHandler(*service.NewProfileRabbitService(store, f.published, "profile_points"))
+	addresses := NewAdressHandler(*service.NewAddressService(f.addresses, store))
+	profiles.RegisterRoutes(api)
+	addresses.RegisterRoutes(api)
+	apiV2 := api.Group("/v2", v2...)
+	profiles.RegisterRoutesV2(apiV2)
+	addresses.RegisterRoutesV2(apiV2)
+	return f
+}
+
+func (f *apiFixture) serve(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (f *apiFixture) patch(path, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return f.serve(req)
+}
+
+// assertProblemCode verifica que la respuesta sea un problem+json con ese code.
+func assertProblemCode(t *testing.T, rec *httptest.ResponseRecorder, code string) {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, middleware.ProblemContentType) {
+		t.Fatalf("Content-Type %q, esperaba %s", ct, middleware.ProblemContentType)
+	}
+	var problem struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil || problem.Code != code {
+		t.Fatalf("code %q, esperaba %q (%v): %s", problem.Code, code, err, rec.Body)
+	}
+}
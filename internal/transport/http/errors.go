@@ -0,0 +1,20 @@
+package http
+
+import (
+	"profilego/internal/domain"
+)
+
+// Errores propios de la capa HTTP. Se responden con middleware.Fail y los renderiza
+// middleware.Problems como application/problem+json, igual que los del servicio.
+var (
+	errInvalidBody   = domain.Validation("invalid_body", "el cuerpo del request no es un JSON válido para este recurso")
+	errMissingUserID = domain.Validation("validation_failed", "userId es requerido",
+		domain.FieldError{Field: "userId", Message: "es obligatorio"})
+	errNoUserID    = domain.Unauthenticated("unauthenticated", "no se pudo recuperar el userId")
+	errIfMatchList = domain.Validation("invalid_if_match", "If-Match admite un solo ETag")
+)
+
+// invalidBody envuelve el error de binding para que quede en el log sin exponerse al cliente.
+func invalidBody(err error) error {
+	return errInvalidBody.Wrap(err)
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"net/http"
+	"profilego/internal/middleware"
+	"profilego/internal/service"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag arma el ETag fuerte de un recurso a partir de su versión.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// setETag agrega el ETag de la versión al response.
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", etag(version))
+}
+
+// notModified responde 304 si If-None-Match coincide con la versión actual (comparación débil).
+func notModified(c *gin.Context, version int) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			setETag(c, version)
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion devuelve la versión pedida en If-Match; 0 si no vino o es "*" (sólo exige que exista).
+// Un ETag débil o que no es de este servicio nunca coincide (412) y se admite un único ETag.
+func ifMatchVersion(c *gin.Context) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	if strings.Contains(header, ",") {
+		middleware.Fail(c, errIfMatchList)
+		return 0, false
+	}
+	middleware.MarkIfMatch(c)
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		middleware.Fail(c, service.ErrVersionConflict)
+		return 0, false
+	}
+	return version, true
+}
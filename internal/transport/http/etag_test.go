@@ -0,0 +1,183 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"profilego/internal/domain"
+	"profilego/internal/middleware"
+)
+
+func TestGetETag(t *testing.T) {
+	f := newAPIFixture(t)
+	profilePath := "/api/v2/profiles/me"
+	addressPath := "/api/v2/profiles/me/addresses/" + f.address.AddressID.String()
+	current := etag(f.profile.Version)
+
+	tests := []struct {
+		name        string
+		path        string
+		ifNoneMatch string
+		wantStatus  int
+		wantETag    string
+	}{
+		{name: "GET del perfil devuelve el ETag", path: profilePath, wantStatus: http.StatusOK, wantETag: current},
+		{name: "If-None-Match vigente", path: profilePath, ifNoneMatch: current, wantStatus: http.StatusNotModified, wantETag: current},
+		{name: "If-None-Match débil", path: profilePath, ifNoneMatch: "W/" + current, wantStatus: http.StatusNotModified, wantETag: current},
+		{name: "If-None-Match en una lista", path: profilePath, ifNoneMatch: `"99", ` + current, wantStatus: http.StatusNotModified, wantETag: current},
+		{name: "If-None-Match *", path: profilePath, ifNoneMatch: "*", wantStatus: http.StatusNotModified, wantETag: current},
+		{name: "If-None-Match viejo", path: profilePath, ifNoneMatch: `"99"`, wantStatus: http.StatusOK, wantETag: current},
+		{name: "GET de la dirección devuelve el ETag", path: addressPath, wantStatus: http.StatusOK, wantETag: etag(f.address.Version)},
+		{
+			name: "If-None-Match vigente en la dirección", path: addressPath, ifNoneMatch: etag(f.address.Version),
+			wantStatus: http.StatusNotModified, wantETag: etag(f.address.Version),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := f.serve(req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := rec.Header().Get("ETag"); got != tt.wantETag {
+				t.Fatalf("ETag %q, esperaba %q", got, tt.wantETag)
+			}
+			if tt.wantStatus == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Fatalf("un 304 no lleva cuerpo: %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestPreconditions(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string // relativo a /api/v2/profiles/me; {address} es la dirección del fixture
+		ifMatch        string // {current} es el ETag vigente del recurso
+		requireIfMatch bool
+		concurrent     bool
+		wantStatus     int
+		wantCode       string
+		unchanged      bool
+	}{
+		{name: "PATCH con la versión vigente", method: http.MethodPatch, ifMatch: "{current}", wantStatus: http.StatusOK},
+		{name: "PATCH sin If-Match", method: http.MethodPatch, wantStatus: http.StatusOK},
+		{name: "PATCH con If-Match *", method: http.MethodPatch, ifMatch: "*", requireIfMatch: true, wantStatus: http.StatusOK},
+		{
+			name: "PATCH con una versión vieja", method: http.MethodPatch, ifMatch: `"1"`,
+			wantStatus: http.StatusPreconditionFailed, wantCode: "version_conflict", unchanged: true,
+		},
+		{
+			name: "PATCH con un ETag débil", method: http.MethodPatch, ifMatch: "W/{current}",
+			wantStatus: http.StatusPreconditionFailed, wantCode: "version_conflict", unchanged: true,
+		},
+		{
+			name: "PATCH con varios ETag", method: http.MethodPatch, ifMatch: `"1", {current}`,
+			wantStatus: http.StatusBadRequest, wantCode: "invalid_if_match", unchanged: true,
+		},
+		{
+			name: "PATCH sin If-Match cuando se exige", method: http.MethodPatch, requireIfMatch: true,
+			wantStatus: http.StatusPreconditionRequired, wantCode: "precondition_required", unchanged: true,
+		},
+		{
+			name: "DELETE sin If-Match cuando se exige", method: http.MethodDelete, requireIfMatch: true,
+			wantStatus: http.StatusPreconditionRequired, wantCode: "precondition_required", unchanged: true,
+		},
+		{name: "GET sin If-Match cuando se exige", method: http.MethodGet, requireIfMatch: true, wantStatus: http.StatusOK, unchanged: true},
+		{
+			// Sin If-Match la escritura igual se condiciona a la versión leída: el cliente no
+			// pidió una precondición, así que es un 409 y no un 412
+			name: "escritura concurrente sin If-Match", method: http.MethodPatch, concurrent: true,
+			wantStatus: http.StatusConflict, wantCode: "conflict",
+		},
+		{
+			name: "escritura concurrente con If-Match", method: http.MethodPatch, ifMatch: "{current}", concurrent: true,
+			wantStatus: http.StatusPreconditionFailed, wantCode: "version_conflict",
+		},
+		{name: "DELETE con la versión vigente", method: http.MethodDelete, ifMatch: "{current}", wantStatus: http.StatusNoContent},
+		{
+			name: "DELETE con una versión vieja", method: http.MethodDelete, ifMatch: `"1"`,
+			wantStatus: http.StatusPreconditionFailed, wantCode: "version_conflict", unchanged: true,
+		},
+		{
+			name: "DELETE de una dirección con una versión vieja", method: http.MethodDelete, path: "/addresses/{address}", ifMatch: `"99"`,
+			wantStatus: http.StatusPreconditionFailed, wantCode: "version_conflict", unchanged: true,
+		},
+		{
+			name: "DELETE de una dirección con la versión vigente", method: http.MethodDelete, path: "/addresses/{address}", ifMatch: "{current}",
+			wantStatus: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f *apiFixture
+			if tt.requireIfMatch {
+				f = newAPIFixture(t, middleware.RequireIfMatch())
+			} else {
+				f = newAPIFixture(t)
+			}
+			ctx := context.Background()
+			if tt.concurrent {
+				f.beforePatch = func() {
+					f.beforePatch = nil
+					err := f.profiles.PatchProfile(ctx, f.profile.ProfileID, 0,
+						domain.ProfilePatch{ProfileName: domain.SetField("Otra")}, time.Now())
+					if err != nil {
+						t.Fatalf("escritura concurrente: %v", err)
+					}
+				}
+			}
+
+			version := f.profile.Version
+			if tt.path != "" {
+				version = f.address.Version
+			}
+			path := "/api/v2/profiles/me" + strings.ReplaceAll(tt.path, "{address}", f.address.AddressID.String())
+			var req *http.Request
+			if tt.method == http.MethodPatch {
+				req = httptest.NewRequest(tt.method, path, strings.NewReader(`{"phone":"1155556666"}`))
+				req.Header.Set("Content-Type", mergePatchContentType)
+			} else {
+				req = httptest.NewRequest(tt.method, path, nil)
+			}
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", strings.ReplaceAll(tt.ifMatch, "{current}", etag(version)))
+			}
+			rec := f.serve(req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				assertProblemCode(t, rec, tt.wantCode)
+			}
+			if rec.Code == http.StatusOK && tt.method == http.MethodPatch {
+				if got, want := rec.Header().Get("ETag"), etag(f.profile.Version+1); got != want {
+					t.Fatalf("ETag %q después de modificar, esperaba %q", got, want)
+				}
+			}
+
+			if tt.path != "" {
+				stored, _ := f.addresses.GetAddressByID(ctx, f.address.AddressID)
+				if stored.ActiveAddress != tt.unchanged {
+					t.Fatalf("la dirección activa=%v, esperaba %v", stored.ActiveAddress, tt.unchanged)
+				}
+				return
+			}
+			stored, _ := f.profiles.GetProfile(ctx, "u1")
+			if tt.unchanged && (stored == nil || stored.Version != f.profile.Version) {
+				t.Fatalf("un request rechazado no puede modificar el perfil: %+v", stored)
+			}
+		})
+	}
+}
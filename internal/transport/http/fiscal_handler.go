@@ -0,0 +1,72 @@
+package http
+
+import (
+	"net/http"
+	"profilego/internal/domain"
+	"profilego/internal/fiscal"
+	"profilego/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FiscalHandler expone las validaciones de datos fiscales sin modificar ningún perfil.
+type FiscalHandler struct{}
+
+func NewFiscalHandler() *FiscalHandler {
+	return &FiscalHandler{}
+}
+
+// validationResult es la respuesta de /fiscal/validate: un CUIT inválido no es un error del
+// request, así que se responde 200 con valid=false y el detalle por campo.
+type validationResult struct {
+	Valid     bool                `json:"valid"`
+	Number    string              `json:"number,omitempty"`
+	Formatted string              `json:"formatted,omitempty"`
+	Type      fiscal.PersonType   `json:"type,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+// Validate valida un CUIT/CUIL con las mismas reglas que los datos fiscales del perfil.
+func (h *FiscalHandler) Validate(c *gin.Context) {
+	var req struct {
+		CUIL *string `json:"CUIL"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.Fail(c, invalidBody(err))
+		return
+	}
+	if req.CUIL == nil {
+		middleware.Fail(c, domain.Validation("validation_failed", "CUIL es requerido",
+			domain.FieldError{Field: "CUIL", Message: fiscal.ErrEmpty.Error()}))
+		return
+	}
+
+	cuit, err := fiscal.Parse(*req.CUIL)
+	if err != nil {
+		c.JSON(http.StatusOK, validationResult{
+			Errors: []domain.FieldError{{Field: "CUIL", Message: err.Error()}},
+		})
+		return
+	}
+	c.JSON(http.StatusOK, validationResult{
+		Valid:     true,
+		Number:    cuit.Number,
+		Formatted: cuit.Formatted(),
+		Type:      cuit.Type,
+	})
+}
+
+// Options devuelve los catálogos de condiciones fiscales, regímenes y jurisdicciones de IIBB
+// que acepta PUT /profiles/{userId}/fiscal-data.
+func (h *FiscalHandler) Options(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"conditions":    fiscal.Conditions,
+		"iibbRegimes":   fiscal.Regimes,
+		"jurisdictions": fiscal.Jurisdictions,
+	})
+}
+
+func (h *FiscalHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/fiscal/options", h.Options)
+	router.POST("/fiscal/validate", h.Validate)
+}
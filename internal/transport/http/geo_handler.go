@@ -0,0 +1,85 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"profilego/internal/domain"
+	"profilego/internal/geo"
+	"profilego/internal/middleware"
+	"profilego/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GeoHandler expone el catálogo de provincias y localidades para autocompletar direcciones.
+type GeoHandler struct {
+	geoService *service.GeoService
+}
+
+func NewGeoHandler(geoService *service.GeoService) *GeoHandler {
+	return &GeoHandler{geoService: geoService}
+}
+
+// localityPage es una página de resultados de /geo/localities.
+type localityPage struct {
+	Items  []domain.Locality `json:"items"`
+	Total  int               `json:"total"`
+	Limit  int               `json:"limit"`
+	Offset int               `json:"offset"`
+}
+
+// Provinces devuelve el catálogo de provincias (ISO 3166-2:AR).
+func (h *GeoHandler) Provinces(c *gin.Context) {
+	c.JSON(http.StatusOK, geo.Provinces)
+}
+
+// Localities busca localidades por lo que el usuario lleva escrito del código postal (cp) y
+// del nombre (q), opcionalmente dentro de una provincia, paginadas con limit y offset.
+func (h *GeoHandler) Localities(c *gin.Context) {
+	limit, ok := intQuery(c, "limit")
+	if !ok {
+		return
+	}
+	offset, ok := intQuery(c, "offset")
+	if !ok {
+		return
+	}
+	search := service.LocalitySearch{
+		CP:       c.Query("cp"),
+		Query:    c.Query("q"),
+		Province: c.Query("province"),
+		Limit:    limit,
+		Offset:   offset,
+	}
+
+	localities, total, err := h.geoService.SearchLocalities(c.Request.Context(), search)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if limit == 0 {
+		limit = service.DefaultLocalityLimit
+	}
+	c.JSON(http.StatusOK, localityPage{Items: localities, Total: total, Limit: limit, Offset: offset})
+}
+
+// intQuery parsea un parámetro entero opcional; si no viene devuelve 0.
+func intQuery(c *gin.Context, name string) (int, bool) {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return 0, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		middleware.Fail(c, domain.Validation("validation_failed", "parámetro inválido",
+			domain.FieldError{Field: name, Message: "debe ser un número entero"}))
+		return 0, false
+	}
+	return value, true
+}
+
+func (h *GeoHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/geo/provinces", h.Provinces)
+	router.GET("/geo/localities", h.Localities)
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout es el tiempo máximo que se espera a cada dependencia en /readyz.
+const readinessTimeout = 2 * time.Second
+
+// HealthCheck verifica una dependencia del servicio.
+// Si Optional es true, su falla se informa pero no marca al servicio como no listo.
+type HealthCheck struct {
+	Name     string
+	Check    func(ctx context.Context) error
+	Optional bool
+}
+
+// checkResult es el detalle de una dependencia en la respuesta de /readyz.
+type checkResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Optional  bool    `json:"optional,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type HealthHandler struct {
+	checks []HealthCheck
+}
+
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Liveness indica que el proceso está vivo. No consulta dependencias.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness ejecuta todos los checks en paralelo y devuelve 503 si falla alguno obligatorio.
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	results := make(map[string]checkResult, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(ctx)
+			result := checkResult{
+				Status:    "ok",
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+				Optional:  check.Optional,
+			}
+			if err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	status, code := "ok", http.StatusOK
+	for _, r := range results {
+		if r.Status == "ok" {
+			continue
+		}
+		if !r.Optional {
+			status, code = "fail", http.StatusServiceUnavailable
+			break
+		}
+		status = "degraded"
+	}
+
+	c.JSON(code, gin.H{"status": status, "checks": results})
+}
+
+func (h *HealthHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/healthz", h.Liveness)
+	router.GET("/readyz", h.Readiness)
+}
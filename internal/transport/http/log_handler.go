@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+	"profilego/internal/domain"
+	"profilego/internal/middleware"
+	"profilego/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogLevelHandler permite consultar y cambiar el nivel de log en tiempo de ejecución.
+type LogLevelHandler struct{}
+
+func NewLogLevelHandler() *LogLevelHandler {
+	return &LogLevelHandler{}
+}
+
+func (h *LogLevelHandler) GetLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": logger.Level().String()})
+}
+
+func (h *LogLevelHandler) SetLevel(c *gin.Context) {
+	var req struct {
+		Level string `json:"level" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.Fail(c, invalidBody(err))
+		return
+	}
+
+	if err := logger.SetLevel(req.Level); err != nil {
+		middleware.Fail(c, domain.Validation("invalid_log_level", err.Error(),
+			domain.FieldError{Field: "level", Message: err.Error()}))
+		return
+	}
+
+	logger.FromContext(c.Request.Context()).Info("nivel de log actualizado", "level", logger.Level().String())
+	c.JSON(http.StatusOK, gin.H{"level": logger.Level().String()})
+}
+
+func (h *LogLevelHandler) RegisterRoutes(router *gin.RouterGroup) {
+	adminGroup := router.Group("/admin")
+	{
+		adminGroup.GET("/log-level", h.GetLevel)
+		adminGroup.PUT("/log-level", middleware.RequirePermission(domain.PermAdminLogLevel), h.SetLevel)
+	}
+}
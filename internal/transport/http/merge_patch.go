@@ -0,0 +1,50 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"profilego/internal/domain"
+	"profilego/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mergePatchContentType es el media type de JSON Merge Patch (RFC 7396).
+const mergePatchContentType = "application/merge-patch+json"
+
+// bindMergePatch decodifica un JSON Merge Patch en dst. Acepta también application/json por
+// compatibilidad; cualquier otro Content-Type responde 415. El documento debe ser un objeto y
+// los campos desconocidos o no modificables responden 400.
+func bindMergePatch(c *gin.Context, dst interface{}) bool {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType != mergePatchContentType && mediaType != "application/json" {
+		c.Header("Accept-Patch", mergePatchContentType)
+		middleware.Fail(c, &middleware.Problem{
+			Status: http.StatusUnsupportedMediaType,
+			Code:   "unsupported_media_type",
+			Detail: "Content-Type debe ser " + mergePatchContentType,
+		})
+		return false
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		middleware.Fail(c, invalidBody(err))
+		return false
+	}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) == 0 || trimmed[0] != '{' {
+		middleware.Fail(c, domain.Validation("invalid_body", "el patch debe ser un objeto JSON"))
+		return false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		middleware.Fail(c, invalidBody(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"profilego/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestPatchProfileMergePatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCode    string
+		unchanged   bool
+		check       func(t *testing.T, p *domain.Profile)
+	}{
+		{
+			name:        "un campo ausente no cambia",
+			contentType: mergePatchContentType,
+			body:        `{"phone":"1155556666"}`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, p *domain.Profile) {
+				if p.Phone != "1155556666" || p.ProfileName != "Ana" || p.ProfileMail != "ana@example.com" {
+					t.Fatalf("sólo debía cambiar el teléfono: %+v", p)
+				}
+				if p.ProfileImage == nil || *p.ProfileImage != "avatar.png" {
+					t.Fatalf("la imagen ausente no debía cambiar: %v", p.ProfileImage)
+				}
+			},
+		},
+		{
+			name:        "null borra la imagen",
+			contentType: mergePatchContentType + "; charset=utf-8",
+			body:        `{"profileImage":null}`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, p *domain.Profile) {
+				if p.ProfileImage != nil || p.ProfileName != "Ana" {
+					t.Fatalf("null debía borrar sólo la imagen: %+v", p)
+				}
+			},
+		},
+		{
+			name:        "objeto vacío no cambia nada",
+			contentType: mergePatchContentType,
+			body:        `{}`,
+			wantStatus:  http.StatusOK,
+			unchanged:   true,
+			check: func(t *testing.T, p *domain.Profile) {
+				if p.ProfileName != "Ana" || p.ProfileImage == nil {
+					t.Fatalf("un patch vacío no cambia nada: %+v", p)
+				}
+			},
+		},
+		{
+			name:        "application/json se acepta por compatibilidad",
+			contentType: "application/json",
+			body:        `{"profileName":"Ana María"}`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, p *domain.Profile) {
+				if p.ProfileName != "Ana María" || p.Phone != "1144445555" {
+					t.Fatalf("sólo debía cambiar el nombre: %+v", p)
+				}
+			},
+		},
+		{"null en un obligatorio", mergePatchContentType, `{"phone":null}`, http.StatusBadRequest, "validation_failed", true, nil},
+		// Los campos modificables son escalares: un objeto anidado no es un valor válido
+		{"objeto anidado en un escalar", mergePatchContentType, `{"profileName":{"first":"Ana"}}`, http.StatusBadRequest, "invalid_body", true, nil},
+		{"campo no modificable", mergePatchContentType, `{"profilePoints":10}`, http.StatusBadRequest, "invalid_body", true, nil},
+		{"el documento no es un objeto", mergePatchContentType, `["phone"]`, http.StatusBadRequest, "invalid_body", true, nil},
+		{"Content-Type incorrecto", "text/plain", `{"phone":"1155556666"}`, http.StatusUnsupportedMediaType, "unsupported_media_type", true, nil},
+		{"sin Content-Type", "", `{"phone":"1155556666"}`, http.StatusUnsupportedMediaType, "unsupported_media_type", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAPIFixture(t)
+			rec := f.patch("/api/v2/profiles/me", tt.contentType, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				assertProblemCode(t, rec, tt.wantCode)
+				if tt.wantStatus == http.StatusUnsupportedMediaType && rec.Header().Get("Accept-Patch") != mergePatchContentType {
+					t.Fatalf("un 415 debe indicar Accept-Patch: %q", rec.Header().Get("Accept-Patch"))
+				}
+			}
+			stored, _ := f.profiles.GetProfile(context.Background(), "u1")
+			if tt.unchanged && stored.Version != f.profile.Version {
+				t.Fatalf("el perfil no debía modificarse: versión %d, antes %d", stored.Version, f.profile.Version)
+			}
+			if tt.check == nil {
+				return
+			}
+
+			var got domain.Profile
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("respuesta inválida: %v", err)
+			}
+			tt.check(t, &got)
+			tt.check(t, stored)
+			if rec.Header().Get("ETag") != etag(stored.Version) {
+				t.Fatalf("ETag %q, esperaba la versión %d", rec.Header().Get("ETag"), stored.Version)
+			}
+		})
+	}
+}
+
+func TestPatchAddressMergePatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		check      func(t *testing.T, a *domain.Address)
+	}{
+		{
+			name:       "un campo ausente no cambia",
+			body:       `{"street":"Av. Córdoba"}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, a *domain.Address) {
+				if a.Street != "Av. Córdoba" || a.Number != 1234 || a.CP != "C1425ABC" || a.Floor == nil || *a.Floor != "3B" {
+					t.Fatalf("sólo debía cambiar la calle: %+v", a)
+				}
+			},
+		},
+		{
+			name:       "null borra el piso",
+			body:       `{"floor":null,"references":"timbre 2"}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, a *domain.Address) {
+				if a.Floor != nil || a.References == nil || *a.References != "timbre 2" || a.Street != "Av. Santa Fe" {
+					t.Fatalf("debía borrarse el piso y agregarse las referencias: %+v", a)
+				}
+			},
+		},
+		{
+			name:       "null en un obligatorio",
+			body:       `{"number":null}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "los campos relacionados se validan juntos",
+			body:       `{"province":"AR-X"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAPIFixture(t)
+			rec := f.patch("/api/v2/profiles/me/addresses/"+f.address.AddressID.String(), mergePatchContentType, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			stored, _ := f.addresses.GetAddressByID(context.Background(), f.address.AddressID)
+			if tt.check == nil {
+				if stored.Version != 1 {
+					t.Fatalf("un patch rechazado no puede modificar la dirección: %+v", stored)
+				}
+				return
+			}
+			var got domain.Address
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("respuesta inválida: %v", err)
+			}
+			tt.check(t, &got)
+			tt.check(t, stored)
+		})
+	}
+
+	t.Run("Content-Type incorrecto", func(t *testing.T) {
+		f := newAPIFixture(t)
+		rec := f.patch("/api/v2/profiles/me/addresses/"+uuid.NewString(), "application/x-www-form-urlencoded", "street=x")
+		if rec.Code != http.StatusUnsupportedMediaType {
+			t.Fatalf("status %d, esperaba 415: %s", rec.Code, rec.Body)
+		}
+		assertProblemCode(t, rec, "unsupported_media_type")
+	})
+}
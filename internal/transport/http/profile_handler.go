@@ -1,16 +1,13 @@
 package http
 
 import (
-	"fmt"
-	"log"
-
 	//"log"
 	"net/http"
 	"profilego/internal/domain"
 
 	//"strconv"
 
-	//"profilego/internal/middleware"
+	"profilego/internal/middleware"
 	"profilego/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -29,14 +26,14 @@ func (h *ProfileHandler) CreateProfile(c *gin.Context) {
 	// Extraer userId del middleware (lo extrae del token JWT)
 	userId, exists := c.Get("userId")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No se pudo recuperar el userId"})
+		middleware.Fail(c, errNoUserID)
 		return
 	}
 
 	// Parsear el JSON del body
 	var profile domain.Profile
 	if err := c.ShouldBindJSON(&profile); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		middleware.Fail(c, invalidBody(err))
 		return
 	}
 
@@ -46,7 +43,7 @@ func (h *ProfileHandler) CreateProfile(c *gin.Context) {
 
 	// Llamar al servicio para crear el perfil
 	if err := h.profileService.CreateProfile(c.Request.Context(), userId.(string), &profile); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		middleware.Fail(c, err)
 		return
 	}
 
@@ -58,17 +55,17 @@ func (h *ProfileHandler) GetProfile(c *gin.Context) {
 	userId := c.Param("userId")
 
 	/*if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No se pudo recuperar el userId"})
+		middleware.Fail(c, errNoUserID)
 		return
 	}*/
 
 	profile, err := h.profileService.GetProfile(c.Request.Context(), userId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		middleware.Fail(c, err)
 		return
 	}
 	if profile == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Perfil no encontrado"})
+		middleware.Fail(c, service.ErrProfileNotFound)
 		return
 	}
 
@@ -76,24 +73,24 @@ func (h *ProfileHandler) GetProfile(c *gin.Context) {
 }
 
 func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
-	userId := c.Param("userId") // 📌 Obtener el userId desde la URL
+	userId := c.Param("userId") // Obtener el userId desde la URL
 
 	if userId == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "userId es requerido"})
+		middleware.Fail(c, errMissingUserID)
 		return
 	}
 
 	var updateProfileRequest domain.Profile
 
 	if err := c.ShouldBindJSON(&updateProfileRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		middleware.Fail(c, invalidBody(err))
 		return
 	}
 
 	ctx := c.Request.Context()
-	err := h.profileService.UpdateProfile(ctx, userId, &updateProfileRequest) // 🔹 Pasamos userId
+	err := h.profileService.UpdateProfile(ctx, userId, &updateProfileRequest) // Pasamos userId
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		middleware.Fail(c, err)
 		return
 	}
 
@@ -101,54 +98,45 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 }
 
 func (h *ProfileHandler) UpdateFiscalData(c *gin.Context) {
-	// 🔹 Extraer `userId` del token (100% confiable)
-	userIdToken, exists := c.Get("userId")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No se pudo recuperar el userId"})
-		return
-	}
-
-	// 🔹 Extraer `userId` de la URL
+	// Extraer `userId` de la URL (RequireOwner ya validó que sea el del token o que haya permiso)
 	userIdParam := c.Param("userId")
 	if userIdParam == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "userId es requerido en la URL"})
+		middleware.Fail(c, errMissingUserID)
 		return
 	}
 
-	// 🔹 Buscar el perfil en la base de datos usando el `userIdParam`
+	// Buscar el perfil en la base de datos usando el `userIdParam`
 	profile, err := h.profileService.Repo.GetByUserID(c.Request.Context(), userIdParam)
 
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Perfil no encontrado"})
+		middleware.Fail(c, err)
 		return
 	}
-
-	// 🔹 Validar que el perfil le pertenece al usuario autenticado (comparar con el `userIdToken`)
-	if profile.UserID != fmt.Sprintf("%s", userIdToken) {
-
-		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permisos para modificar este perfil"})
+	if profile == nil {
+		middleware.Fail(c, service.ErrProfileNotFound)
 		return
 	}
-	// 🔹 Parsear JSON del body
+
+	// Parsear JSON del body
 	var updateFiscalData domain.Profile
 	if err := c.ShouldBindJSON(&updateFiscalData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		middleware.Fail(c, invalidBody(err))
 		return
 	}
 
-	// 🔹 Asegurar que `profileId` y `userId` sean los correctos
+	// Asegurar que `profileId` y `userId` sean los correctos
 	updateFiscalData.ProfileID = profile.ProfileID
-	updateFiscalData.UserID = profile.UserID // ⚠ Importante: Usar `profile.UserID`, ya que es el validado.
+	updateFiscalData.UserID = profile.UserID // Importante: Usar `profile.UserID`, ya que es el validado.
 
-	// 🔹 Llamar al servicio para actualizar los datos fiscales
+	// Llamar al servicio para actualizar los datos fiscales
 	ctx := c.Request.Context()
 	err = h.profileService.UpdateFiscalData(ctx, profile.UserID, &updateFiscalData)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		middleware.Fail(c, err)
 		return
 	}
 
-	// 🔹 Respuesta de éxito
+	// Respuesta de éxito
 	c.JSON(http.StatusOK, gin.H{"message": "Datos fiscales actualizados correctamente"})
 }
 
@@ -156,7 +144,7 @@ func (h *ProfileHandler) UpdateProfileImage(c *gin.Context) {
 	userId := c.Param("userId") // Obtener el userId desde la URL
 
 	if userId == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "userId es requerido"})
+		middleware.Fail(c, errMissingUserID)
 		return
 	}
 
@@ -166,18 +154,14 @@ func (h *ProfileHandler) UpdateProfileImage(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		middleware.Fail(c, invalidBody(err))
 		return
 	}
 
 	// Llamar al servicio para actualizar la imagen
 	err := h.profileService.UpdateProfileImage(c.Request.Context(), userId, req.ProfileID, req.ProfileImage)
 	if err != nil {
-		if err.Error() == "usuario no encontrado" { // 📌 Ahora sí manejamos el error en el servicio
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar la imagen"})
-		}
+		middleware.Fail(c, err)
 		return
 	}
 
@@ -185,24 +169,21 @@ func (h *ProfileHandler) UpdateProfileImage(c *gin.Context) {
 }
 
 func (h *ProfileHandler) UpdateProfilePoints(c *gin.Context) {
-	log.Println("ESTA ENTRANDO ACA")
 	userId := c.Param("userId")
 	if userId == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No se pudo recuperar el userId"})
+		middleware.Fail(c, errNoUserID)
 		return
 	}
-	log.Println("ESTA ENTRANDO ACA")
 	var req domain.Profile
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		middleware.Fail(c, invalidBody(err))
 		return
 	}
 
 	err := h.profileService.UpdateProfilePoints(c.Request.Context(), userId, &req)
 	if err != nil {
-		log.Println("ESTA ENTRANDO ACA")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		middleware.Fail(c, err)
 		return
 	}
 
@@ -212,20 +193,20 @@ func (h *ProfileHandler) UpdateProfilePoints(c *gin.Context) {
 func (h *ProfileHandler) UpdateProfileLevel(c *gin.Context) {
 	userId := c.Param("userId")
 	if userId == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No se pudo recuperar el userId"})
+		middleware.Fail(c, errNoUserID)
 		return
 	}
 
 	var req domain.Profile
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
+		middleware.Fail(c, invalidBody(err))
 		return
 	}
 
 	err := h.profileService.UpdateProfileLevel(c.Request.Context(), userId, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		middleware.Fail(c, err)
 		return
 	}
 
@@ -233,14 +214,17 @@ func (h *ProfileHandler) UpdateProfileLevel(c *gin.Context) {
 }
 
 func (h *ProfileHandler) RegisterRoutes(router *gin.RouterGroup) {
-	profileGroup := router.Group("/profiles")
+	profileGroup := router.Group("/profiles", middleware.Deprecated("/api/v2/profiles"))
 	{
-		profileGroup.POST("/:userId/create", h.CreateProfile)
-		profileGroup.GET("/:userId", h.GetProfile)
-		profileGroup.POST("/:userId/updateProfile", h.UpdateProfile)
-		profileGroup.POST("/:userId/updateFiscalData", h.UpdateFiscalData)
-		profileGroup.POST("/:userId/updateImage", h.UpdateProfileImage)
-		profileGroup.POST("/:userId/updateProfilePoints", h.UpdateProfilePoints)
-		profileGroup.POST("/:userId/updateProfileLevel", h.UpdateProfileLevel)
+		owned := profileGroup.Group("", middleware.RequireOwner())
+		owned.POST("/:userId/create", h.CreateProfile)
+		owned.GET("/:userId", h.GetProfile)
+		owned.POST("/:userId/updateProfile", h.UpdateProfile)
+		owned.POST("/:userId/updateFiscalData", h.UpdateFiscalData)
+		owned.POST("/:userId/updateImage", h.UpdateProfileImage)
+
+		// Puntos y nivel los asignan otros servicios/usuarios con permiso, no el dueño del perfil
+		profileGroup.POST("/:userId/updateProfilePoints", middleware.RequirePermission(domain.PermPointsGrant), h.UpdateProfilePoints)
+		profileGroup.POST("/:userId/updateProfileLevel", middleware.RequirePermission(domain.PermLevelSet), h.UpdateProfileLevel)
 	}
 }
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"profilego/internal/domain"
+)
+
+func TestPointsAndLevelRequirePermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		body        string
+		permissions []string
+		wantStatus  int
+		wantCode    string
+		wantEvents  int
+		check       func(t *testing.T, p *domain.Profile)
+	}{
+		{
+			name: "el dueño no puede otorgarse puntos", path: "/api/profiles/u1/updateProfilePoints", body: `{"profilePoints":50}`,
+			wantStatus: http.StatusForbidden, wantCode: "permission_denied",
+		},
+		{
+			name: "otorgar puntos con points:grant", path: "/api/profiles/u1/updateProfilePoints", body: `{"profilePoints":50}`,
+			permissions: []string{domain.PermPointsGrant}, wantStatus: http.StatusOK, wantEvents: 1,
+			check: func(t *testing.T, p *domain.Profile) {
+				if p.ProfilePoints != 50 {
+					t.Fatalf("esperaba 50 puntos, hay %d", p.ProfilePoints)
+				}
+			},
+		},
+		{
+			name: "points:grant no permite cambiar el nivel", path: "/api/profiles/u1/updateProfileLevel", body: `{"profileLevel":3}`,
+			permissions: []string{domain.PermPointsGrant}, wantStatus: http.StatusForbidden, wantCode: "permission_denied",
+		},
+		{
+			name: "cambiar el nivel con level:set", path: "/api/profiles/u1/updateProfileLevel", body: `{"profileLevel":3}`,
+			permissions: []string{domain.PermLevelSet}, wantStatus: http.StatusOK,
+			check: func(t *testing.T, p *domain.Profile) {
+				if p.ProfileLevel != 3 {
+					t.Fatalf("esperaba nivel 3, hay %d", p.ProfileLevel)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAPIFixture(t)
+			// Puntos y nivel los asigna otro usuario o servicio, no hace falta ser el dueño
+			f.user = &domain.AuthUser{ID: "svc", Enabled: true, Permissions: tt.permissions}
+			if tt.permissions == nil {
+				f.user.ID = "u1"
+			}
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := f.serve(req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				assertProblemCode(t, rec, tt.wantCode)
+			}
+
+			stored, _ := f.profiles.GetProfile(context.Background(), "u1")
+			if tt.check == nil {
+				if stored.ProfilePoints != 0 || stored.ProfileLevel != 0 || len(f.published.messages) != 0 {
+					t.Fatalf("un request rechazado no puede modificar el perfil ni publicar eventos: %+v", stored)
+				}
+				return
+			}
+			tt.check(t, stored)
+			// El cambio de nivel no publica: el consumidor de puntos volvería a subirlo
+			if len(f.published.messages) != tt.wantEvents {
+				t.Fatalf("esperaba %d eventos publicados, hay %d", tt.wantEvents, len(f.published.messages))
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package http
+
+import (
+	"net/http"
+	"profilego/internal/domain"
+	"profilego/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutesV2 registra la API REST de perfiles (/profiles/{userId}).
+// {userId} acepta el alias "me".
+func (h *ProfileHandler) RegisterRoutesV2(router *gin.RouterGroup) {
+	router.POST("/profiles", h.createProfileV2)
+
+	profile := router.Group("/profiles/:userId", middleware.RequireOwner())
+	{
+		profile.GET("", h.getProfileV2)
+		profile.PUT("", h.replaceProfileV2)
+		profile.PATCH("", h.patchProfileV2)
+		profile.DELETE("", h.deleteProfileV2)
+		profile.PUT("/fiscal-data", h.putFiscalDataV2)
+		profile.PUT("/image", h.putImageV2)
+	}
+}
+
+// createProfileV2 crea el perfil del usuario autenticado: 201 con Location, 409 si ya existe.
+func (h *ProfileHandler) createProfileV2(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	var profile domain.Profile
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		middleware.Fail(c, invalidBody(err))
+		return
+	}
+
+	if err := h.profileService.CreateProfile(c.Request.Context(), user.ID, &profile); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	c.Header("Location", c.Request.URL.Path+"/"+user.ID)
+	writeProfile(c, http.StatusCreated, &profile)
+}
+
+// getProfileV2 devuelve el perfil con su ETag; 304 si coincide con If-None-Match.
+func (h *ProfileHandler) getProfileV2(c *gin.Context) {
+	profile, err := h.profileService.GetProfile(c.Request.Context(), c.Param("userId"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	if notModified(c, profile.Version) {
+		return
+	}
+	writeProfile(c, http.StatusOK, profile)
+}
+
+// replaceProfileV2 reemplaza los datos básicos: los campos ausentes quedan vacíos y fallan la validación.
+func (h *ProfileHandler) replaceProfileV2(c *gin.Context) {
+	var body domain.Profile
+	if err := c.ShouldBindJSON(&body); err != nil {
+		middleware.Fail(c, invalidBody(err))
+		return
+	}
+
+	h.applyProfilePatch(c, domain.ProfilePatch{
+		ProfileName: domain.SetField(body.ProfileName),
+		ProfileMail: domain.SetField(body.ProfileMail),
+		Phone:       domain.SetField(body.Phone),
+	})
+}
+
+// patchProfileV2 aplica un JSON Merge Patch: sólo cambian los campos enviados y
+// "profileImage": null borra la imagen.
+func (h *ProfileHandler) patchProfileV2(c *gin.Context) {
+	var patch domain.ProfilePatch
+	if !bindMergePatch(c, &patch) {
+		return
+	}
+	h.applyProfilePatch(c, patch)
+}
+
+func (h *ProfileHandler) applyProfilePatch(c *gin.Context, patch domain.ProfilePatch) {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	profile, err := h.profileService.PatchProfile(c.Request.Context(), c.Param("userId"), version, patch)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	writeProfile(c, http.StatusOK, profile)
+}
+
+func (h *ProfileHandler) deleteProfileV2(c *gin.Context) {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.profileService.DeleteProfileByUser(c.Request.Context(), c.Param("userId"), version); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ProfileHandler) putFiscalDataV2(c *gin.Context) {
+	ctx := c.Request.Context()
+	userId := c.Param("userId")
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var fiscal domain.Profile
+	if err := c.ShouldBindJSON(&fiscal); err != nil {
+		middleware.Fail(c, invalidBody(err))
+		return
+	}
+
+	profile, err := h.profileService.GetProfile(ctx, userId)
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	fiscal.ProfileID = profile.ProfileID
+	fiscal.UserID = profile.UserID
+	fiscal.Version = version
+	if err := h.profileService.UpdateFiscalData(ctx, userId, &fiscal); err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+
+	h.writeCurrentProfile(c)
+}
+
+// putImageV2 reemplaza la imagen del perfil; para borrarla se usa PATCH con "profileImage": null.
+func (h *ProfileHandler) putImageV2(c *gin.Context) {
+	var req struct {
+		ProfileImage string `json:"profileImage"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.Fail(c, invalidBody(err))
+		return
+	}
+
+	h.applyProfilePatch(c, domain.ProfilePatch{ProfileImage: domain.SetField(req.ProfileImage)})
+}
+
+// writeCurrentProfile responde con el perfil vigente después de una modificación.
+func (h *ProfileHandler) writeCurrentProfile(c *gin.Context) {
+	profile, err := h.profileService.GetProfile(c.Request.Context(), c.Param("userId"))
+	if err != nil {
+		middleware.Fail(c, err)
+		return
+	}
+	writeProfile(c, http.StatusOK, profile)
+}
+
+// writeProfile responde el perfil con su ETag.
+func writeProfile(c *gin.Context, status int, profile *domain.Profile) {
+	setETag(c, profile.Version)
+	c.JSON(status, profile)
+}
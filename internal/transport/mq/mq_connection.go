@@ -1,7 +1,9 @@
 package mq
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
 
 	"github.com/streadway/amqp"
 )
@@ -16,21 +18,31 @@ type RabbitMQConnection struct {
 func NewRabbitMQ(url string) (*RabbitMQConnection, error) {
 	conn, err := amqp.Dial(url)
 	if err != nil {
-		log.Fatalf("❌ Error conectando a RabbitMQ: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("error conectando a RabbitMQ: %w", err)
 	}
 
 	ch, err := conn.Channel()
 	if err != nil {
-		log.Fatalf("❌ Error abriendo canal RabbitMQ: %v", err)
-		return nil, err
+		conn.Close()
+		return nil, fmt.Errorf("error abriendo canal RabbitMQ: %w", err)
 	}
 
 	return &RabbitMQConnection{Conn: conn, Ch: ch}, nil
 }
 
-// Close cierra la conexión con RabbitMQ
-func (r *RabbitMQConnection) Close() {
-	r.Ch.Close()
-	r.Conn.Close()
+// Close cierra el canal y la conexión con RabbitMQ
+func (r *RabbitMQConnection) Close() error {
+	chErr := r.Ch.Close()
+	if err := r.Conn.Close(); err != nil {
+		return err
+	}
+	return chErr
+}
+
+// Check informa un error si la conexión o el canal con RabbitMQ están cerrados.
+func (r *RabbitMQConnection) Check(ctx context.Context) error {
+	if r.Conn.IsClosed() {
+		return errors.New("conexión con RabbitMQ cerrada")
+	}
+	return nil
 }
@@ -3,95 +3,224 @@ package mq
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"profilego/internal/domain"
+	"profilego/internal/metrics"
 	"profilego/internal/service"
+	"profilego/pkg/logger"
+	"profilego/pkg/tracing"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Consumer procesa los mensajes de RabbitMQ
 type Consumer struct {
 	Conn           *RabbitMQConnection
 	ProfileService *service.ProfileService
+
+	tag     string
+	done    chan struct{}
+	running atomic.Bool
 }
 
 // NewConsumer crea un nuevo consumidor
 func NewConsumer(conn *RabbitMQConnection, profileService *service.ProfileService) *Consumer {
-	return &Consumer{Conn: conn, ProfileService: profileService}
+	return &Consumer{
+		Conn:           conn,
+		ProfileService: profileService,
+		tag:            "profilego-" + uuid.NewString(),
+		done:           make(chan struct{}),
+	}
 }
 
-// StartListening inicia la escucha de eventos de RabbitMQ
-func (c *Consumer) StartListening(queueName string) {
+// StartListening se suscribe a la cola y procesa los mensajes en una goroutine.
+// Los mensajes se confirman (ack) recién después de procesarse correctamente.
+func (c *Consumer) StartListening(queueName string) error {
+	// Un mensaje en vuelo a la vez: al apagar sólo hay que esperar ese
+	if err := c.Conn.Ch.Qos(1, 0, false); err != nil {
+		return err
+	}
+
 	msgs, err := c.Conn.Ch.Consume(
 		queueName, // Cola
-		"",        // Consumer
-		true,      // Auto-ack
+		c.tag,     // Consumer
+		false,     // Auto-ack
 		false,     // Exclusive
 		false,     // No-local
 		false,     // No-wait
 		nil,       // Args
 	)
 	if err != nil {
-		log.Fatalf("❌ Error consumiendo mensajes: %v", err)
+		return err
 	}
 
-	log.Printf("📢 Escuchando mensajes en cola '%s'...", queueName)
+	slog.Info("escuchando mensajes", "queue", queueName)
 
-	for msg := range msgs {
-		var event struct {
-			UserID        string `json:"userId"`
-			ProfileID     string `json:"profileId"`
-			ProfilePoints int    `json:"profilePoints"`
-		}
+	c.running.Store(true)
+	go func() {
+		defer close(c.done)
+		defer c.running.Store(false)
 
-		if err := json.Unmarshal(msg.Body, &event); err != nil {
-			log.Printf("❌ Error procesando mensaje: %v", err)
-			continue
+		for msg := range msgs {
+			c.handleDelivery(queueName, msg)
 		}
+	}()
 
-		/*log.Printf("📩 Mensaje recibido -> userId: %s, profileId: %s, profilePoints: %d",
-		event.UserID, event.ProfileID, event.ProfilePoints)*/
+	return nil
+}
 
-		// 🔎 Obtener el perfil actual del usuario
-		profile, err := c.ProfileService.Repo.GetByUserID(context.TODO(), event.UserID)
-		if err != nil {
-			log.Printf("❌ Error obteniendo perfil: %v", err)
-			continue
-		}
+// Running indica si la goroutine de consumo sigue activa.
+func (c *Consumer) Running() bool {
+	return c.running.Load()
+}
 
-		// 📊 Calcular los nuevos puntos sumando los actuales con los recibidos
-		//var totalPoints int
-		//log.Println("PUNTOS", profile.ProfilePoints, event.ProfilePoints, totalPoints)
-		//totalPoints = profile.ProfilePoints + event.ProfilePoints
-		//log.Println("PUNTOS", profile.ProfilePoints, event.ProfilePoints, totalPoints)
-		// 🔄 Mantener los valores actuales antes de actualizar
-		profileUpdate := &domain.Profile{
-			ProfilePoints: profile.ProfilePoints,
-			ProfileLevel:  profile.ProfileLevel, // 🔹 Mantiene el nivel actual
-		}
+// Check informa un error si el consumidor no está procesando mensajes.
+func (c *Consumer) Check(ctx context.Context) error {
+	if !c.Running() {
+		return errors.New("el consumidor no está activo")
+	}
+	return nil
+}
 
-		// 🚀 Verificar si el total de puntos supera los 1000 y aún no ha subido de nivel
-		if profile.ProfilePoints > 1000 {
-			log.Printf("🎯 userId %s alcanzó más de 1000 puntos. Evaluando nivel...", event.UserID)
+// Stop cancela la suscripción y espera a que termine el mensaje en proceso.
+func (c *Consumer) Stop(ctx context.Context) error {
+	if !c.running.Load() {
+		return nil
+	}
+	if err := c.Conn.Ch.Cancel(c.tag, false); err != nil {
+		return err
+	}
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-			// 📊 Subir el nivel a 1 solo si sigue en 0
-			newLevel := profile.ProfileLevel + 1 // 🚀 Aquí forzamos el nivel a 1
+// handleDelivery procesa un mensaje continuando la traza del productor. Lo confirma (ack) sólo si
+// se procesó; si no, lo rechaza según el error (ver settleFailed).
+func (c *Consumer) handleDelivery(queueName string, msg amqp.Delivery) {
+	// Continuar la traza del productor a partir de los headers del mensaje
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), headerCarrier(msg.Headers))
+	ctx, span := tracing.Tracer("mq").Start(ctx, "process "+queueName,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingDestinationName(queueName),
+			semconv.MessagingOperationTypeDeliver,
+		),
+	)
+	var err error
+	defer tracing.End(span, &err)
+
+	// Mismos campos de correlación que un request HTTP
+	requestID := msg.CorrelationId
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	ctx = logger.WithRequestID(ctx, requestID)
+	ctx = logger.WithContext(ctx, slog.Default().With(
+		"request_id", requestID,
+		"queue", queueName,
+		"trace_id", span.SpanContext().TraceID().String(),
+	))
+
+	if !msg.Timestamp.IsZero() {
+		metrics.MQConsumeLag.WithLabelValues(queueName).Observe(metrics.Since(msg.Timestamp))
+	}
 
-			if newLevel != profile.ProfileLevel { // ✅ Evita actualizar si ya estaba en el nivel correcto
-				profileUpdate.ProfileLevel = newLevel
-				log.Printf("🎉 userId %s sube a nivel %d", event.UserID, newLevel)
-			}
+	var event struct {
+		UserID        string `json:"userId"`
+		ProfileID     string `json:"profileId"`
+		ProfilePoints int    `json:"profilePoints"`
+	}
+
+	if err = json.Unmarshal(msg.Body, &event); err != nil {
+		logger.FromContext(ctx).Error("error procesando mensaje", "error", err)
+		metrics.MQConsumedTotal.WithLabelValues(queueName, "invalid").Inc()
+		msg.Nack(false, false) // mensaje inválido, no se reencola
+		return
+	}
+
+	ctx = logger.With(ctx, "user_id", event.UserID)
+	logger.FromContext(ctx).Debug("mensaje recibido", "profile_id", event.ProfileID, "profile_points", event.ProfilePoints)
+
+	if err = c.handlePointsEvent(ctx, event.UserID); err != nil {
+		result := settleFailed(msg, err)
+		logger.FromContext(ctx).Error("error procesando evento de puntos", "error", err, "result", result)
+		metrics.MQConsumedTotal.WithLabelValues(queueName, result).Inc()
+		return
+	}
+	metrics.MQConsumedTotal.WithLabelValues(queueName, "processed").Inc()
+	msg.Ack(false)
+}
+
+// settleFailed rechaza un mensaje que no se pudo procesar y devuelve el resultado para las
+// métricas. Los errores permanentes (el perfil no existe, datos inválidos) no se reencolan; los demás (p. ej. la
+// base no responde) se reencolan una vez y, si vuelven a fallar, se descartan o van a la
+// dead-letter exchange si la cola tiene una configurada.
+func settleFailed(msg amqp.Delivery, err error) string {
+	switch {
+	case errors.Is(err, domain.ErrNotFound), errors.Is(err, domain.ErrValidation):
+		msg.Nack(false, false)
+		return "rejected"
+	case !msg.Redelivered:
+		msg.Nack(false, true)
+		return "requeued"
+	default:
+		msg.Nack(false, false)
+		return "failed"
+	}
+}
+
+// handlePointsEvent evalúa si el usuario sube de nivel después de sumar puntos.
+func (c *Consumer) handlePointsEvent(ctx context.Context, userID string) error {
+	// Obtener el perfil actual del usuario
+	log := logger.FromContext(ctx)
+	profile, err := c.ProfileService.Repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error obteniendo perfil: %w", err)
+	}
+	if profile == nil {
+		return fmt.Errorf("%w: userId %s", service.ErrProfileNotFound, userID)
+	}
+
+	// Mantener los valores actuales antes de actualizar
+	profileUpdate := &domain.Profile{
+		ProfilePoints: profile.ProfilePoints,
+		ProfileLevel:  profile.ProfileLevel, // Mantiene el nivel actual
+	}
+
+	// Verificar si el total de puntos supera los 1000
+	if profile.ProfilePoints > 1000 {
+		log.Debug("el usuario superó los 1000 puntos, evaluando nivel", "profile_points", profile.ProfilePoints)
+
+		// Sube un nivel por cada evento que supera el umbral
+		newLevel := profile.ProfileLevel + 1
+
+		if newLevel != profile.ProfileLevel { // Evita actualizar si ya estaba en el nivel correcto
+			profileUpdate.ProfileLevel = newLevel
+			log.Info("el usuario sube de nivel", "profile_level", newLevel)
 		}
+	}
 
-		// 📝 Guardar la actualización solo si hubo cambios
-		if profileUpdate.ProfilePoints != profile.ProfilePoints || profileUpdate.ProfileLevel != profile.ProfileLevel {
-			err = c.ProfileService.UpdateProfileLevel(context.TODO(), event.UserID, profileUpdate)
-			if err != nil {
-				log.Printf("❌ Error actualizando nivel: %v", err)
-			} else {
-				log.Printf("✅ Nivel actualizado a %d para userId %s", profileUpdate.ProfileLevel, event.UserID)
-			}
-		} else {
-			log.Printf("🔵 No hay cambios en puntos o nivel. No se actualiza el perfil.")
+	// Guardar la actualización solo si hubo cambios
+	if profileUpdate.ProfilePoints != profile.ProfilePoints || profileUpdate.ProfileLevel != profile.ProfileLevel {
+		err = c.ProfileService.UpdateProfileLevel(ctx, userID, profileUpdate)
+		if err != nil {
+			return fmt.Errorf("error actualizando nivel: %w", err)
 		}
+		log.Info("nivel actualizado", "profile_level", profileUpdate.ProfileLevel)
+	} else {
+		log.Debug("no hay cambios en puntos o nivel, no se actualiza el perfil")
 	}
+	return nil
 }
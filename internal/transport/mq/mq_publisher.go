@@ -1,24 +1,71 @@
 package mq
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"sync"
+	"time"
+
+	"profilego/internal/metrics"
+	"profilego/pkg/logger"
+	"profilego/pkg/tracing"
 
 	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Publisher define el publicador de RabbitMQ
 type Publisher struct {
-	conn *amqp.Connection
+	conn  *amqp.Connection
+	queue string // cola por defecto para los eventos de perfil
+
+	mu       sync.RWMutex
+	closed   bool
+	inFlight sync.WaitGroup
 }
 
+// ErrPublisherClosed se devuelve al publicar después de Close.
+var ErrPublisherClosed = errors.New("publisher cerrado")
+
 // NewPublisher crea una nueva instancia de Publisher
-func NewPublisher(conn *amqp.Connection) *Publisher {
-	return &Publisher{conn: conn}
+func NewPublisher(conn *amqp.Connection, queue string) *Publisher {
+	return &Publisher{conn: conn, queue: queue}
 }
 
 // PublishMessage publica un mensaje en una cola de RabbitMQ
-func (p *Publisher) PublishMessage(queueName string, message []byte) error {
+func (p *Publisher) PublishMessage(ctx context.Context, queueName string, message []byte) (err error) {
+	ctx, span := tracing.Tracer("mq").Start(ctx, "publish "+queueName,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingDestinationName(queueName),
+			semconv.MessagingOperationTypePublish,
+		),
+	)
+	defer tracing.End(span, &err)
+
+	start := time.Now()
+	defer func() {
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		metrics.MQPublishTotal.WithLabelValues(queueName, result).Inc()
+		metrics.MQPublishDuration.WithLabelValues(queueName).Observe(metrics.Since(start))
+	}()
+
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return ErrPublisherClosed
+	}
+	p.inFlight.Add(1)
+	p.mu.RUnlock()
+	defer p.inFlight.Done()
+
 	ch, err := p.conn.Channel()
 	if err != nil {
 		return err
@@ -37,29 +84,56 @@ func (p *Publisher) PublishMessage(queueName string, message []byte) error {
 		return err
 	}
 
+	// Propagar el contexto de traza para que el consumidor continúe la misma traza
+	headers := amqp.Table{}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(headers))
+
 	err = ch.Publish(
 		"",     // exchange
 		q.Name, // routing key
 		false,  // mandatory
 		false,  // immediate
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        message,
+			ContentType:   "application/json",
+			CorrelationId: logger.RequestID(ctx), // permite seguir el evento en los logs del consumidor
+			Timestamp:     time.Now(),            // usado por el consumidor para medir el lag
+			Headers:       headers,
+			Body:          message,
 		},
 	)
 
 	if err != nil {
-		log.Println("Error publicando mensaje en RabbitMQ:", err)
+		logger.FromContext(ctx).Error("error publicando mensaje en RabbitMQ", "queue", queueName, "error", err)
 		return err
 	}
 
-	log.Printf("✅ Mensaje publicado en la cola: %s", queueName)
+	logger.FromContext(ctx).Info("mensaje publicado", "queue", queueName)
 	return nil
 }
 
+// Close deja de aceptar publicaciones y espera a que terminen las que están en curso.
+func (p *Publisher) Close(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // PublishProfileLevelUpdate - Método faltante para implementar la interfaz service.Publisher
 // func (p *Publisher) PublishProfileLevelUpdate(userID string, level int) error { //kike modifico parcialmente esto (lo comentado en el cuerpo)
-func (p *Publisher) PublishProfilePoints(profileid string, profilepoints int) error {
+func (p *Publisher) PublishProfilePoints(ctx context.Context, profileid string, profilepoints int) error {
 	message := map[string]interface{}{
 		//"userID": userID,
 		//"level":  level, // Asegurarse de que es un entero
@@ -72,10 +146,10 @@ func (p *Publisher) PublishProfilePoints(profileid string, profilepoints int) er
 		return err
 	}
 
-	return p.PublishMessage("direct_profile", body)
+	return p.PublishMessage(ctx, p.queue, body)
 }
 
-func (p *Publisher) PublishProfileLevelUpdate(profileid string, profileLevel int) error {
+func (p *Publisher) PublishProfileLevelUpdate(ctx context.Context, profileid string, profileLevel int) error {
 	message := map[string]interface{}{
 		//"userID": userID,
 		//"level":  level, // Asegurarse de que es un entero
@@ -88,5 +162,5 @@ func (p *Publisher) PublishProfileLevelUpdate(profileid string, profileLevel int
 		return err
 	}
 
-	return p.PublishMessage("direct_profile", body)
+	return p.PublishMessage(ctx, p.queue, body)
 }
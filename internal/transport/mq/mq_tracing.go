@@ -0,0 +1,41 @@
+package mq
+
+import (
+	"fmt"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// headerCarrier adapta amqp.Table a propagation.TextMapCarrier para inyectar y
+// extraer el contexto de traza W3C (traceparent/tracestate) en los headers del mensaje.
+type headerCarrier amqp.Table
+
+var _ propagation.TextMapCarrier = headerCarrier{}
+
+func (h headerCarrier) Get(key string) string {
+	v, ok := h[key]
+	if !ok {
+		return ""
+	}
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	default:
+		return fmt.Sprint(s)
+	}
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h[key] = value
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"profilego/internal/config"
+	"profilego/pkg/db"
+)
+
+// runMigrate implementa el subcomando `profilego migrate up|down [n]|status [flags]`.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal("uso: profilego migrate up|down [n]|status [flags]")
+	}
+	action, args := args[0], args[1:]
+
+	steps := 1
+	if action == "down" && len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			steps, args = n, args[1:]
+		}
+	}
+
+	cfg, err := config.Load(args)
+	if err != nil {
+		log.Fatalf("❌ Error cargando la configuración: %v", err)
+	}
+
+	database, err := db.InitDB(cfg.DB.DSN(), cfg.DB.ConnectTimeout)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer database.Close()
+
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		log.Fatalf("❌ Error leyendo migraciones: %v", err)
+	}
+
+	ctx := context.Background()
+	switch action {
+	case "up":
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatalf("❌ Error aplicando migraciones: %v", err)
+		}
+		fmt.Printf("✅ %d migraciones aplicadas\n", n)
+	case "down":
+		n, err := migrator.Down(ctx, steps)
+		if err != nil {
+			log.Fatalf("❌ Error revirtiendo migraciones: %v", err)
+		}
+		fmt.Printf("✅ %d migraciones revertidas\n", n)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("❌ Error consultando migraciones: %v", err)
+		}
+		for _, s := range statuses {
+			applied := "pendiente"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, applied)
+		}
+	default:
+		log.Fatalf("acción de migración desconocida: %s", action)
+	}
+}
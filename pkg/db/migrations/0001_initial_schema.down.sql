@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS address;
+DROP TABLE IF EXISTS profile;
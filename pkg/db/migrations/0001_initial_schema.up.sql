@@ -0,0 +1,34 @@
+-- Esquema inicial de perfiles y direcciones.
+CREATE TABLE IF NOT EXISTS profile (
+    profileId       UUID         PRIMARY KEY,
+    userId          VARCHAR(255) NOT NULL,
+    profileImage    TEXT,
+    profileName     VARCHAR(255) NOT NULL,
+    profileLevel    INTEGER      NOT NULL DEFAULT 0,
+    profilePoints   INTEGER      NOT NULL DEFAULT 0,
+    profileMail     VARCHAR(255) NOT NULL,
+    phone           VARCHAR(50)  NOT NULL,
+    CUIL            VARCHAR(20),
+    fiscalAdress    VARCHAR(255),
+    fiscalCondition VARCHAR(100),
+    IIBB            VARCHAR(50),
+    creationDate    TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updatedDate     TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS profile_userid_key ON profile (userId);
+
+CREATE TABLE IF NOT EXISTS address (
+    addressId     UUID         PRIMARY KEY,
+    CP            VARCHAR(20)  NOT NULL,
+    street        VARCHAR(255) NOT NULL,
+    number        INTEGER      NOT NULL,
+    floor         VARCHAR(20),
+    mainAddress   BOOLEAN      NOT NULL DEFAULT FALSE,
+    creationDate  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updatedDate   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    activeAddress BOOLEAN      NOT NULL DEFAULT TRUE,
+    idProfile     UUID         NOT NULL REFERENCES profile (profileId) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS address_idprofile_idx ON address (idProfile);
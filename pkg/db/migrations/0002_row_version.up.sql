@@ -0,0 +1,3 @@
+-- Versión de fila para control de concurrencia optimista (ETag / If-Match).
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE address ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,15 @@
+ALTER TABLE profile DROP CONSTRAINT IF EXISTS profile_iibbregime_check;
+ALTER TABLE profile DROP CONSTRAINT IF EXISTS profile_fiscalcondition_check;
+
+-- Se restauran los valores originales guardados por el up; los perfiles cargados después
+-- conservan el código o el número actual.
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS IIBB VARCHAR(50);
+UPDATE profile SET IIBB = COALESCE(iibb_legacy, iibbNumber),
+                   fiscalCondition = COALESCE(fiscalCondition_legacy, fiscalCondition);
+
+ALTER TABLE profile DROP COLUMN IF EXISTS fiscalCondition_legacy;
+ALTER TABLE profile DROP COLUMN IF EXISTS iibb_legacy;
+ALTER TABLE profile DROP COLUMN IF EXISTS iibbJurisdiction;
+ALTER TABLE profile DROP COLUMN IF EXISTS iibbNumber;
+ALTER TABLE profile DROP COLUMN IF EXISTS iibbRegime;
+ALTER TABLE profile DROP COLUMN IF EXISTS monotributoCategory;
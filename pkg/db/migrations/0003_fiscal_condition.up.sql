@@ -0,0 +1,38 @@
+-- Condición fiscal e Ingresos Brutos como valores de catálogo en columnas propias.
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS monotributoCategory CHAR(1);
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS iibbRegime          VARCHAR(30);
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS iibbNumber          VARCHAR(20);
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS iibbJurisdiction    VARCHAR(3);
+
+-- Los valores originales se conservan tal cual para que el down pueda restaurarlos: la
+-- traducción descarta los textos que no reconoce.
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS fiscalCondition_legacy VARCHAR(100);
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS iibb_legacy            VARCHAR(50);
+UPDATE profile SET fiscalCondition_legacy = fiscalCondition, iibb_legacy = IIBB
+WHERE fiscalCondition IS NOT NULL OR IIBB IS NOT NULL;
+
+-- Los textos libres conocidos se traducen al código; el resto queda en NULL y se vuelve a
+-- pedir la próxima vez que se actualicen los datos fiscales.
+UPDATE profile SET fiscalCondition = CASE lower(trim(fiscalCondition))
+        WHEN 'responsable inscripto'     THEN 'responsable_inscripto'
+        WHEN 'iva responsable inscripto' THEN 'responsable_inscripto'
+        WHEN 'monotributista'            THEN 'monotributista'
+        WHEN 'responsable monotributo'   THEN 'monotributista'
+        WHEN 'exento'                    THEN 'exento'
+        WHEN 'iva sujeto exento'         THEN 'exento'
+        WHEN 'consumidor final'          THEN 'consumidor_final'
+        WHEN 'no responsable'            THEN 'no_responsable'
+        WHEN 'iva no responsable'        THEN 'no_responsable'
+    END
+WHERE fiscalCondition IS NOT NULL;
+
+-- El número anterior no tenía régimen ni jurisdicción: se asume contribuyente local.
+UPDATE profile SET iibbRegime = 'local', iibbNumber = regexp_replace(IIBB, '[^0-9]', '', 'g')
+WHERE IIBB ~ '^[0-9 .-]+$';
+
+ALTER TABLE profile DROP COLUMN IF EXISTS IIBB;
+
+ALTER TABLE profile ADD CONSTRAINT profile_fiscalcondition_check CHECK (fiscalCondition IN (
+    'responsable_inscripto', 'monotributista', 'exento', 'consumidor_final', 'no_responsable'));
+ALTER TABLE profile ADD CONSTRAINT profile_iibbregime_check CHECK (iibbRegime IN (
+    'local', 'convenio_multilateral', 'exento'));
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS address_main_idx;
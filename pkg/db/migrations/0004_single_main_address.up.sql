@@ -0,0 +1,14 @@
+-- Un perfil puede tener varias direcciones activas pero a lo sumo una principal.
+
+-- Una dirección dada de baja no puede ser la principal.
+UPDATE address SET mainAddress = FALSE WHERE mainAddress AND NOT activeAddress;
+
+-- Si un perfil tiene varias principales se conserva la modificada más recientemente.
+UPDATE address a SET mainAddress = FALSE
+WHERE a.mainAddress AND a.activeAddress AND EXISTS (
+    SELECT 1 FROM address b
+    WHERE b.idProfile = a.idProfile AND b.mainAddress AND b.activeAddress
+      AND (b.updatedDate, b.addressId) > (a.updatedDate, a.addressId)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS address_main_idx ON address (idProfile) WHERE mainAddress AND activeAddress;
@@ -0,0 +1,6 @@
+ALTER TABLE address DROP COLUMN IF EXISTS addressReferences;
+ALTER TABLE address DROP COLUMN IF EXISTS betweenStreets;
+ALTER TABLE address DROP COLUMN IF EXISTS country;
+ALTER TABLE address DROP COLUMN IF EXISTS apartment;
+ALTER TABLE address DROP COLUMN IF EXISTS locality;
+ALTER TABLE address DROP COLUMN IF EXISTS province;
@@ -0,0 +1,14 @@
+-- Dirección estructurada: provincia (ISO 3166-2:AR), localidad, departamento, país y referencias.
+-- "references" es palabra reservada, por eso la columna se llama addressReferences.
+ALTER TABLE address ADD COLUMN IF NOT EXISTS province          VARCHAR(5)   NOT NULL DEFAULT '';
+ALTER TABLE address ADD COLUMN IF NOT EXISTS locality          VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE address ADD COLUMN IF NOT EXISTS apartment         VARCHAR(20);
+ALTER TABLE address ADD COLUMN IF NOT EXISTS country           CHAR(2)      NOT NULL DEFAULT 'AR';
+ALTER TABLE address ADD COLUMN IF NOT EXISTS betweenStreets    VARCHAR(255);
+ALTER TABLE address ADD COLUMN IF NOT EXISTS addressReferences VARCHAR(255);
+
+-- Los CPA existentes se normalizan y su letra indica la provincia. El resto de las direcciones
+-- queda sin provincia ni localidad y se completa la próxima vez que se modifiquen.
+UPDATE address SET CP = upper(replace(CP, ' ', '')) WHERE CP IS NOT NULL;
+UPDATE address SET province = 'AR-' || substr(CP, 1, 1)
+WHERE CP ~ '^[A-HJ-NP-Z][0-9]{4}[A-Z]{3}$';
@@ -0,0 +1,12 @@
+-- Catálogo de localidades y códigos postales para autocompletar y validar direcciones.
+-- Se carga con `profilego geo load`. searchKey es el nombre normalizado (geo.SearchKey).
+CREATE TABLE IF NOT EXISTS locality (
+    province  VARCHAR(5)   NOT NULL,
+    name      VARCHAR(255) NOT NULL,
+    searchKey VARCHAR(255) NOT NULL,
+    cp        CHAR(4)      NOT NULL,
+    PRIMARY KEY (province, cp, searchKey)
+);
+
+CREATE INDEX IF NOT EXISTS locality_cp_idx ON locality (cp text_pattern_ops);
+CREATE INDEX IF NOT EXISTS locality_searchkey_idx ON locality (searchKey text_pattern_ops);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS address_geocode_pending_idx;
+ALTER TABLE address DROP CONSTRAINT IF EXISTS address_geocodequality_check;
+ALTER TABLE address DROP COLUMN IF EXISTS geocodeQuality;
+ALTER TABLE address DROP COLUMN IF EXISTS longitude;
+ALTER TABLE address DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,11 @@
+-- Coordenadas de las direcciones, completadas en segundo plano por el geocodificador.
+ALTER TABLE address ADD COLUMN IF NOT EXISTS latitude       DOUBLE PRECISION;
+ALTER TABLE address ADD COLUMN IF NOT EXISTS longitude      DOUBLE PRECISION;
+ALTER TABLE address ADD COLUMN IF NOT EXISTS geocodeQuality VARCHAR(20) NOT NULL DEFAULT 'pending';
+
+ALTER TABLE address ADD CONSTRAINT address_geocodequality_check CHECK (geocodeQuality IN (
+    'pending', 'exact', 'street', 'locality', 'not_found'));
+
+-- Las direcciones pendientes se buscan con `profilego geo regeocode`.
+CREATE INDEX IF NOT EXISTS address_geocode_pending_idx ON address (updatedDate)
+    WHERE activeAddress AND geocodeQuality = 'pending';
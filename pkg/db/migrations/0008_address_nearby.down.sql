@@ -0,0 +1 @@
+DROP INDEX IF EXISTS address_coordinates_idx;
@@ -0,0 +1,7 @@
+-- Búsqueda de direcciones cercanas a un punto sin PostGIS. La consulta filtra primero por un
+-- rectángulo de latitud y longitud (geo.BoundingBox) y sólo calcula la distancia de haversine
+-- de las filas que quedan dentro. El índice resuelve el rango de latitud y descarta por
+-- longitud sin leer la tabla; sólo incluye direcciones activas con coordenadas.
+-- La dirección más cercana de un perfil usa address_idprofile_idx: son pocas filas.
+CREATE INDEX IF NOT EXISTS address_coordinates_idx ON address (latitude, longitude)
+    WHERE activeAddress AND latitude IS NOT NULL;
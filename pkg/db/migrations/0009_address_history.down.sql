@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS address_history_update ON address;
+DROP TRIGGER IF EXISTS address_history_insert ON address;
+DROP FUNCTION IF EXISTS address_history_record();
+DROP TABLE IF EXISTS address_history;
+DROP FUNCTION IF EXISTS address_history_immutable();
@@ -0,0 +1,89 @@
+-- Historial de direcciones: cada versión de una dirección queda guardada como una fila
+-- inmutable con quién (changedBy, el userId que hizo el cambio) y cuándo la generó. Las
+-- coordenadas no forman parte del historial: se derivan de la dirección.
+-- No tiene FK a profile: al borrar un perfil sus direcciones se borran pero el historial
+-- se conserva (es justamente lo que se quiere poder consultar después).
+CREATE TABLE IF NOT EXISTS address_history (
+    addressId         UUID         NOT NULL,
+    version           INTEGER      NOT NULL,
+    operation         VARCHAR(20)  NOT NULL CHECK (operation IN (
+                          'create', 'update', 'deactivate', 'reactivate', 'snapshot')),
+    changedBy         VARCHAR(255),
+    changedAt         TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    CP                VARCHAR(20)  NOT NULL,
+    street            VARCHAR(255) NOT NULL,
+    number            INTEGER      NOT NULL,
+    floor             VARCHAR(20),
+    apartment         VARCHAR(20),
+    locality          VARCHAR(255) NOT NULL,
+    province          VARCHAR(5)   NOT NULL,
+    country           CHAR(2)      NOT NULL,
+    betweenStreets    VARCHAR(255),
+    addressReferences VARCHAR(255),
+    mainAddress       BOOLEAN      NOT NULL,
+    activeAddress     BOOLEAN      NOT NULL,
+    creationDate      TIMESTAMPTZ  NOT NULL,
+    updatedDate       TIMESTAMPTZ  NOT NULL,
+    idProfile         UUID         NOT NULL,
+    PRIMARY KEY (addressId, version)
+);
+
+CREATE INDEX IF NOT EXISTS address_history_idprofile_idx ON address_history (idProfile);
+
+-- Cada INSERT y cada UPDATE que cambia la versión de una dirección agrega una fila. Los cambios
+-- que no la incrementan (las coordenadas) no generan historial. El repositorio informa el
+-- usuario con set_config('profilego.actor', userId, true) dentro de la transacción.
+CREATE OR REPLACE FUNCTION address_history_record() RETURNS trigger AS $$
+DECLARE
+    op VARCHAR(20) := 'update';
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        op := 'create';
+    ELSIF OLD.activeAddress AND NOT NEW.activeAddress THEN
+        op := 'deactivate';
+    ELSIF NOT OLD.activeAddress AND NEW.activeAddress THEN
+        op := 'reactivate';
+    END IF;
+
+    INSERT INTO address_history (addressId, version, operation, changedBy, changedAt, CP, street, number,
+        floor, apartment, locality, province, country, betweenStreets, addressReferences, mainAddress,
+        activeAddress, creationDate, updatedDate, idProfile)
+    VALUES (NEW.addressId, NEW.version, op, NULLIF(current_setting('profilego.actor', true), ''), now(),
+        NEW.CP, NEW.street, NEW.number, NEW.floor, NEW.apartment, NEW.locality, NEW.province, NEW.country,
+        NEW.betweenStreets, NEW.addressReferences, NEW.mainAddress, NEW.activeAddress, NEW.creationDate,
+        NEW.updatedDate, NEW.idProfile);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS address_history_insert ON address;
+CREATE TRIGGER address_history_insert AFTER INSERT ON address
+    FOR EACH ROW EXECUTE FUNCTION address_history_record();
+
+DROP TRIGGER IF EXISTS address_history_update ON address;
+CREATE TRIGGER address_history_update AFTER UPDATE ON address
+    FOR EACH ROW WHEN (NEW.version IS DISTINCT FROM OLD.version) EXECUTE FUNCTION address_history_record();
+
+-- Las filas del historial no se modifican ni se borran.
+CREATE OR REPLACE FUNCTION address_history_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'address_history no admite modificaciones (%)', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS address_history_immutable ON address_history;
+CREATE TRIGGER address_history_immutable BEFORE UPDATE OR DELETE ON address_history
+    FOR EACH ROW EXECUTE FUNCTION address_history_immutable();
+
+-- Las direcciones existentes arrancan el historial con su estado actual. Las versiones anteriores
+-- no se conocen, así que la fila 'snapshot' se fecha en creationDate: un as-of anterior a la
+-- migración devuelve ese estado (el único conocido) y operation = 'snapshot' indica que puede
+-- no ser el que estaba vigente en ese momento.
+INSERT INTO address_history (addressId, version, operation, changedAt, CP, street, number, floor, apartment,
+    locality, province, country, betweenStreets, addressReferences, mainAddress, activeAddress,
+    creationDate, updatedDate, idProfile)
+SELECT addressId, version, 'snapshot', creationDate, CP, street, number, floor, apartment,
+    locality, province, country, betweenStreets, addressReferences, mainAddress, activeAddress,
+    creationDate, updatedDate, idProfile
+FROM address
+ON CONFLICT DO NOTHING;
@@ -1,41 +1,29 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
-// InitDB inicializa la conexión con PostgreSQL
-func InitDB() (*sql.DB, error) {
-	// Obtener variables de entorno
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-
-	// Crear la cadena de conexión
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
-
+// InitDB abre la conexión con PostgreSQL y verifica que responda dentro del timeout indicado.
+func InitDB(dsn string, timeout time.Duration) (*sql.DB, error) {
 	// Abrir conexión con PostgreSQL
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		log.Fatalf("Error al abrir la conexión con la base de datos: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("error al abrir la conexión con la base de datos: %w", err)
 	}
 
 	// Verificar la conexión
-	err = db.Ping()
-	if err != nil {
-		log.Fatalf("Error al conectar con la base de datos: %v", err)
-		return nil, err
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("no se pudo conectar a la base de datos: %w", err)
 	}
 
-	log.Println("✅ Conexión exitosa a PostgreSQL")
 	return db, nil
 }
@@ -0,0 +1,98 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// hook es un paso del apagado ordenado.
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager coordina el apagado ordenado de los componentes del servicio.
+// Los hooks se ejecutan en el orden en que fueron registrados y comparten un mismo deadline.
+type Manager struct {
+	timeout time.Duration
+
+	mu    sync.Mutex
+	hooks []hook
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	cause    error
+}
+
+// New crea un Manager cuyo apagado completo no puede superar `timeout`.
+func New(timeout time.Duration) *Manager {
+	return &Manager{timeout: timeout, stop: make(chan struct{})}
+}
+
+// OnShutdown registra un paso de apagado. Se ejecutan en orden de registro.
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, fn: fn})
+}
+
+// Go ejecuta fn en una goroutine; si termina con error se dispara el apagado.
+func (m *Manager) Go(name string, fn func() error) {
+	go func() {
+		if err := fn(); err != nil {
+			m.trigger(fmt.Errorf("%s: %w", name, err))
+		}
+	}()
+}
+
+// Wait bloquea hasta recibir SIGINT/SIGTERM o hasta que falle un componente lanzado con Go.
+// Devuelve el error que provocó el apagado, o nil si fue por señal.
+func (m *Manager) Wait() error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case sig := <-signals:
+		slog.Info("señal recibida, iniciando apagado", "signal", sig.String())
+		m.trigger(nil)
+	case <-m.stop:
+	}
+	return m.cause
+}
+
+// Shutdown ejecuta los hooks registrados en orden dentro del deadline configurado.
+// Un hook que falla no impide que se ejecuten los siguientes.
+func (m *Manager) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	m.mu.Lock()
+	hooks := append([]hook(nil), m.hooks...)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, h := range hooks {
+		if err := h.fn(ctx); err != nil {
+			slog.Error("error en el apagado", "component", h.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		slog.Info("componente cerrado", "component", h.name)
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) trigger(cause error) {
+	m.stopOnce.Do(func() {
+		m.cause = cause
+		close(m.stop)
+	})
+}
@@ -1,32 +1,94 @@
 package logger
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
 )
 
-// Logger personalizado
-var Logger *log.Logger
+// level es compartido por todos los loggers creados con Init y puede cambiarse en caliente.
+var level = new(slog.LevelVar)
 
-// InitLogger inicializa el logger
-func InitLogger() {
-	// Crear archivo de logs
-	file, err := os.OpenFile("app.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalf("Error al abrir el archivo de logs: %v", err)
+type ctxKey int
+
+const (
+	loggerKey ctxKey = iota
+	requestIDKey
+)
+
+// Init configura el logger por defecto de la aplicación.
+// format puede ser "json" o "text"; levelName es debug, info, warn o error.
+func Init(format, levelName string) (*slog.Logger, error) {
+	return InitWriter(os.Stdout, format, levelName)
+}
+
+// InitWriter es igual que Init pero escribe en w.
+func InitWriter(w io.Writer, format, levelName string) (*slog.Logger, error) {
+	if err := SetLevel(levelName); err != nil {
+		return nil, err
 	}
 
-	// Inicializar logger
-	Logger = log.New(file, "LOG: ", log.Ldate|log.Ltime|log.Lshortfile)
-	log.Println("✅ Logger inicializado")
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "json", "":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("formato de log inválido: %q", format)
+	}
+
+	l := slog.New(handler)
+	slog.SetDefault(l)
+	return l, nil
+}
+
+// SetLevel cambia el nivel de log de todos los loggers en tiempo de ejecución.
+func SetLevel(levelName string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(levelName)); err != nil {
+		return fmt.Errorf("nivel de log inválido: %q", levelName)
+	}
+	level.Set(l)
+	return nil
+}
+
+// Level devuelve el nivel de log actual.
+func Level() slog.Level {
+	return level.Level()
+}
+
+// WithContext guarda el logger en el contexto.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext devuelve el logger guardado en el contexto o el logger por defecto.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+// With agrega atributos al logger del contexto y devuelve el contexto actualizado.
+func With(ctx context.Context, args ...any) context.Context {
+	return WithContext(ctx, FromContext(ctx).With(args...))
 }
 
-// LogInfo imprime logs de nivel informativo
-func LogInfo(msg string) {
-	Logger.Println("INFO: " + msg)
+// WithRequestID guarda el identificador de correlación en el contexto.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
 }
 
-// LogError imprime logs de errores
-func LogError(msg string) {
-	Logger.Println("ERROR: " + msg)
+// RequestID devuelve el identificador de correlación del contexto, o "" si no hay.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
 }